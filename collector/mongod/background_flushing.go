@@ -1,42 +1,38 @@
 package collector_mongod
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	backgroundFlushingflushesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	backgroundFlushingflushesTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "background_flushing",
 		Name:      "flushes_total",
-		Help:      "flushes is a counter that collects the number of times the database has flushed all writes to disk. This value will grow as database runs for longer periods of time",
-	})
-	backgroundFlushingtotalMilliseconds = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	backgroundFlushingtotalMilliseconds = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "background_flushing",
 		Name:      "total_milliseconds",
-		Help:      "The total_ms value provides the total number of milliseconds (ms) that the mongod processes have spent writing (i.e. flushing) data to disk. Because this is an absolute value, consider the value offlushes and average_ms to provide better context for this datum",
-	})
-	backgroundFlushingaverageMilliseconds = prometheus.NewGauge(prometheus.GaugeOpts{
+	}, nil)
+	backgroundFlushingaverageMilliseconds = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "background_flushing",
 		Name:      "average_milliseconds",
-		Help:      `The average_ms value describes the relationship between the number of flushes and the total amount of time that the database has spent writing data to disk. The larger flushes is, the more likely this value is likely to represent a "normal," time; however, abnormal data can skew this value`,
-	})
-	backgroundFlushinglastMilliseconds = prometheus.NewGauge(prometheus.GaugeOpts{
+	}, nil)
+	backgroundFlushinglastMilliseconds = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "background_flushing",
 		Name:      "last_milliseconds",
-		Help:      "The value of the last_ms field is the amount of time, in milliseconds, that the last flush operation took to complete. Use this value to verify that the current performance of the server and is in line with the historical data provided by average_ms and total_ms",
-	})
-	backgroundFlushinglastFinishedTime = prometheus.NewGauge(prometheus.GaugeOpts{
+	}, nil)
+	backgroundFlushinglastFinishedTime = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "background_flushing",
 		Name:      "last_finished_time",
-		Help:      "The last_finished field provides a timestamp of the last completed flush operation in the ISODateformat. If this value is more than a few minutes old relative to your server’s current time and accounting for differences in time zone, restarting the database may result in some data loss",
-	})
+	}, nil)
 )
 
 // FlushStats is the flush stats metrics
@@ -49,25 +45,10 @@ type FlushStats struct {
 }
 
 // Export exports the metrics for prometheus.
-func (flushStats *FlushStats) Export(ch chan<- prometheus.Metric) {
-	backgroundFlushingflushesTotal.Add(flushStats.Flushes)
-	backgroundFlushingtotalMilliseconds.Add(flushStats.TotalMs)
-	backgroundFlushingaverageMilliseconds.Set(flushStats.AverageMs)
-	backgroundFlushinglastMilliseconds.Set(flushStats.LastMs)
-	backgroundFlushinglastFinishedTime.Set(float64(flushStats.LastFinished.Unix()))
-
-	backgroundFlushingflushesTotal.Collect(ch)
-	backgroundFlushingtotalMilliseconds.Collect(ch)
-	backgroundFlushingaverageMilliseconds.Collect(ch)
-	backgroundFlushinglastMilliseconds.Collect(ch)
-	backgroundFlushinglastFinishedTime.Collect(ch)
-}
-
-// Describe describes the metrics for prometheus
-func (flushStats *FlushStats) Describe(ch chan<- *prometheus.Desc) {
-	backgroundFlushingflushesTotal.Describe(ch)
-	backgroundFlushingtotalMilliseconds.Describe(ch)
-	backgroundFlushingaverageMilliseconds.Describe(ch)
-	backgroundFlushinglastMilliseconds.Describe(ch)
-	backgroundFlushinglastFinishedTime.Describe(ch)
+func (flushStats *FlushStats) Export(ch *shared.MetricChannel) {
+	ch.Send(backgroundFlushingflushesTotal, flushStats.Flushes)
+	ch.Send(backgroundFlushingtotalMilliseconds, flushStats.TotalMs)
+	ch.Send(backgroundFlushingaverageMilliseconds, flushStats.AverageMs)
+	ch.Send(backgroundFlushinglastMilliseconds, flushStats.LastMs)
+	ch.Send(backgroundFlushinglastFinishedTime, float64(flushStats.LastFinished.Unix()))
 }
@@ -1,23 +1,22 @@
 package collector_mongod
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	connections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	connections = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Name:      "connections",
-		Help:      "The connections sub document data regarding the current status of incoming connections and availability of the database server. Use these values to assess the current load and capacity requirements of the server",
 	}, []string{"state"})
 )
 var (
-	connectionsMetricsCreatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	connectionsMetricsCreatedTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "connections_metrics",
 		Name:      "created_total",
-		Help:      "totalCreated provides a count of all incoming connections created to the server. This number includes connections that have since closed",
-	})
+	}, nil)
 )
 
 // ConnectionStats are connections metrics
@@ -28,17 +27,9 @@ type ConnectionStats struct {
 }
 
 // Export exports the data to prometheus.
-func (connectionStats *ConnectionStats) Export(ch chan<- prometheus.Metric) {
-	connections.WithLabelValues("current").Set(connectionStats.Current)
-	connections.WithLabelValues("available").Set(connectionStats.Available)
-	connections.Collect(ch)
+func (connectionStats *ConnectionStats) Export(ch *shared.MetricChannel) {
+	ch.Send(connections, connectionStats.Current, "current")
+	ch.Send(connections, connectionStats.Available, "available")
 
-	connectionsMetricsCreatedTotal.Add(connectionStats.TotalCreated)
-	connectionsMetricsCreatedTotal.Collect(ch)
-}
-
-// Describe describes the metrics for prometheus
-func (connectionStats *ConnectionStats) Describe(ch chan<- *prometheus.Desc) {
-	connections.Describe(ch)
-	connectionsMetricsCreatedTotal.Describe(ch)
+	ch.Send(connectionsMetricsCreatedTotal, connectionStats.TotalCreated)
 }
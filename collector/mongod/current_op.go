@@ -0,0 +1,112 @@
+package collector_mongod
+
+import (
+	"strconv"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	currentOpActive = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "current_op",
+		Name:      "active",
+	}, []string{"op", "ns", "waiting_for_lock"})
+	currentOpSecondsRunning = shared.NewHistogramDesc(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "current_op",
+		Name:      "running_seconds",
+		Buckets:   []float64{1, 5, 10, 30, 60, 300, 600, 1800, 3600},
+	}, []string{"op"})
+	currentOpOldestSeconds = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "current_op",
+		Name:      "oldest_running_seconds",
+	}, []string{"op"})
+)
+
+func init() {
+	shared.RegisterGroup("current_op")
+	// the currentOp command replaced the db.$cmd.sys.inprog pseudo-collection in 3.2
+	shared.RegisterCapability(shared.Capability{Name: "currentOp", MinVersion: shared.Version{Major: 3, Minor: 2}})
+}
+
+// CurrentOp is an in-progress operation returned by the currentOp command.
+type CurrentOp struct {
+	Op             string  `bson:"op"`
+	Namespace      string  `bson:"ns"`
+	SecsRunning    float64 `bson:"secs_running"`
+	WaitingForLock bool    `bson:"waitingForLock"`
+}
+
+// CurrentOpStatus keeps the active operations returned by the currentOp command.
+type CurrentOpStatus struct {
+	InProgress []CurrentOp `bson:"inprog"`
+}
+
+// Export exports the active operations to prometheus.
+func (status *CurrentOpStatus) Export(ch *shared.MetricChannel) {
+	type activeKey struct {
+		op, ns         string
+		waitingForLock bool
+	}
+	active := map[activeKey]float64{}
+	running := map[string]*shared.Histogram{}
+	oldest := map[string]float64{}
+	for _, op := range status.InProgress {
+		active[activeKey{op.Op, op.Namespace, op.WaitingForLock}]++
+		if running[op.Op] == nil {
+			running[op.Op] = shared.NewHistogram(currentOpSecondsRunning)
+		}
+		running[op.Op].Observe(op.SecsRunning)
+		if secs, ok := oldest[op.Op]; !ok || op.SecsRunning > secs {
+			oldest[op.Op] = op.SecsRunning
+		}
+	}
+
+	for key, count := range active {
+		ch.Send(currentOpActive, count, key.op, key.ns, strconv.FormatBool(key.waitingForLock))
+	}
+	for op, histogram := range running {
+		ch.SendHistogram(currentOpSecondsRunning, histogram, op)
+	}
+	for op, secs := range oldest {
+		ch.Send(currentOpOldestSeconds, secs, op)
+	}
+}
+
+// Filter keeps the operations on the namespaces matching the filter.
+func (status *CurrentOpStatus) Filter(filter *shared.NamespaceFilter) {
+	ops := status.InProgress[:0]
+	for _, op := range status.InProgress {
+		if filter.Match(op.Namespace) {
+			ops = append(ops, op)
+		}
+	}
+	status.InProgress = ops
+}
+
+// GetCurrentOpStatus returns the active operations running for at least minSecsRunning seconds
+// on the namespaces matching the filter. The operations are selected by the server, so that only
+// the slow ones are sent back.
+func GetCurrentOpStatus(session shared.Session, filter *shared.NamespaceFilter, minSecsRunning int) *CurrentOpStatus {
+	command := bson.D{
+		{Name: "currentOp", Value: 1},
+		{Name: "active", Value: true},
+	}
+	if minSecsRunning > 0 {
+		command = append(command, bson.DocElem{Name: "secs_running", Value: bson.M{"$gte": minSecsRunning}})
+	}
+
+	status := &CurrentOpStatus{}
+	err := session.Run("admin", command, status)
+	if err != nil {
+		glog.Errorf("Failed to get current operations: %s", err)
+		return nil
+	}
+	status.Filter(filter)
+	return status
+}
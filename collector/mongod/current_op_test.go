@@ -0,0 +1,41 @@
+package collector_mongod
+
+import (
+	"testing"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
+)
+
+func Test_CurrentOpStatus(t *testing.T) {
+	status := &CurrentOpStatus{
+		InProgress: []CurrentOp{
+			{Op: "query", Namespace: "app.users", SecsRunning: 3},
+			{Op: "query", Namespace: "app.users", SecsRunning: 40, WaitingForLock: true},
+			{Op: "update", Namespace: "app.orders", SecsRunning: 12},
+			{Op: "query", Namespace: "local.oplog.rs", SecsRunning: 100},
+		},
+	}
+	filter, err := shared.NewNamespaceFilter("", `^local\.`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Filter(filter)
+	if len(status.InProgress) != 3 {
+		t.Fatalf("expected 3 operations after filtering, got %d", len(status.InProgress))
+	}
+
+	values, err := sessiontest.GatherExport(status.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[`mongodb_mongod_current_op_oldest_running_seconds{op="query"}`] != 40 {
+		t.Errorf("expected the oldest query to run for 40s, got %v", values[`mongodb_mongod_current_op_oldest_running_seconds{op="query"}`])
+	}
+	if values[`mongodb_mongod_current_op_active{ns="app.users",op="query",waiting_for_lock="true"}`] != 1 {
+		t.Errorf("expected 1 query waiting for a lock, got %v", values[`mongodb_mongod_current_op_active{ns="app.users",op="query",waiting_for_lock="true"}`])
+	}
+	if values[`mongodb_mongod_current_op_running_seconds{op="query"}`] != 2 {
+		t.Errorf("expected 2 running queries, got %v", values[`mongodb_mongod_current_op_running_seconds{op="query"}`])
+	}
+}
@@ -0,0 +1,198 @@
+package collector_mongod
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	dbCollections = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "db",
+		Name:      "collections",
+	}, []string{"db"})
+	dbObjects = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "db",
+		Name:      "objects",
+	}, []string{"db"})
+	dbIndexes = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "db",
+		Name:      "indexes",
+	}, []string{"db"})
+	dbSizeBytes = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "db",
+		Name:      "size_bytes",
+	}, []string{"db", "type"})
+)
+
+var (
+	collectionDocuments = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "collection",
+		Name:      "documents",
+	}, []string{"db", "collection"})
+	collectionIndexes = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "collection",
+		Name:      "indexes",
+	}, []string{"db", "collection"})
+	collectionSizeBytes = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "collection",
+		Name:      "size_bytes",
+	}, []string{"db", "collection", "type"})
+	collectionIndexSizeBytes = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "collection",
+		Name:      "index_size_bytes",
+	}, []string{"db", "collection", "index"})
+)
+
+func init() {
+	shared.RegisterGroup("db_stats")
+	shared.RegisterGroup("coll_stats")
+}
+
+// DatabaseStats keeps the data returned by the dbStats command.
+type DatabaseStats struct {
+	Name        string  `bson:"db"`
+	Collections float64 `bson:"collections"`
+	Objects     float64 `bson:"objects"`
+	DataSize    float64 `bson:"dataSize"`
+	StorageSize float64 `bson:"storageSize"`
+	Indexes     float64 `bson:"indexes"`
+	IndexSize   float64 `bson:"indexSize"`
+}
+
+// DatabaseStatsList is the dbStats of every database.
+type DatabaseStatsList []DatabaseStats
+
+// Export exports the database stats to prometheus.
+func (list DatabaseStatsList) Export(ch *shared.MetricChannel) {
+	for _, stats := range list {
+		ch.Send(dbCollections, stats.Collections, stats.Name)
+		ch.Send(dbObjects, stats.Objects, stats.Name)
+		ch.Send(dbIndexes, stats.Indexes, stats.Name)
+		ch.Send(dbSizeBytes, stats.DataSize, stats.Name, "data")
+		ch.Send(dbSizeBytes, stats.StorageSize, stats.Name, "storage")
+		ch.Send(dbSizeBytes, stats.IndexSize, stats.Name, "index")
+	}
+}
+
+// GetDatabaseStats returns the dbStats of every database.
+func GetDatabaseStats(session shared.Session) DatabaseStatsList {
+	names, err := session.DatabaseNames()
+	if err != nil {
+		glog.Error("Failed to list databases.")
+		return nil
+	}
+
+	list := DatabaseStatsList{}
+	for _, name := range names {
+		stats := DatabaseStats{}
+		err := session.Run(name, bson.D{{"dbStats", 1}, {"scale", 1}}, &stats)
+		if err != nil {
+			glog.Errorf("Failed to get dbStats of %s: %s", name, err)
+			continue
+		}
+		list = append(list, stats)
+	}
+	return list
+}
+
+// CollectionStats keeps the data returned by the collStats command.
+type CollectionStats struct {
+	Database       string             `bson:"-"`
+	Name           string             `bson:"-"`
+	Count          float64            `bson:"count"`
+	Size           float64            `bson:"size"`
+	StorageSize    float64            `bson:"storageSize"`
+	Indexes        float64            `bson:"nindexes"`
+	TotalIndexSize float64            `bson:"totalIndexSize"`
+	IndexSizes     map[string]float64 `bson:"indexSizes"`
+}
+
+// CollectionStatsList is the collStats of the selected collections.
+type CollectionStatsList []CollectionStats
+
+// Export exports the collection stats to prometheus.
+func (list CollectionStatsList) Export(ch *shared.MetricChannel) {
+	for _, stats := range list {
+		ch.Send(collectionDocuments, stats.Count, stats.Database, stats.Name)
+		ch.Send(collectionIndexes, stats.Indexes, stats.Database, stats.Name)
+		ch.Send(collectionSizeBytes, stats.Size, stats.Database, stats.Name, "data")
+		ch.Send(collectionSizeBytes, stats.StorageSize, stats.Database, stats.Name, "storage")
+		ch.Send(collectionSizeBytes, stats.TotalIndexSize, stats.Database, stats.Name, "index")
+		for index, size := range stats.IndexSizes {
+			ch.Send(collectionIndexSizeBytes, size, stats.Database, stats.Name, index)
+		}
+	}
+}
+
+// CollectionNamespace is a database and collection pair.
+type CollectionNamespace struct {
+	Database   string
+	Collection string
+}
+
+// ListNamespaces returns the collections matching the filter, sorted, up to limit namespaces (0 = no limit).
+// The views and the system collections are skipped.
+func ListNamespaces(session shared.Session, filter *shared.NamespaceFilter, limit int) ([]CollectionNamespace, error) {
+	databases, err := session.DatabaseNames()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(databases)
+
+	namespaces := []CollectionNamespace{}
+	for _, database := range databases {
+		collections, err := session.CollectionNames(database)
+		if err != nil {
+			glog.Errorf("Failed to list collections of %s: %s", database, err)
+			continue
+		}
+		sort.Strings(collections)
+		for _, collection := range collections {
+			if strings.HasPrefix(collection, "system.") || !filter.Match(database+"."+collection) {
+				continue
+			}
+			if limit > 0 && len(namespaces) >= limit {
+				glog.Warningf("More than %d collections match the namespace filter, ignoring the others", limit)
+				return namespaces, nil
+			}
+			namespaces = append(namespaces, CollectionNamespace{Database: database, Collection: collection})
+		}
+	}
+	return namespaces, nil
+}
+
+// GetCollectionStats returns the collStats of the collections matching the filter, up to limit collections.
+func GetCollectionStats(session shared.Session, filter *shared.NamespaceFilter, limit int) CollectionStatsList {
+	namespaces, err := ListNamespaces(session, filter, limit)
+	if err != nil {
+		glog.Error("Failed to list collections.")
+		return nil
+	}
+
+	list := CollectionStatsList{}
+	for _, ns := range namespaces {
+		stats := CollectionStats{}
+		err := session.Run(ns.Database, bson.D{{"collStats", ns.Collection}, {"scale", 1}}, &stats)
+		if err != nil {
+			glog.Errorf("Failed to get collStats of %s.%s: %s", ns.Database, ns.Collection, err)
+			continue
+		}
+		stats.Database = ns.Database
+		stats.Name = ns.Collection
+		list = append(list, stats)
+	}
+	return list
+}
@@ -0,0 +1,99 @@
+package collector_mongod
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func namespacesSession() *sessiontest.Session {
+	return &sessiontest.Session{
+		Collections: map[string][]bson.M{
+			"shop.orders":         nil,
+			"shop.customers":      nil,
+			"shop.system.profile": nil,
+			"shop.system.js":      nil,
+			"admin.system.users":  nil,
+			"logs.events":         nil,
+		},
+		Commands: map[string]interface{}{
+			"shop.collStats": bson.M{"count": 12, "size": 1200, "storageSize": 4096, "nindexes": 2, "totalIndexSize": 8192,
+				"indexSizes": bson.M{"_id_": 4096, "email_1": 4096}},
+			"logs.collStats": bson.M{"count": 3, "size": 300, "storageSize": 4096, "nindexes": 1, "totalIndexSize": 4096,
+				"indexSizes": bson.M{"_id_": 4096}},
+		},
+	}
+}
+
+func Test_ListNamespaces(t *testing.T) {
+	denyLogs, err := shared.NewNamespaceFilter("", `^logs\.`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		filter   *shared.NamespaceFilter
+		limit    int
+		expected []CollectionNamespace
+	}{
+		{
+			name: "system collections skipped",
+			expected: []CollectionNamespace{
+				{Database: "logs", Collection: "events"},
+				{Database: "shop", Collection: "customers"},
+				{Database: "shop", Collection: "orders"},
+			},
+		},
+		{
+			name:   "filtered",
+			filter: denyLogs,
+			expected: []CollectionNamespace{
+				{Database: "shop", Collection: "customers"},
+				{Database: "shop", Collection: "orders"},
+			},
+		},
+		{
+			name:  "limited",
+			limit: 2,
+			expected: []CollectionNamespace{
+				{Database: "logs", Collection: "events"},
+				{Database: "shop", Collection: "customers"},
+			},
+		},
+	}
+	for _, test := range tests {
+		namespaces, err := ListNamespaces(namespacesSession(), test.filter, test.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(namespaces, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, namespaces)
+		}
+	}
+}
+
+func Test_ExportCollectionStats(t *testing.T) {
+	list := GetCollectionStats(namespacesSession(), nil, 0)
+	if len(list) != 3 {
+		t.Fatalf("expected the stats of 3 collections, got %d", len(list))
+	}
+
+	values, err := sessiontest.GatherExport(list.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessiontest.ExpectValues(t, values, map[string]float64{
+		`mongodb_mongod_collection_documents{collection="orders",db="shop"}`:                           12,
+		`mongodb_mongod_collection_indexes{collection="events",db="logs"}`:                             1,
+		`mongodb_mongod_collection_size_bytes{collection="orders",db="shop",type="data"}`:              1200,
+		`mongodb_mongod_collection_size_bytes{collection="events",db="logs",type="index"}`:             4096,
+		`mongodb_mongod_collection_index_size_bytes{collection="customers",db="shop",index="email_1"}`: 4096,
+	})
+	if _, ok := values[`mongodb_mongod_collection_documents{collection="system.profile",db="shop"}`]; ok {
+		t.Error("the system collections should not be exported")
+	}
+}
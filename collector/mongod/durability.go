@@ -1,47 +1,42 @@
 package collector_mongod
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	durabilityCommits = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	durabilityCommits = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Name:      "durability_commits",
-		Help:      "Durability commits",
 	}, []string{"state"})
 )
 var (
-	durabilityJournaledMegabytes = prometheus.NewGauge(prometheus.GaugeOpts{
+	durabilityJournaledMegabytes = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "durability",
 		Name:      "journaled_megabytes",
-		Help:      "The journaledMB provides the amount of data in megabytes (MB) written to journal during the last journal group commit interval",
-	})
-	durabilityWriteToDataFilesMegabytes = prometheus.NewGauge(prometheus.GaugeOpts{
+	}, nil)
+	durabilityWriteToDataFilesMegabytes = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "durability",
 		Name:      "write_to_data_files_megabytes",
-		Help:      "The writeToDataFilesMB provides the amount of data in megabytes (MB) written from journal to the data files during the last journal group commit interval",
-	})
-	durabilityCompression = prometheus.NewGauge(prometheus.GaugeOpts{
+	}, nil)
+	durabilityCompression = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "durability",
 		Name:      "compression",
-		Help:      "The compression represents the compression ratio of the data written to the journal: ( journaled_size_of_data / uncompressed_size_of_data )",
-	})
-	durabilityEarlyCommits = prometheus.NewSummary(prometheus.SummaryOpts{
+	}, nil)
+	durabilityEarlyCommits = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "durability",
 		Name:      "early_commits",
-		Help:      "The earlyCommits value reflects the number of times MongoDB requested a commit before the scheduled journal group commit interval. Use this value to ensure that your journal group commit interval is not too long for your deployment",
-	})
+	}, nil)
 )
 var (
-	durabilityTimeMilliseconds = prometheus.NewSummaryVec(prometheus.SummaryOpts{
+	durabilityTimeMilliseconds = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Name:      "durability_time_milliseconds",
-		Help:      "Summary of times spent during the journaling process.",
 	}, []string{"stage"})
 )
 
@@ -55,13 +50,12 @@ type DurTiming struct {
 }
 
 // Export exports the data for the prometheus server.
-func (durTiming *DurTiming) Export(ch chan<- prometheus.Metric) {
-	durabilityTimeMilliseconds.WithLabelValues("dt").Observe(durTiming.Dt)
-	durabilityTimeMilliseconds.WithLabelValues("prep_log_buffer").Observe(durTiming.PrepLogBuffer)
-	durabilityTimeMilliseconds.WithLabelValues("write_to_journal").Observe(durTiming.WriteToJournal)
-	durabilityTimeMilliseconds.WithLabelValues("write_to_data_files").Observe(durTiming.WriteToDataFiles)
-	durabilityTimeMilliseconds.WithLabelValues("remap_private_view").Observe(durTiming.RemapPrivateView)
-	durabilityTimeMilliseconds.Collect(ch)
+func (durTiming *DurTiming) Export(ch *shared.MetricChannel) {
+	ch.Send(durabilityTimeMilliseconds, durTiming.Dt, "dt")
+	ch.Send(durabilityTimeMilliseconds, durTiming.PrepLogBuffer, "prep_log_buffer")
+	ch.Send(durabilityTimeMilliseconds, durTiming.WriteToJournal, "write_to_journal")
+	ch.Send(durabilityTimeMilliseconds, durTiming.WriteToDataFiles, "write_to_data_files")
+	ch.Send(durabilityTimeMilliseconds, durTiming.RemapPrivateView, "remap_private_view")
 }
 
 // DurStats are the stats related to durability.
@@ -76,35 +70,14 @@ type DurStats struct {
 }
 
 // Export export the durability stats for the prometheus server.
-func (durStats *DurStats) Export(ch chan<- prometheus.Metric) {
-	durabilityCommits.WithLabelValues("written").Set(durStats.Commits)
-	durabilityCommits.WithLabelValues("in_write_lock").Set(durStats.CommitsInWriteLock)
+func (durStats *DurStats) Export(ch *shared.MetricChannel) {
+	ch.Send(durabilityCommits, durStats.Commits, "written")
+	ch.Send(durabilityCommits, durStats.CommitsInWriteLock, "in_write_lock")
 
-	durabilityJournaledMegabytes.Set(durStats.JournaledMB)
-	durabilityWriteToDataFilesMegabytes.Set(durStats.WriteToDataFilesMB)
-	durabilityCompression.Set(durStats.Compression)
-	durabilityEarlyCommits.Observe(durStats.EarlyCommits)
+	ch.Send(durabilityJournaledMegabytes, durStats.JournaledMB)
+	ch.Send(durabilityWriteToDataFilesMegabytes, durStats.WriteToDataFilesMB)
+	ch.Send(durabilityCompression, durStats.Compression)
+	ch.Send(durabilityEarlyCommits, durStats.EarlyCommits)
 
 	durStats.TimeMs.Export(ch)
-
-	durStats.Collect(ch)
-}
-
-// Collect collects the metrics for prometheus
-func (durStats *DurStats) Collect(ch chan<- prometheus.Metric) {
-	durabilityCommits.Collect(ch)
-	durabilityJournaledMegabytes.Collect(ch)
-	durabilityWriteToDataFilesMegabytes.Collect(ch)
-	durabilityCompression.Collect(ch)
-	durabilityEarlyCommits.Collect(ch)
-}
-
-// Describe describes the metrics for prometheus
-func (durStats *DurStats) Describe(ch chan<- *prometheus.Desc) {
-	durabilityCommits.Describe(ch)
-	durabilityJournaledMegabytes.Describe(ch)
-	durabilityWriteToDataFilesMegabytes.Describe(ch)
-	durabilityCompression.Describe(ch)
-	durabilityEarlyCommits.Describe(ch)
-	durabilityTimeMilliseconds.Describe(ch)
 }
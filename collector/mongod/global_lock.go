@@ -1,41 +1,37 @@
 package collector_mongod
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	globalLockRatio = prometheus.NewGauge(prometheus.GaugeOpts{
+	globalLockRatio = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "global_lock",
 		Name:      "ratio",
-		Help:      "The value of ratio displays the relationship between lockTime and totalTime. Low values indicate that operations have held the globalLock frequently for shorter periods of time. High values indicate that operations have held globalLock infrequently for longer periods of time",
-	})
-	globalLockTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	globalLockTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "global_lock",
 		Name:      "total",
-		Help:      "The value of totalTime represents the time, in microseconds, since the database last started and creation of the globalLock. This is roughly equivalent to total server uptime",
-	})
-	globalLockLockTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	globalLockLockTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "global_lock",
 		Name:      "lock_total",
-		Help:      "The value of lockTime represents the time, in microseconds, since the database last started, that the globalLock has been held",
-	})
+	}, nil)
 )
 var (
-	globalLockCurrentQueue = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	globalLockCurrentQueue = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Name:      "global_lock_current_queue",
-		Help:      "The currentQueue data structure value provides more granular information concerning the number of operations queued because of a lock",
 	}, []string{"type"})
 )
 var (
-	globalLockClient = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	globalLockClient = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Name:      "global_lock_client",
-		Help:      "The activeClients data structure provides more granular information about the number of connected clients and the operation types (e.g. read or write) performed by these clients",
 	}, []string{"type"})
 )
 
@@ -47,9 +43,9 @@ type ClientStats struct {
 }
 
 // Export exports the metrics to prometheus
-func (clientStats *ClientStats) Export(ch chan<- prometheus.Metric) {
-	globalLockClient.WithLabelValues("reader").Set(clientStats.Readers)
-	globalLockClient.WithLabelValues("writer").Set(clientStats.Writers)
+func (clientStats *ClientStats) Export(ch *shared.MetricChannel) {
+	ch.Send(globalLockClient, clientStats.Readers, "reader")
+	ch.Send(globalLockClient, clientStats.Writers, "writer")
 }
 
 // QueueStats queue stats
@@ -60,38 +56,28 @@ type QueueStats struct {
 }
 
 // Export exports the metrics to prometheus
-func (queueStats *QueueStats) Export(ch chan<- prometheus.Metric) {
-	globalLockCurrentQueue.WithLabelValues("reader").Set(queueStats.Readers)
-	globalLockCurrentQueue.WithLabelValues("writer").Set(queueStats.Writers)
+func (queueStats *QueueStats) Export(ch *shared.MetricChannel) {
+	ch.Send(globalLockCurrentQueue, queueStats.Readers, "reader")
+	ch.Send(globalLockCurrentQueue, queueStats.Writers, "writer")
 }
 
-// GlobalLockStats global lock stats
+// GlobalLockStats global lock stats, lockTime is only reported before 3.0
 type GlobalLockStats struct {
 	TotalTime     float64      `bson:"totalTime"`
-	LockTime      float64      `bson:"lockTime"`
+	LockTime      *float64     `bson:"lockTime"`
 	Ratio         float64      `bson:"ratio"`
 	CurrentQueue  *QueueStats  `bson:"currentQueue"`
 	ActiveClients *ClientStats `bson:"activeClients"`
 }
 
 // Export exports the metrics to prometheus
-func (globalLock *GlobalLockStats) Export(ch chan<- prometheus.Metric) {
-	globalLockTotal.Add(globalLock.LockTime)
-	globalLockRatio.Set(globalLock.Ratio)
+func (globalLock *GlobalLockStats) Export(ch *shared.MetricChannel) {
+	ch.Send(globalLockTotal, globalLock.TotalTime)
+	if globalLock.LockTime != nil {
+		ch.Send(globalLockLockTotal, *globalLock.LockTime)
+	}
+	ch.Send(globalLockRatio, globalLock.Ratio)
 
 	globalLock.CurrentQueue.Export(ch)
 	globalLock.ActiveClients.Export(ch)
-
-	globalLockTotal.Collect(ch)
-	globalLockRatio.Collect(ch)
-	globalLockCurrentQueue.Collect(ch)
-	globalLockClient.Collect(ch)
-}
-
-// Describe describes the metrics for prometheus
-func (globalLock *GlobalLockStats) Describe(ch chan<- *prometheus.Desc) {
-	globalLockTotal.Describe(ch)
-	globalLockRatio.Describe(ch)
-	globalLockCurrentQueue.Describe(ch)
-	globalLockClient.Describe(ch)
 }
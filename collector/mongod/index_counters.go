@@ -1,27 +1,26 @@
 package collector_mongod
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	indexCountersMissRatio = prometheus.NewGauge(prometheus.GaugeOpts{
+	indexCountersMissRatio = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "index_counters",
 		Name:      "miss_ratio",
-		Help:      "The missRatio value is the ratio of hits to misses. This value is typically 0 or approaching 0",
-	})
+	}, nil)
 )
 
 var (
-	indexCountersTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	indexCountersTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "index_counters_total",
-		Help:      "Total indexes by type",
 	}, []string{"type"})
 )
 
-//IndexCounterStats index counter stats
+// IndexCounterStats index counter stats
 type IndexCounterStats struct {
 	Accesses  float64 `bson:"accesses"`
 	Hits      float64 `bson:"hits"`
@@ -31,21 +30,11 @@ type IndexCounterStats struct {
 }
 
 // Export exports the data to prometheus.
-func (indexCountersStats *IndexCounterStats) Export(ch chan<- prometheus.Metric) {
-	indexCountersTotal.WithLabelValues("accesses").Add(indexCountersStats.Accesses)
-	indexCountersTotal.WithLabelValues("hits").Add(indexCountersStats.Hits)
-	indexCountersTotal.WithLabelValues("misses").Add(indexCountersStats.Misses)
-	indexCountersTotal.WithLabelValues("resets").Add(indexCountersStats.Resets)
+func (indexCountersStats *IndexCounterStats) Export(ch *shared.MetricChannel) {
+	ch.Send(indexCountersTotal, indexCountersStats.Accesses, "accesses")
+	ch.Send(indexCountersTotal, indexCountersStats.Hits, "hits")
+	ch.Send(indexCountersTotal, indexCountersStats.Misses, "misses")
+	ch.Send(indexCountersTotal, indexCountersStats.Resets, "resets")
 
-	indexCountersMissRatio.Set(indexCountersStats.MissRatio)
-
-	indexCountersTotal.Collect(ch)
-	indexCountersMissRatio.Collect(ch)
-
-}
-
-// Describe describes the metrics for prometheus
-func (indexCountersStats *IndexCounterStats) Describe(ch chan<- *prometheus.Desc) {
-	indexCountersTotal.Describe(ch)
-	indexCountersMissRatio.Describe(ch)
+	ch.Send(indexCountersMissRatio, indexCountersStats.MissRatio)
 }
@@ -0,0 +1,78 @@
+package collector_mongod
+
+import (
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	indexAccessesTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "index",
+		Name:      "accesses_total",
+	}, []string{"db", "collection", "index"})
+	indexAccessesSinceTimestamp = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "index",
+		Name:      "accesses_since_timestamp",
+	}, []string{"db", "collection", "index"})
+)
+
+func init() {
+	shared.RegisterGroup("index_stats")
+	shared.RegisterCapability(shared.Capability{Name: "indexStats", MinVersion: shared.Version{Major: 3, Minor: 2}})
+}
+
+// IndexAccesses are the accesses of an index.
+type IndexAccesses struct {
+	Ops   float64   `bson:"ops"`
+	Since time.Time `bson:"since"`
+}
+
+// IndexStats keeps a document returned by the $indexStats aggregation stage.
+type IndexStats struct {
+	Database   string        `bson:"-"`
+	Collection string        `bson:"-"`
+	Name       string        `bson:"name"`
+	Accesses   IndexAccesses `bson:"accesses"`
+}
+
+// IndexStatsList is the $indexStats of the selected collections.
+type IndexStatsList []IndexStats
+
+// Export exports the index stats to prometheus.
+func (list IndexStatsList) Export(ch *shared.MetricChannel) {
+	for _, stats := range list {
+		ch.Send(indexAccessesTotal, stats.Accesses.Ops, stats.Database, stats.Collection, stats.Name)
+		ch.Send(indexAccessesSinceTimestamp, float64(stats.Accesses.Since.Unix()), stats.Database, stats.Collection, stats.Name)
+	}
+}
+
+// GetIndexStats returns the $indexStats of the collections matching the filter, up to limit collections.
+func GetIndexStats(session shared.Session, filter *shared.NamespaceFilter, limit int) IndexStatsList {
+	namespaces, err := ListNamespaces(session, filter, limit)
+	if err != nil {
+		glog.Error("Failed to list collections.")
+		return nil
+	}
+
+	list := IndexStatsList{}
+	for _, ns := range namespaces {
+		var results []IndexStats
+		err := session.Pipe(ns.Database, ns.Collection, []bson.M{{"$indexStats": bson.M{}}}).All(&results)
+		if err != nil {
+			glog.Errorf("Failed to get $indexStats of %s.%s: %s", ns.Database, ns.Collection, err)
+			continue
+		}
+		for _, stats := range results {
+			stats.Database = ns.Database
+			stats.Collection = ns.Collection
+			list = append(list, stats)
+		}
+	}
+	return list
+}
@@ -0,0 +1,47 @@
+package collector_mongod
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func Test_GetIndexStats(t *testing.T) {
+	since := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	session := &sessiontest.Session{
+		Collections: map[string][]bson.M{
+			"shop.orders":         nil,
+			"shop.system.profile": nil,
+		},
+		Aggregate: func(database, collection string, pipeline interface{}) []interface{} {
+			if database != "shop" || collection != "orders" {
+				t.Errorf("unexpected $indexStats of %s.%s", database, collection)
+			}
+			return []interface{}{
+				bson.M{"name": "_id_", "key": bson.M{"_id": 1}, "host": "db1:27017", "accesses": bson.M{"ops": int64(1500), "since": since}},
+				bson.M{"name": "status_1_date_-1", "key": bson.D{{"status", 1}, {"date", -1}}, "host": "db1:27017", "accesses": bson.M{"ops": int64(0), "since": since}},
+			}
+		},
+	}
+
+	list := GetIndexStats(session, nil, 0)
+	if len(list) != 2 {
+		t.Fatalf("expected 2 indexes, got %+v", list)
+	}
+	if list[1].Database != "shop" || list[1].Collection != "orders" || list[1].Name != "status_1_date_-1" || !list[1].Accesses.Since.Equal(since) {
+		t.Errorf("unexpected decoding of $indexStats: %+v", list[1])
+	}
+
+	values, err := sessiontest.GatherExport(list.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessiontest.ExpectValues(t, values, map[string]float64{
+		`mongodb_mongod_index_accesses_total{collection="orders",db="shop",index="_id_"}`:                       1500,
+		`mongodb_mongod_index_accesses_total{collection="orders",db="shop",index="status_1_date_-1"}`:           0,
+		`mongodb_mongod_index_accesses_since_timestamp{collection="orders",db="shop",index="_id_"}`:             float64(since.Unix()),
+		`mongodb_mongod_index_accesses_since_timestamp{collection="orders",db="shop",index="status_1_date_-1"}`: float64(since.Unix()),
+	})
+}
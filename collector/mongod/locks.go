@@ -1,30 +1,46 @@
 package collector_mongod
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	locksTimeLockedGlobalMicrosecondsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	locksTimeLockedGlobalMicrosecondsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "locks_time_locked_global_microseconds_total",
-		Help:      "amount of time in microseconds that any database has held the global lock",
 	}, []string{"type", "database"})
 )
 var (
-	locksTimeLockedLocalMicrosecondsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	locksTimeLockedLocalMicrosecondsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "locks_time_locked_local_microseconds_total",
-		Help:      "amount of time in microseconds that any database has held the local lock",
 	}, []string{"type", "database"})
 )
 var (
-	locksTimeAcquiringGlobalMicrosecondsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	locksTimeAcquiringGlobalMicrosecondsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "locks_time_acquiring_global_microseconds_total",
-		Help:      "amount of time in microseconds that any database has spent waiting for the global lock",
 	}, []string{"type", "database"})
 )
+var (
+	locksAcquireTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "locks_acquire_total",
+	}, []string{"resource", "mode"})
+	locksAcquireWaitTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "locks_acquire_wait_total",
+	}, []string{"resource", "mode"})
+	locksTimeAcquiringMicrosecondsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "locks_time_acquiring_microseconds_total",
+	}, []string{"resource", "mode"})
+	locksDeadlockTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "locks_deadlock_total",
+	}, []string{"resource", "mode"})
+)
 
 // LockStatsMap is a map of lock stats
 type LockStatsMap map[string]LockStats
@@ -37,37 +53,63 @@ type ReadWriteLockTimes struct {
 	WriteLower float64 `bson:"w"`
 }
 
-// LockStats lock stats
+// LockModeCounts are the counts of a lock resource by mode (r, w, R or W).
+type LockModeCounts map[string]float64
+
+// LockStats lock stats. Before 3.0 the locks are keyed by database with the timeLockedMicros and
+// timeAcquiringMicros fields; since 3.0 they are keyed by resource type (Global, Database,
+// Collection, ...) with the counts by mode, timeAcquiringMicros keeping the same R/W/r/w keys.
 type LockStats struct {
 	TimeLockedMicros    ReadWriteLockTimes `bson:"timeLockedMicros"`
 	TimeAcquiringMicros ReadWriteLockTimes `bson:"timeAcquiringMicros"`
+
+	AcquireCount     LockModeCounts `bson:"acquireCount"`
+	AcquireWaitCount LockModeCounts `bson:"acquireWaitCount"`
+	DeadlockCount    LockModeCounts `bson:"deadlockCount"`
+}
+
+// Mode returns the time of a mode (r, w, R or W) in the lock times.
+func (times ReadWriteLockTimes) Mode(mode string) float64 {
+	switch mode {
+	case "R":
+		return times.Read
+	case "W":
+		return times.Write
+	case "r":
+		return times.ReadLower
+	case "w":
+		return times.WriteLower
+	}
+	return 0
 }
 
 // Export exports the data to prometheus.
-func (locks LockStatsMap) Export(ch chan<- prometheus.Metric) {
+func (locks LockStatsMap) Export(ch *shared.MetricChannel) {
 	for key, locks := range locks {
 		if key == "." {
 			key = "dot"
 		}
 
-		locksTimeLockedGlobalMicrosecondsTotal.WithLabelValues("read", key).Add(locks.TimeLockedMicros.Read)
-		locksTimeLockedGlobalMicrosecondsTotal.WithLabelValues("write", key).Add(locks.TimeLockedMicros.Write)
+		ch.Send(locksTimeLockedGlobalMicrosecondsTotal, locks.TimeLockedMicros.Read, "read", key)
+		ch.Send(locksTimeLockedGlobalMicrosecondsTotal, locks.TimeLockedMicros.Write, "write", key)
 
-		locksTimeLockedLocalMicrosecondsTotal.WithLabelValues("read", key).Add(locks.TimeLockedMicros.ReadLower)
-		locksTimeLockedLocalMicrosecondsTotal.WithLabelValues("write", key).Add(locks.TimeLockedMicros.WriteLower)
+		ch.Send(locksTimeLockedLocalMicrosecondsTotal, locks.TimeLockedMicros.ReadLower, "read", key)
+		ch.Send(locksTimeLockedLocalMicrosecondsTotal, locks.TimeLockedMicros.WriteLower, "write", key)
 
-		locksTimeAcquiringGlobalMicrosecondsTotal.WithLabelValues("read", key).Add(locks.TimeAcquiringMicros.ReadLower)
-		locksTimeAcquiringGlobalMicrosecondsTotal.WithLabelValues("write", key).Add(locks.TimeAcquiringMicros.WriteLower)
-	}
+		ch.Send(locksTimeAcquiringGlobalMicrosecondsTotal, locks.TimeAcquiringMicros.ReadLower, "read", key)
+		ch.Send(locksTimeAcquiringGlobalMicrosecondsTotal, locks.TimeAcquiringMicros.WriteLower, "write", key)
 
-	locksTimeLockedGlobalMicrosecondsTotal.Collect(ch)
-	locksTimeLockedLocalMicrosecondsTotal.Collect(ch)
-	locksTimeAcquiringGlobalMicrosecondsTotal.Collect(ch)
+		locks.AcquireCount.export(ch, locksAcquireTotal, key)
+		locks.AcquireWaitCount.export(ch, locksAcquireWaitTotal, key)
+		for mode := range locks.AcquireCount {
+			ch.Send(locksTimeAcquiringMicrosecondsTotal, locks.TimeAcquiringMicros.Mode(mode), key, mode)
+		}
+		locks.DeadlockCount.export(ch, locksDeadlockTotal, key)
+	}
 }
 
-// Describe describes the metrics for prometheus
-func (locks LockStatsMap) Describe(ch chan<- *prometheus.Desc) {
-	locksTimeLockedGlobalMicrosecondsTotal.Describe(ch)
-	locksTimeLockedLocalMicrosecondsTotal.Describe(ch)
-	locksTimeAcquiringGlobalMicrosecondsTotal.Describe(ch)
+func (counts LockModeCounts) export(ch *shared.MetricChannel, desc *shared.MetricDesc, resource string) {
+	for mode, count := range counts {
+		ch.Send(desc, count, resource, mode)
+	}
 }
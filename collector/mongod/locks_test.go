@@ -0,0 +1,65 @@
+package collector_mongod
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func Test_ExportModernLockStats(t *testing.T) {
+	data, err := bson.Marshal(bson.M{
+		"Global": bson.M{
+			"acquireCount":        bson.M{"r": int64(1200), "w": int64(300), "W": int64(4)},
+			"acquireWaitCount":    bson.M{"W": int64(2)},
+			"timeAcquiringMicros": bson.M{"W": int64(5000)},
+		},
+		"Collection": bson.M{
+			"acquireCount":  bson.M{"r": int64(800), "w": int64(250)},
+			"deadlockCount": bson.M{"w": int64(1)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	locks := LockStatsMap{}
+	if err := bson.Unmarshal(data, &locks); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := sessiontest.GatherExport(locks.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessiontest.ExpectValues(t, values, map[string]float64{
+		`mongodb_mongod_locks_acquire_total{mode="r",resource="Global"}`:                     1200,
+		`mongodb_mongod_locks_acquire_total{mode="W",resource="Global"}`:                     4,
+		`mongodb_mongod_locks_acquire_total{mode="w",resource="Collection"}`:                 250,
+		`mongodb_mongod_locks_acquire_wait_total{mode="W",resource="Global"}`:                2,
+		`mongodb_mongod_locks_time_acquiring_microseconds_total{mode="W",resource="Global"}`: 5000,
+		`mongodb_mongod_locks_time_acquiring_microseconds_total{mode="r",resource="Global"}`: 0,
+		`mongodb_mongod_locks_deadlock_total{mode="w",resource="Collection"}`:                1,
+	})
+	if _, ok := values[`mongodb_mongod_locks_time_acquiring_microseconds_total{mode="R",resource="Global"}`]; ok {
+		t.Error("the modes never acquired should not be exported")
+	}
+}
+
+func Test_LegacyLockStatsHaveNoModes(t *testing.T) {
+	serverStatus := &ServerStatus{}
+	loadServerStatusFromBson(LoadFixture("server_status.bson"), serverStatus)
+
+	values, err := sessiontest.GatherExport(serverStatus.Locks.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[`mongodb_mongod_locks_time_locked_local_microseconds_total{database="admin",type="read"}`] == 0 {
+		t.Error("the legacy lock times were not exported")
+	}
+	for name := range values {
+		if strings.HasPrefix(name, "mongodb_mongod_locks_acquire") {
+			t.Errorf("unexpected %s for the legacy lock stats", name)
+		}
+	}
+}
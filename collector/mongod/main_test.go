@@ -0,0 +1,14 @@
+package collector_mongod
+
+import (
+	"io/ioutil"
+)
+
+func LoadFixture(name string) []byte {
+	data, err := ioutil.ReadFile("../fixtures/" + name)
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
@@ -1,247 +1,214 @@
 package collector_mongod
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	metricsCursorTimedOutTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	metricsCursorTimedOutTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_cursor",
 		Name:      "timed_out_total",
-		Help:      "timedOut provides the total number of cursors that have timed out since the server process started. If this number is large or growing at a regular rate, this may indicate an application error",
-	})
+	}, nil)
 )
 var (
-	metricsCursorOpen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	metricsCursorOpen = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Name:      "metrics_cursor_open",
-		Help:      "The open is an embedded document that contains data regarding open cursors",
 	}, []string{"state"})
 )
 var (
-	metricsDocumentTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	metricsDocumentTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "metrics_document_total",
-		Help:      "The document holds a document of that reflect document access and modification patterns and data use. Compare these values to the data in the opcounters document, which track total number of operations",
 	}, []string{"state"})
 )
 var (
-	metricsGetLastErrorWtimeNumTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+	metricsGetLastErrorWtimeNumTotal = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_get_last_error_wtime",
 		Name:      "num_total",
-		Help:      "num reports the total number of getLastError operations with a specified write concern (i.e. w) that wait for one or more members of a replica set to acknowledge the write operation (i.e. a w value greater than 1.)",
-	})
-	metricsGetLastErrorWtimeTotalMilliseconds = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	metricsGetLastErrorWtimeTotalMilliseconds = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_get_last_error_wtime",
 		Name:      "total_milliseconds",
-		Help:      "total_millis reports the total amount of time in milliseconds that the mongod has spent performing getLastError operations with write concern (i.e. w) that wait for one or more members of a replica set to acknowledge the write operation (i.e. a w value greater than 1.)",
-	})
+	}, nil)
 )
 var (
-	metricsGetLastErrorWtimeoutsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	metricsGetLastErrorWtimeoutsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_get_last_error",
 		Name:      "wtimeouts_total",
-		Help:      "wtimeouts reports the number of times that write concern operations have timed out as a result of the wtimeout threshold to getLastError.",
-	})
+	}, nil)
 )
 var (
-	metricsOperationTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	metricsOperationTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "metrics_operation_total",
-		Help:      "operation is a sub-document that holds counters for several types of update and query operations that MongoDB handles using special operation types",
 	}, []string{"type"})
 )
 var (
-	metricsQueryExecutorTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	metricsQueryExecutorTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "metrics_query_executor_total",
-		Help:      "queryExecutor is a document that reports data from the query execution system",
 	}, []string{"state"})
 )
 var (
-	metricsRecordMovesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	metricsRecordMovesTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_record",
 		Name:      "moves_total",
-		Help:      "moves reports the total number of times documents move within the on-disk representation of the MongoDB data set. Documents move as a result of operations that increase the size of the document beyond their allocated record size",
-	})
+	}, nil)
 )
 var (
-	metricsReplApplyBatchesNumTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	metricsReplApplyBatchesNumTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_apply_batches",
 		Name:      "num_total",
-		Help:      "num reports the total number of batches applied across all databases",
-	})
-	metricsReplApplyBatchesTotalMilliseconds = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	metricsReplApplyBatchesTotalMilliseconds = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_apply_batches",
 		Name:      "total_milliseconds",
-		Help:      "total_millis reports the total amount of time the mongod has spent applying operations from the oplog",
-	})
+	}, nil)
 )
 var (
-	metricsReplApplyOpsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	metricsReplApplyOpsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_apply",
 		Name:      "ops_total",
-		Help:      "ops reports the total number of oplog operations applied",
-	})
+	}, nil)
 )
 var (
-	metricsReplBufferCount = prometheus.NewGauge(prometheus.GaugeOpts{
+	metricsReplBufferCount = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_buffer",
 		Name:      "count",
-		Help:      "count reports the current number of operations in the oplog buffer",
-	})
-	metricsReplBufferMaxSizeBytes = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	metricsReplBufferMaxSizeBytes = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_buffer",
 		Name:      "max_size_bytes",
-		Help:      "maxSizeBytes reports the maximum size of the buffer. This value is a constant setting in the mongod, and is not configurable",
-	})
-	metricsReplBufferSizeBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+	}, nil)
+	metricsReplBufferSizeBytes = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_buffer",
 		Name:      "size_bytes",
-		Help:      "sizeBytes reports the current size of the contents of the oplog buffer",
-	})
+	}, nil)
 )
 var (
-	metricsReplExecutorTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	metricsReplExecutorTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_executor",
 		Name:      "total",
-		Help:      "total number of operations in the replication executor",
 	}, []string{"type"})
-	metricsReplExecutorQueue = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	metricsReplExecutorQueue = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_executor",
 		Name:      "queue",
-		Help:      "number of queued operations in the replication executor",
 	}, []string{"type"})
-	metricsReplExecutorEventWaiters = prometheus.NewGauge(prometheus.GaugeOpts{
+	metricsReplExecutorEventWaiters = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_executor",
 		Name:      "event_waiters",
-		Help:      "number of event waiters in the replication executor",
-	})
-	metricsReplExecutorUnsignaledEvents = prometheus.NewGauge(prometheus.GaugeOpts{
+	}, nil)
+	metricsReplExecutorUnsignaledEvents = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_executor",
 		Name:      "unsignaled_events",
-		Help:      "number of unsignaled events in the replication executor",
-	})
+	}, nil)
 )
 var (
-	metricsReplNetworkGetmoresNumTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	metricsReplNetworkGetmoresNumTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_network_getmores",
 		Name:      "num_total",
-		Help:      "num reports the total number of getmore operations, which are operations that request an additional set of operations from the replication sync source.",
-	})
-	metricsReplNetworkGetmoresTotalMilliseconds = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	metricsReplNetworkGetmoresTotalMilliseconds = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_network_getmores",
 		Name:      "total_milliseconds",
-		Help:      "total_millis reports the total amount of time required to collect data from getmore operations",
-	})
+	}, nil)
 )
 var (
-	metricsReplNetworkBytesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	metricsReplNetworkBytesTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_network",
 		Name:      "bytes_total",
-		Help:      "bytes reports the total amount of data read from the replication sync source",
-	})
-	metricsReplNetworkOpsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	metricsReplNetworkOpsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_network",
 		Name:      "ops_total",
-		Help:      "ops reports the total number of operations read from the replication source.",
-	})
-	metricsReplNetworkReadersCreatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	metricsReplNetworkReadersCreatedTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_network",
 		Name:      "readers_created_total",
-		Help:      "readersCreated reports the total number of oplog query processes created. MongoDB will create a new oplog query any time an error occurs in the connection, including a timeout, or a network operation. Furthermore, readersCreated will increment every time MongoDB selects a new source fore replication.",
-	})
+	}, nil)
 )
 var (
-	metricsReplOplogInsertNumTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	metricsReplOplogInsertNumTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_oplog_insert",
 		Name:      "num_total",
-		Help:      "num reports the total number of items inserted into the oplog.",
-	})
-	metricsReplOplogInsertTotalMilliseconds = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	metricsReplOplogInsertTotalMilliseconds = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_oplog_insert",
 		Name:      "total_milliseconds",
-		Help:      "total_millis reports the total amount of time spent for the mongod to insert data into the oplog.",
-	})
+	}, nil)
 )
 var (
-	metricsReplOplogInsertBytesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	metricsReplOplogInsertBytesTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_oplog",
 		Name:      "insert_bytes_total",
-		Help:      "insertBytes the total size of documents inserted into the oplog.",
-	})
+	}, nil)
 )
 var (
-	metricsReplPreloadDocsNumTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	metricsReplPreloadDocsNumTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_preload_docs",
 		Name:      "num_total",
-		Help:      "num reports the total number of documents loaded during the pre-fetch stage of replication",
-	})
-	metricsReplPreloadDocsTotalMilliseconds = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	metricsReplPreloadDocsTotalMilliseconds = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_preload_docs",
 		Name:      "total_milliseconds",
-		Help:      "total_millis reports the total amount of time spent loading documents as part of the pre-fetch stage of replication",
-	})
+	}, nil)
 )
 var (
-	metricsReplPreloadIndexesNumTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	metricsReplPreloadIndexesNumTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_preload_indexes",
 		Name:      "num_total",
-		Help:      "num reports the total number of index entries loaded by members before updating documents as part of the pre-fetch stage of replication",
-	})
-	metricsReplPreloadIndexesTotalMilliseconds = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	metricsReplPreloadIndexesTotalMilliseconds = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_repl_preload_indexes",
 		Name:      "total_milliseconds",
-		Help:      "total_millis reports the total amount of time spent loading index entries as part of the pre-fetch stage of replication",
-	})
+	}, nil)
 )
 var (
-	metricsStorageFreelistSearchTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	metricsStorageFreelistSearchTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "metrics_storage_freelist_search_total",
-		Help:      "metrics about searching records in the database.",
 	}, []string{"type"})
 )
 var (
-	metricsTTLDeletedDocumentsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	metricsTTLDeletedDocumentsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_ttl",
 		Name:      "deleted_documents_total",
-		Help:      "deletedDocuments reports the total number of documents deleted from collections with a ttl index.",
-	})
-	metricsTTLPassesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	metricsTTLPassesTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "metrics_ttl",
 		Name:      "passes_total",
-		Help:      "passes reports the number of times the background process removes documents from collections with a ttl index",
-	})
+	}, nil)
 )
 
 // DocumentStats are the stats associated to a document.
@@ -253,11 +220,11 @@ type DocumentStats struct {
 }
 
 // Export exposes the document stats to be consumed by the prometheus server.
-func (documentStats *DocumentStats) Export(ch chan<- prometheus.Metric) {
-	metricsDocumentTotal.WithLabelValues("deleted").Add(documentStats.Deleted)
-	metricsDocumentTotal.WithLabelValues("inserted").Add(documentStats.Inserted)
-	metricsDocumentTotal.WithLabelValues("returned").Add(documentStats.Returned)
-	metricsDocumentTotal.WithLabelValues("updated").Add(documentStats.Updated)
+func (documentStats *DocumentStats) Export(ch *shared.MetricChannel) {
+	ch.Send(metricsDocumentTotal, documentStats.Deleted, "deleted")
+	ch.Send(metricsDocumentTotal, documentStats.Inserted, "inserted")
+	ch.Send(metricsDocumentTotal, documentStats.Returned, "returned")
+	ch.Send(metricsDocumentTotal, documentStats.Updated, "updated")
 }
 
 // BenchmarkStats is bechmark info about an operation.
@@ -273,11 +240,11 @@ type GetLastErrorStats struct {
 }
 
 // Export exposes the get last error stats.
-func (getLastErrorStats *GetLastErrorStats) Export(ch chan<- prometheus.Metric) {
-	metricsGetLastErrorWtimeNumTotal.Set(getLastErrorStats.Wtime.Num)
-	metricsGetLastErrorWtimeTotalMilliseconds.Add(getLastErrorStats.Wtime.TotalMillis)
+func (getLastErrorStats *GetLastErrorStats) Export(ch *shared.MetricChannel) {
+	ch.Send(metricsGetLastErrorWtimeNumTotal, getLastErrorStats.Wtime.Num)
+	ch.Send(metricsGetLastErrorWtimeTotalMilliseconds, getLastErrorStats.Wtime.TotalMillis)
 
-	metricsGetLastErrorWtimeoutsTotal.Add(getLastErrorStats.Wtimeouts)
+	ch.Send(metricsGetLastErrorWtimeoutsTotal, getLastErrorStats.Wtimeouts)
 }
 
 // OperationStats are the stats for some kind of operations.
@@ -288,10 +255,10 @@ type OperationStats struct {
 }
 
 // Export exports the operation stats.
-func (operationStats *OperationStats) Export(ch chan<- prometheus.Metric) {
-	metricsOperationTotal.WithLabelValues("fastmod").Add(operationStats.Fastmod)
-	metricsOperationTotal.WithLabelValues("idhack").Add(operationStats.Idhack)
-	metricsOperationTotal.WithLabelValues("scan_and_order").Add(operationStats.ScanAndOrder)
+func (operationStats *OperationStats) Export(ch *shared.MetricChannel) {
+	ch.Send(metricsOperationTotal, operationStats.Fastmod, "fastmod")
+	ch.Send(metricsOperationTotal, operationStats.Idhack, "idhack")
+	ch.Send(metricsOperationTotal, operationStats.ScanAndOrder, "scan_and_order")
 }
 
 // QueryExecutorStats are the stats associated with a query execution.
@@ -301,9 +268,9 @@ type QueryExecutorStats struct {
 }
 
 // Export exports the query executor stats.
-func (queryExecutorStats *QueryExecutorStats) Export(ch chan<- prometheus.Metric) {
-	metricsQueryExecutorTotal.WithLabelValues("scanned").Add(queryExecutorStats.Scanned)
-	metricsQueryExecutorTotal.WithLabelValues("scanned_objects").Add(queryExecutorStats.ScannedObjects)
+func (queryExecutorStats *QueryExecutorStats) Export(ch *shared.MetricChannel) {
+	ch.Send(metricsQueryExecutorTotal, queryExecutorStats.Scanned, "scanned")
+	ch.Send(metricsQueryExecutorTotal, queryExecutorStats.ScannedObjects, "scanned_objects")
 }
 
 // RecordStats are stats associated with a record.
@@ -312,8 +279,8 @@ type RecordStats struct {
 }
 
 // Export exposes the record stats.
-func (recordStats *RecordStats) Export(ch chan<- prometheus.Metric) {
-	metricsRecordMovesTotal.Add(recordStats.Moves)
+func (recordStats *RecordStats) Export(ch *shared.MetricChannel) {
+	ch.Send(metricsRecordMovesTotal, recordStats.Moves)
 }
 
 // ApplyStats are the stats associated with the apply operation.
@@ -323,11 +290,11 @@ type ApplyStats struct {
 }
 
 // Export exports the apply stats
-func (applyStats *ApplyStats) Export(ch chan<- prometheus.Metric) {
-	metricsReplApplyOpsTotal.Add(applyStats.Ops)
+func (applyStats *ApplyStats) Export(ch *shared.MetricChannel) {
+	ch.Send(metricsReplApplyOpsTotal, applyStats.Ops)
 
-	metricsReplApplyBatchesNumTotal.Add(applyStats.Batches.Num)
-	metricsReplApplyBatchesTotalMilliseconds.Add(applyStats.Batches.TotalMillis)
+	ch.Send(metricsReplApplyBatchesNumTotal, applyStats.Batches.Num)
+	ch.Send(metricsReplApplyBatchesTotalMilliseconds, applyStats.Batches.TotalMillis)
 }
 
 // BufferStats are the stats associated with the buffer
@@ -338,10 +305,10 @@ type BufferStats struct {
 }
 
 // Export exports the buffer stats.
-func (bufferStats *BufferStats) Export(ch chan<- prometheus.Metric) {
-	metricsReplBufferCount.Set(bufferStats.Count)
-	metricsReplBufferMaxSizeBytes.Add(bufferStats.MaxSizeBytes)
-	metricsReplBufferSizeBytes.Set(bufferStats.SizeBytes)
+func (bufferStats *BufferStats) Export(ch *shared.MetricChannel) {
+	ch.Send(metricsReplBufferCount, bufferStats.Count)
+	ch.Send(metricsReplBufferMaxSizeBytes, bufferStats.MaxSizeBytes)
+	ch.Send(metricsReplBufferSizeBytes, bufferStats.SizeBytes)
 }
 
 // ReplExecutorStats are the stats associated with replication execution
@@ -353,15 +320,15 @@ type ReplExecutorStats struct {
 }
 
 // Export replication executor stats
-func (replExecutorStats *ReplExecutorStats) Export(ch chan<- prometheus.Metric) {
+func (replExecutorStats *ReplExecutorStats) Export(ch *shared.MetricChannel) {
 	for key, val := range replExecutorStats.Counters {
-		metricsReplExecutorTotal.WithLabelValues(key).Add(val)
+		ch.Send(metricsReplExecutorTotal, val, key)
 	}
 	for key, val := range replExecutorStats.Queues {
-		metricsReplExecutorQueue.WithLabelValues(key).Set(val)
+		ch.Send(metricsReplExecutorQueue, val, key)
 	}
-	metricsReplExecutorEventWaiters.Set(replExecutorStats.EventWaiters)
-	metricsReplExecutorUnsignaledEvents.Set(replExecutorStats.UnsignaledEvents)
+	ch.Send(metricsReplExecutorEventWaiters, replExecutorStats.EventWaiters)
+	ch.Send(metricsReplExecutorUnsignaledEvents, replExecutorStats.UnsignaledEvents)
 }
 
 // MetricsNetworkStats are the network stats.
@@ -373,13 +340,28 @@ type MetricsNetworkStats struct {
 }
 
 // Export exposes the network stats.
-func (metricsNetworkStats *MetricsNetworkStats) Export(ch chan<- prometheus.Metric) {
-	metricsReplNetworkBytesTotal.Add(metricsNetworkStats.Bytes)
-	metricsReplNetworkOpsTotal.Add(metricsNetworkStats.Ops)
-	metricsReplNetworkReadersCreatedTotal.Add(metricsNetworkStats.ReadersCreated)
+func (metricsNetworkStats *MetricsNetworkStats) Export(ch *shared.MetricChannel) {
+	ch.Send(metricsReplNetworkBytesTotal, metricsNetworkStats.Bytes)
+	ch.Send(metricsReplNetworkOpsTotal, metricsNetworkStats.Ops)
+	ch.Send(metricsReplNetworkReadersCreatedTotal, metricsNetworkStats.ReadersCreated)
 
-	metricsReplNetworkGetmoresNumTotal.Add(metricsNetworkStats.GetMores.Num)
-	metricsReplNetworkGetmoresTotalMilliseconds.Add(metricsNetworkStats.GetMores.TotalMillis)
+	ch.Send(metricsReplNetworkGetmoresNumTotal, metricsNetworkStats.GetMores.Num)
+	ch.Send(metricsReplNetworkGetmoresTotalMilliseconds, metricsNetworkStats.GetMores.TotalMillis)
+}
+
+// ReplOplogStats are the stats of the writes to the oplog.
+type ReplOplogStats struct {
+	Insert      *BenchmarkStats `bson:"insert"`
+	InsertBytes float64         `bson:"insertBytes"`
+}
+
+// Export exports the oplog stats.
+func (replOplogStats *ReplOplogStats) Export(ch *shared.MetricChannel) {
+	if replOplogStats.Insert != nil {
+		ch.Send(metricsReplOplogInsertNumTotal, replOplogStats.Insert.Num)
+		ch.Send(metricsReplOplogInsertTotalMilliseconds, replOplogStats.Insert.TotalMillis)
+	}
+	ch.Send(metricsReplOplogInsertBytesTotal, replOplogStats.InsertBytes)
 }
 
 // ReplStats are the stats associated with the replication process.
@@ -388,11 +370,12 @@ type ReplStats struct {
 	Buffer       *BufferStats         `bson:"buffer"`
 	Executor     *ReplExecutorStats   `bson:"executor,omitempty"`
 	Network      *MetricsNetworkStats `bson:"network"`
+	Oplog        *ReplOplogStats      `bson:"oplog"`
 	PreloadStats *PreloadStats        `bson:"preload"`
 }
 
 // Export exposes the replication stats.
-func (replStats *ReplStats) Export(ch chan<- prometheus.Metric) {
+func (replStats *ReplStats) Export(ch *shared.MetricChannel) {
 	replStats.Apply.Export(ch)
 	replStats.Buffer.Export(ch)
 	replStats.Network.Export(ch)
@@ -401,6 +384,9 @@ func (replStats *ReplStats) Export(ch chan<- prometheus.Metric) {
 	if replStats.Executor != nil {
 		replStats.Executor.Export(ch)
 	}
+	if replStats.Oplog != nil {
+		replStats.Oplog.Export(ch)
+	}
 }
 
 // PreloadStats are the stats associated with preload operation.
@@ -410,12 +396,12 @@ type PreloadStats struct {
 }
 
 // Export exposes the preload stats.
-func (preloadStats *PreloadStats) Export(ch chan<- prometheus.Metric) {
-	metricsReplPreloadDocsNumTotal.Add(preloadStats.Docs.Num)
-	metricsReplPreloadDocsTotalMilliseconds.Add(preloadStats.Docs.TotalMillis)
+func (preloadStats *PreloadStats) Export(ch *shared.MetricChannel) {
+	ch.Send(metricsReplPreloadDocsNumTotal, preloadStats.Docs.Num)
+	ch.Send(metricsReplPreloadDocsTotalMilliseconds, preloadStats.Docs.TotalMillis)
 
-	metricsReplPreloadIndexesNumTotal.Add(preloadStats.Indexes.Num)
-	metricsReplPreloadIndexesTotalMilliseconds.Add(preloadStats.Indexes.TotalMillis)
+	ch.Send(metricsReplPreloadIndexesNumTotal, preloadStats.Indexes.Num)
+	ch.Send(metricsReplPreloadIndexesTotalMilliseconds, preloadStats.Indexes.TotalMillis)
 }
 
 // StorageStats are the stats associated with the storage.
@@ -426,10 +412,22 @@ type StorageStats struct {
 }
 
 // Export exports the storage stats.
-func (storageStats *StorageStats) Export(ch chan<- prometheus.Metric) {
-	metricsStorageFreelistSearchTotal.WithLabelValues("bucket_exhausted").Add(storageStats.BucketExhausted)
-	metricsStorageFreelistSearchTotal.WithLabelValues("requests").Add(storageStats.Requests)
-	metricsStorageFreelistSearchTotal.WithLabelValues("scanned").Add(storageStats.Scanned)
+func (storageStats *StorageStats) Export(ch *shared.MetricChannel) {
+	ch.Send(metricsStorageFreelistSearchTotal, storageStats.BucketExhausted, "bucket_exhausted")
+	ch.Send(metricsStorageFreelistSearchTotal, storageStats.Requests, "requests")
+	ch.Send(metricsStorageFreelistSearchTotal, storageStats.Scanned, "scanned")
+}
+
+// TTLStats are the stats of the deletions of the TTL indexes.
+type TTLStats struct {
+	DeletedDocuments float64 `bson:"deletedDocuments"`
+	Passes           float64 `bson:"passes"`
+}
+
+// Export exports the TTL stats.
+func (ttlStats *TTLStats) Export(ch *shared.MetricChannel) {
+	ch.Send(metricsTTLDeletedDocumentsTotal, ttlStats.DeletedDocuments)
+	ch.Send(metricsTTLPassesTotal, ttlStats.Passes)
 }
 
 // CursorStatsOpen are the stats for open cursors
@@ -446,11 +444,11 @@ type CursorStats struct {
 }
 
 // Export exports the cursor stats.
-func (cursorStats *CursorStats) Export(ch chan<- prometheus.Metric) {
-	metricsCursorTimedOutTotal.Add(cursorStats.TimedOut)
-	metricsCursorOpen.WithLabelValues("noTimeout").Set(cursorStats.Open.NoTimeout)
-	metricsCursorOpen.WithLabelValues("pinned").Set(cursorStats.Open.Pinned)
-	metricsCursorOpen.WithLabelValues("total").Set(cursorStats.Open.Total)
+func (cursorStats *CursorStats) Export(ch *shared.MetricChannel) {
+	ch.Send(metricsCursorTimedOutTotal, cursorStats.TimedOut)
+	ch.Send(metricsCursorOpen, cursorStats.Open.NoTimeout, "noTimeout")
+	ch.Send(metricsCursorOpen, cursorStats.Open.Pinned, "pinned")
+	ch.Send(metricsCursorOpen, cursorStats.Open.Total, "total")
 }
 
 // MetricsStats are all stats associated with metrics of the system
@@ -463,10 +461,11 @@ type MetricsStats struct {
 	Repl          *ReplStats          `bson:"repl"`
 	Storage       *StorageStats       `bson:"storage"`
 	Cursor        *CursorStats        `bson:"cursor"`
+	TTL           *TTLStats           `bson:"ttl"`
 }
 
 // Export exports the metrics stats.
-func (metricsStats *MetricsStats) Export(ch chan<- prometheus.Metric) {
+func (metricsStats *MetricsStats) Export(ch *shared.MetricChannel) {
 	if metricsStats.Document != nil {
 		metricsStats.Document.Export(ch)
 	}
@@ -491,77 +490,7 @@ func (metricsStats *MetricsStats) Export(ch chan<- prometheus.Metric) {
 	if metricsStats.Cursor != nil {
 		metricsStats.Cursor.Export(ch)
 	}
-
-	metricsCursorTimedOutTotal.Collect(ch)
-	metricsCursorOpen.Collect(ch)
-	metricsDocumentTotal.Collect(ch)
-	metricsGetLastErrorWtimeNumTotal.Collect(ch)
-	metricsGetLastErrorWtimeTotalMilliseconds.Collect(ch)
-	metricsGetLastErrorWtimeoutsTotal.Collect(ch)
-	metricsOperationTotal.Collect(ch)
-	metricsQueryExecutorTotal.Collect(ch)
-	metricsRecordMovesTotal.Collect(ch)
-	metricsReplApplyBatchesNumTotal.Collect(ch)
-	metricsReplApplyBatchesTotalMilliseconds.Collect(ch)
-	metricsReplApplyOpsTotal.Collect(ch)
-	metricsReplBufferCount.Collect(ch)
-	metricsReplBufferMaxSizeBytes.Collect(ch)
-	metricsReplBufferSizeBytes.Collect(ch)
-	metricsReplExecutorTotal.Collect(ch)
-	metricsReplExecutorQueue.Collect(ch)
-	metricsReplExecutorEventWaiters.Collect(ch)
-	metricsReplExecutorUnsignaledEvents.Collect(ch)
-	metricsReplNetworkGetmoresNumTotal.Collect(ch)
-	metricsReplNetworkGetmoresTotalMilliseconds.Collect(ch)
-	metricsReplNetworkBytesTotal.Collect(ch)
-	metricsReplNetworkOpsTotal.Collect(ch)
-	metricsReplNetworkReadersCreatedTotal.Collect(ch)
-	metricsReplOplogInsertNumTotal.Collect(ch)
-	metricsReplOplogInsertTotalMilliseconds.Collect(ch)
-	metricsReplOplogInsertBytesTotal.Collect(ch)
-	metricsReplPreloadDocsNumTotal.Collect(ch)
-	metricsReplPreloadDocsTotalMilliseconds.Collect(ch)
-	metricsReplPreloadIndexesNumTotal.Collect(ch)
-	metricsReplPreloadIndexesTotalMilliseconds.Collect(ch)
-	metricsStorageFreelistSearchTotal.Collect(ch)
-	metricsTTLDeletedDocumentsTotal.Collect(ch)
-	metricsTTLPassesTotal.Collect(ch)
-}
-
-// Describe describes the metrics for prometheus
-func (metricsStats *MetricsStats) Describe(ch chan<- *prometheus.Desc) {
-	metricsCursorTimedOutTotal.Describe(ch)
-	metricsCursorOpen.Describe(ch)
-	metricsDocumentTotal.Describe(ch)
-	metricsGetLastErrorWtimeNumTotal.Describe(ch)
-	metricsGetLastErrorWtimeTotalMilliseconds.Describe(ch)
-	metricsGetLastErrorWtimeoutsTotal.Describe(ch)
-	metricsOperationTotal.Describe(ch)
-	metricsQueryExecutorTotal.Describe(ch)
-	metricsRecordMovesTotal.Describe(ch)
-	metricsReplApplyBatchesNumTotal.Describe(ch)
-	metricsReplApplyBatchesTotalMilliseconds.Describe(ch)
-	metricsReplApplyOpsTotal.Describe(ch)
-	metricsReplBufferCount.Describe(ch)
-	metricsReplBufferMaxSizeBytes.Describe(ch)
-	metricsReplBufferSizeBytes.Describe(ch)
-	metricsReplExecutorTotal.Describe(ch)
-	metricsReplExecutorQueue.Describe(ch)
-	metricsReplExecutorEventWaiters.Describe(ch)
-	metricsReplExecutorUnsignaledEvents.Describe(ch)
-	metricsReplNetworkGetmoresNumTotal.Describe(ch)
-	metricsReplNetworkGetmoresTotalMilliseconds.Describe(ch)
-	metricsReplNetworkBytesTotal.Describe(ch)
-	metricsReplNetworkOpsTotal.Describe(ch)
-	metricsReplNetworkReadersCreatedTotal.Describe(ch)
-	metricsReplOplogInsertNumTotal.Describe(ch)
-	metricsReplOplogInsertTotalMilliseconds.Describe(ch)
-	metricsReplOplogInsertBytesTotal.Describe(ch)
-	metricsReplPreloadDocsNumTotal.Describe(ch)
-	metricsReplPreloadDocsTotalMilliseconds.Describe(ch)
-	metricsReplPreloadIndexesNumTotal.Describe(ch)
-	metricsReplPreloadIndexesTotalMilliseconds.Describe(ch)
-	metricsStorageFreelistSearchTotal.Describe(ch)
-	metricsTTLDeletedDocumentsTotal.Describe(ch)
-	metricsTTLPassesTotal.Describe(ch)
+	if metricsStats.TTL != nil {
+		metricsStats.TTL.Export(ch)
+	}
 }
@@ -1,53 +1,168 @@
 package collector_mongod
 
 import (
+	"sync"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
-	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
 var (
-	oplogStatusCount = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"replset_oplog",
-		Name:		"items_total",
-		Help:		"The total number of changes in the oplog",
-	})
-	oplogStatusHeadTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"replset_oplog",
-		Name:		"head_timestamp",
-		Help:		"The timestamp of the newest change in the oplog",
-	})
-	oplogStatusTailTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"replset_oplog",
-		Name:		"tail_timestamp",
-		Help:		"The timestamp of the oldest change in the oplog",
-	})
-	oplogStatusSizeBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"replset_oplog",
-		Name:		"size_bytes",
-		Help:		"Size of oplog in bytes",
+	oplogStatusCount = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "replset_oplog",
+		Name:      "items_total",
+	}, nil)
+	oplogStatusHeadTimestamp = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "replset_oplog",
+		Name:      "head_timestamp",
+	}, nil)
+	oplogStatusTailTimestamp = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "replset_oplog",
+		Name:      "tail_timestamp",
+	}, nil)
+	oplogStatusSizeBytes = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "replset_oplog",
+		Name:      "size_bytes",
 	}, []string{"type"})
+	oplogStatusWindowSeconds = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "replset_oplog",
+		Name:      "window_seconds",
+	}, nil)
+	oplogStatusOpsPerSecond = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "replset_oplog",
+		Name:      "ops_per_second",
+	}, nil)
+	oplogStatusBytesPerSecond = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "replset_oplog",
+		Name:      "bytes_per_second",
+	}, nil)
+	oplogStatusProjectedWindowHours = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "replset_oplog",
+		Name:      "projected_window_hours",
+	}, nil)
+	oplogStatusRatesTruncated = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "replset_oplog",
+		Name:      "rates_truncated",
+	}, nil)
 )
 
+// DefaultOplogScanLimit is the default maximum number of changes read from the oplog head to count the changes since
+// the previous scrape
+const DefaultOplogScanLimit = 10000
+
 type OplogCollectionStats struct {
-	Count		float64	`bson:"count"`
-	Size		float64	`bson:"size"`
-	StorageSize	float64 `bson:"storageSize"`
+	Count       float64 `bson:"count"`
+	Size        float64 `bson:"size"`
+	StorageSize float64 `bson:"storageSize"`
+	MaxSize     float64 `bson:"maxSize"`
 }
 
 type OplogTimestamps struct {
-	Tail	float64
-	Head	float64
+	Tail          float64
+	Head          float64
+	HeadTimestamp bson.MongoTimestamp
+}
+
+// OplogRates are the oplog growth rates between two scrapes
+type OplogRates struct {
+	OpsPerSecond   float64
+	BytesPerSecond float64
+	// Truncated is true when more than the scan limit of changes were written since the previous scrape,
+	// the rates are then those of the last changes up to the limit
+	Truncated bool
 }
 
 type OplogStatus struct {
-	OplogTimestamps	*OplogTimestamps
-	CollectionStats	*OplogCollectionStats
+	OplogTimestamps *OplogTimestamps
+	CollectionStats *OplogCollectionStats
+	Rates           *OplogRates
+}
+
+// OplogRateTracker carries the oplog head across scrapes, to compute the oplog growth rates
+type OplogRateTracker struct {
+	// ScanLimit is the maximum number of changes read from the oplog head per scrape (0 = DefaultOplogScanLimit)
+	ScanLimit int
+
+	mu            sync.Mutex
+	headTimestamp bson.MongoTimestamp
+	sampleTime    time.Time
+}
+
+// Rates counts the changes written to the oplog since the previous call and returns the growth rates,
+// or nil on the first call. When more than ScanLimit changes were written, only the last ones are
+// counted and the rates are computed over the time between them. When the changes cannot be counted,
+// the next call counts them from the same head.
+func (tracker *OplogRateTracker) Rates(session shared.Session, timestamps *OplogTimestamps, stats *OplogCollectionStats) *OplogRates {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	now := time.Now()
+	if tracker.sampleTime.IsZero() {
+		tracker.headTimestamp, tracker.sampleTime = timestamps.HeadTimestamp, now
+		return nil
+	}
+
+	limit := tracker.ScanLimit
+	if limit <= 0 {
+		limit = DefaultOplogScanLimit
+	}
+	ops, oldest, err := CountOplogEntriesSince(session, tracker.headTimestamp, limit)
+	if err != nil {
+		glog.Errorf("Failed to count oplog changes: %s", err)
+		return nil
+	}
+
+	seconds := now.Sub(tracker.sampleTime).Seconds()
+	tracker.headTimestamp, tracker.sampleTime = timestamps.HeadTimestamp, now
+	truncated := ops >= limit
+	if truncated {
+		glog.Warningf("More than %d changes were written to the oplog since the previous scrape, the oplog rates are those of the last %d changes", limit, ops)
+		if window := BsonMongoTimestampToUnix(timestamps.HeadTimestamp) - BsonMongoTimestampToUnix(oldest); window > 0 && window < seconds {
+			seconds = window
+		}
+	}
+	return newOplogRates(ops, seconds, truncated, stats)
+}
+
+// newOplogRates returns the growth rates of ops changes written in seconds, the bytes being estimated
+// from the average change size of the oplog.
+func newOplogRates(ops int, seconds float64, truncated bool, stats *OplogCollectionStats) *OplogRates {
+	rates := &OplogRates{OpsPerSecond: float64(ops) / seconds, Truncated: truncated}
+	if stats != nil && stats.Count > 0 {
+		rates.BytesPerSecond = rates.OpsPerSecond * stats.Size / stats.Count
+	}
+	return rates
+}
+
+// CountOplogEntriesSince counts the oplog changes newer than timestamp, reading backwards from the head up to
+// limit changes, and returns the timestamp of the oldest change counted
+func CountOplogEntriesSince(session shared.Session, timestamp bson.MongoTimestamp, limit int) (int, bson.MongoTimestamp, error) {
+	var entry struct {
+		Timestamp bson.MongoTimestamp `bson:"ts"`
+	}
+	count := 0
+	oldest := timestamp
+	iter := session.Find("local", "oplog.rs", nil).Select(bson.M{"ts": 1, "_id": 0}).Sort("-$natural").Batch(1000).Iter()
+	for count < limit && iter.Next(&entry) {
+		if entry.Timestamp <= timestamp {
+			break
+		}
+		oldest = entry.Timestamp
+		count += 1
+	}
+	return count, oldest, iter.Close()
 }
 
 // there's gotta be a better way to do this, but it works for now :/
@@ -55,15 +170,17 @@ func BsonMongoTimestampToUnix(timestamp bson.MongoTimestamp) float64 {
 	return float64(timestamp >> 32)
 }
 
-func GetOplogTimestamps(session *mgo.Session) (*OplogTimestamps, error) {
+func GetOplogTimestamps(session shared.Session) (*OplogTimestamps, error) {
 	oplogTimestamps := &OplogTimestamps{}
 	var err error
 
 	// retry once if there is an error
 	var tries int64 = 0
-	var head_result struct { Timestamp	bson.MongoTimestamp	`bson:"ts"` }
+	var head_result struct {
+		Timestamp bson.MongoTimestamp `bson:"ts"`
+	}
 	for tries < 2 {
-		err = session.DB("local").C("oplog.rs").Find(nil).Sort("-$natural").Limit(1).One(&head_result)
+		err = session.Find("local", "oplog.rs", nil).Sort("-$natural").Limit(1).One(&head_result)
 		if err == nil {
 			break
 		}
@@ -75,9 +192,11 @@ func GetOplogTimestamps(session *mgo.Session) (*OplogTimestamps, error) {
 
 	// retry once if there is an error
 	tries = 0
-	var tail_result struct { Timestamp	bson.MongoTimestamp	`bson:"ts"` }
+	var tail_result struct {
+		Timestamp bson.MongoTimestamp `bson:"ts"`
+	}
 	for tries < 2 {
-		err = session.DB("local").C("oplog.rs").Find(nil).Sort("$natural").Limit(1).One(&tail_result)
+		err = session.Find("local", "oplog.rs", nil).Sort("$natural").Limit(1).One(&tail_result)
 		if err == nil {
 			break
 		}
@@ -89,42 +208,47 @@ func GetOplogTimestamps(session *mgo.Session) (*OplogTimestamps, error) {
 
 	oplogTimestamps.Tail = BsonMongoTimestampToUnix(tail_result.Timestamp)
 	oplogTimestamps.Head = BsonMongoTimestampToUnix(head_result.Timestamp)
+	oplogTimestamps.HeadTimestamp = head_result.Timestamp
 	return oplogTimestamps, err
 }
 
-func GetOplogCollectionStats(session *mgo.Session) (*OplogCollectionStats, error) {
+func GetOplogCollectionStats(session shared.Session) (*OplogCollectionStats, error) {
 	results := &OplogCollectionStats{}
-	err := session.DB("local").Run(bson.M{ "collStats" : "oplog.rs" }, &results)
+	err := session.Run("local", bson.M{"collStats": "oplog.rs"}, &results)
 	return results, err
 }
 
-func (status *OplogStatus) Export(ch chan<- prometheus.Metric) {
-	oplogStatusSizeBytes.WithLabelValues("current").Set(0)
-	oplogStatusSizeBytes.WithLabelValues("storage").Set(0)
+func (status *OplogStatus) Export(ch *shared.MetricChannel) {
 	if status.CollectionStats != nil {
-		oplogStatusCount.Set(status.CollectionStats.Count)
-		oplogStatusSizeBytes.WithLabelValues("current").Set(status.CollectionStats.Size)
-		oplogStatusSizeBytes.WithLabelValues("storage").Set(status.CollectionStats.StorageSize)
+		ch.Send(oplogStatusCount, status.CollectionStats.Count)
+		ch.Send(oplogStatusSizeBytes, status.CollectionStats.Size, "current")
+		ch.Send(oplogStatusSizeBytes, status.CollectionStats.StorageSize, "storage")
+		if status.CollectionStats.MaxSize > 0 {
+			ch.Send(oplogStatusSizeBytes, status.CollectionStats.MaxSize, "max")
+		}
 	}
 	if status.OplogTimestamps != nil {
-		oplogStatusHeadTimestamp.Set(status.OplogTimestamps.Head)
-		oplogStatusTailTimestamp.Set(status.OplogTimestamps.Tail)
+		ch.Send(oplogStatusHeadTimestamp, status.OplogTimestamps.Head)
+		ch.Send(oplogStatusTailTimestamp, status.OplogTimestamps.Tail)
+		ch.Send(oplogStatusWindowSeconds, status.OplogTimestamps.Head-status.OplogTimestamps.Tail)
 	}
 
-	oplogStatusCount.Collect(ch)
-	oplogStatusHeadTimestamp.Collect(ch)
-	oplogStatusTailTimestamp.Collect(ch)
-	oplogStatusSizeBytes.Collect(ch)
-}
-
-func (status *OplogStatus) Describe(ch chan<- *prometheus.Desc) {
-	oplogStatusCount.Describe(ch)
-	oplogStatusHeadTimestamp.Describe(ch)
-	oplogStatusTailTimestamp.Describe(ch)
-	oplogStatusSizeBytes.Describe(ch)
+	if status.Rates != nil {
+		ch.Send(oplogStatusOpsPerSecond, status.Rates.OpsPerSecond)
+		ch.Send(oplogStatusBytesPerSecond, status.Rates.BytesPerSecond)
+		truncated := 0.0
+		if status.Rates.Truncated {
+			truncated = 1
+		}
+		ch.Send(oplogStatusRatesTruncated, truncated)
+		if status.CollectionStats != nil && status.CollectionStats.MaxSize > 0 && status.Rates.BytesPerSecond > 0 {
+			ch.Send(oplogStatusProjectedWindowHours, status.CollectionStats.MaxSize/status.Rates.BytesPerSecond/3600)
+		}
+	}
 }
 
-func GetOplogStatus(session *mgo.Session) *OplogStatus {
+// GetOplogStatus returns the oplog status, with the growth rates since the previous call when tracker is not nil
+func GetOplogStatus(session shared.Session, tracker *OplogRateTracker) *OplogStatus {
 	collectionStats, err := GetOplogCollectionStats(session)
 	oplogTimestamps, err := GetOplogTimestamps(session)
 	if err != nil {
@@ -132,5 +256,9 @@ func GetOplogStatus(session *mgo.Session) *OplogStatus {
 		return nil
 	}
 
-	return &OplogStatus{CollectionStats:collectionStats,OplogTimestamps:oplogTimestamps}
+	status := &OplogStatus{CollectionStats: collectionStats, OplogTimestamps: oplogTimestamps}
+	if tracker != nil {
+		status.Rates = tracker.Rates(session, oplogTimestamps, collectionStats)
+	}
+	return status
 }
@@ -0,0 +1,128 @@
+package collector_mongod
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func Test_OplogRatesFirstScrape(t *testing.T) {
+	tracker := &OplogRateTracker{}
+	timestamps := &OplogTimestamps{Tail: 1000, Head: 4600, HeadTimestamp: 4600 << 32}
+
+	if rates := tracker.Rates(nil, timestamps, nil); rates != nil {
+		t.Errorf("expected no rates on the first scrape, got %+v", rates)
+	}
+	if tracker.headTimestamp != timestamps.HeadTimestamp {
+		t.Errorf("expected the head timestamp to be remembered, got %v", tracker.headTimestamp)
+	}
+}
+
+func Test_OplogStatusExport(t *testing.T) {
+	status := &OplogStatus{
+		OplogTimestamps: &OplogTimestamps{Tail: 1000, Head: 4600},
+		CollectionStats: &OplogCollectionStats{Count: 100, Size: 1000, MaxSize: 3600000},
+		Rates:           &OplogRates{OpsPerSecond: 10, BytesPerSecond: 100},
+	}
+
+	values, err := sessiontest.GatherExport(status.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["mongodb_mongod_replset_oplog_window_seconds"] != 3600 {
+		t.Errorf("expected an oplog window of 3600s, got %v", values["mongodb_mongod_replset_oplog_window_seconds"])
+	}
+	if values["mongodb_mongod_replset_oplog_projected_window_hours"] != 10 {
+		t.Errorf("expected a projected window of 10h, got %v", values["mongodb_mongod_replset_oplog_projected_window_hours"])
+	}
+}
+
+func oplogSession(timestamps ...int64) *sessiontest.Session {
+	entries := []bson.M{}
+	for _, ts := range timestamps {
+		entries = append(entries, bson.M{"ts": bson.MongoTimestamp(ts << 32), "$natural": ts})
+	}
+	return &sessiontest.Session{Collections: map[string][]bson.M{"local.oplog.rs": entries}}
+}
+
+func Test_CountOplogEntriesSince(t *testing.T) {
+	session := oplogSession(100, 101, 102, 103, 104, 105)
+
+	tests := []struct {
+		since  int64
+		limit  int
+		count  int
+		oldest int64
+	}{
+		{since: 102, limit: 10, count: 3, oldest: 103},
+		{since: 105, limit: 10, count: 0, oldest: 105},
+		{since: 99, limit: 4, count: 4, oldest: 102},
+	}
+	for _, test := range tests {
+		count, oldest, err := CountOplogEntriesSince(session, bson.MongoTimestamp(test.since<<32), test.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != test.count || oldest != bson.MongoTimestamp(test.oldest<<32) {
+			t.Errorf("since %d, limit %d: expected %d changes down to %d, got %d down to %d", test.since, test.limit, test.count, test.oldest, count, oldest>>32)
+		}
+	}
+}
+
+func Test_OplogRates(t *testing.T) {
+	tracker := &OplogRateTracker{headTimestamp: 102 << 32, sampleTime: time.Now().Add(-10 * time.Second)}
+	session := oplogSession(100, 101, 102, 103, 104, 105)
+
+	rates := tracker.Rates(session, &OplogTimestamps{HeadTimestamp: 105 << 32}, &OplogCollectionStats{Count: 6, Size: 600})
+	if rates == nil || rates.Truncated {
+		t.Fatalf("expected untruncated rates, got %+v", rates)
+	}
+	if rates.OpsPerSecond < 0.29 || rates.OpsPerSecond > 0.3 {
+		t.Errorf("expected 3 changes in 10s, got %v/s", rates.OpsPerSecond)
+	}
+	if math.Abs(rates.BytesPerSecond-rates.OpsPerSecond*100) > 1e-9 {
+		t.Errorf("expected 100 bytes per change, got %v/s for %v changes/s", rates.BytesPerSecond, rates.OpsPerSecond)
+	}
+}
+
+func Test_OplogRatesCountError(t *testing.T) {
+	tracker := &OplogRateTracker{headTimestamp: 102 << 32, sampleTime: time.Now().Add(-10 * time.Second)}
+	session := oplogSession(100, 101, 102, 103, 104, 105)
+	session.FindErrors = map[string]error{"local.oplog.rs": errors.New("interrupted")}
+
+	if rates := tracker.Rates(session, &OplogTimestamps{HeadTimestamp: 105 << 32}, nil); rates != nil {
+		t.Fatalf("expected no rates when the changes cannot be counted, got %+v", rates)
+	}
+	if tracker.headTimestamp != 102<<32 {
+		t.Fatalf("expected the head to stay at 102, got %d", tracker.headTimestamp>>32)
+	}
+
+	// the next scrape counts the changes since the same head
+	session.FindErrors = nil
+	rates := tracker.Rates(session, &OplogTimestamps{HeadTimestamp: 105 << 32}, nil)
+	if rates == nil || rates.OpsPerSecond < 0.29 || rates.OpsPerSecond > 0.3 {
+		t.Errorf("expected 3 changes in 10s, got %+v", rates)
+	}
+	if tracker.headTimestamp != 105<<32 {
+		t.Errorf("expected the head to move to 105, got %d", tracker.headTimestamp>>32)
+	}
+}
+
+func Test_OplogRatesTruncatedExport(t *testing.T) {
+	status := &OplogStatus{Rates: newOplogRates(DefaultOplogScanLimit, 5, true, nil)}
+
+	values, err := sessiontest.GatherExport(status.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["mongodb_mongod_replset_oplog_rates_truncated"] != 1 {
+		t.Errorf("expected the rates to be reported as truncated, got %v", values["mongodb_mongod_replset_oplog_rates_truncated"])
+	}
+	if values["mongodb_mongod_replset_oplog_ops_per_second"] != DefaultOplogScanLimit/5 {
+		t.Errorf("expected %d changes/s, got %v", DefaultOplogScanLimit/5, values["mongodb_mongod_replset_oplog_ops_per_second"])
+	}
+}
@@ -0,0 +1,248 @@
+package collector_mongod
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// profileReadLimit caps the number of profiler entries read per database and scrape,
+// the next scrapes catch up with the rest.
+const profileReadLimit = 10000
+
+var (
+	profileDurationSeconds = shared.NewHistogramDesc(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "profile",
+		Name:      "duration_seconds",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"ns", "op"})
+	profileCollscansTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "profile",
+		Name:      "collscans_total",
+	}, []string{"ns"})
+	profilePlansTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "profile",
+		Name:      "plans_total",
+	}, []string{"ns", "plan"})
+	profileExaminedTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "profile",
+		Name:      "examined_total",
+	}, []string{"ns", "op", "type"})
+	profileDocsExaminedPerKey = shared.NewHistogramDesc(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "profile",
+		Name:      "docs_examined_per_key_examined",
+		Buckets:   []float64{0.1, 0.5, 1, 2, 5, 10, 100, 1000},
+	}, []string{"ns"})
+	profileEntriesTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "profile",
+		Name:      "entries_total",
+	}, []string{"database"})
+)
+
+func init() {
+	shared.RegisterGroup("profile")
+}
+
+// ProfileEntry is a document of system.profile. The pre-3.2 field names are decoded as well.
+type ProfileEntry struct {
+	Op                 string    `bson:"op"`
+	Namespace          string    `bson:"ns"`
+	Millis             float64   `bson:"millis"`
+	Timestamp          time.Time `bson:"ts"`
+	PlanSummary        string    `bson:"planSummary"`
+	KeysExamined       *float64  `bson:"keysExamined"`
+	DocsExamined       *float64  `bson:"docsExamined"`
+	LegacyKeysExamined *float64  `bson:"nscanned"`
+	LegacyDocsExamined *float64  `bson:"nscannedObjects"`
+	Returned           float64   `bson:"nreturned"`
+}
+
+// keysExamined returns the number of index keys examined by the operation.
+func (entry *ProfileEntry) keysExamined() float64 {
+	if entry.KeysExamined != nil {
+		return *entry.KeysExamined
+	}
+	if entry.LegacyKeysExamined != nil {
+		return *entry.LegacyKeysExamined
+	}
+	return 0
+}
+
+// docsExamined returns the number of documents examined by the operation.
+func (entry *ProfileEntry) docsExamined() float64 {
+	if entry.DocsExamined != nil {
+		return *entry.DocsExamined
+	}
+	if entry.LegacyDocsExamined != nil {
+		return *entry.LegacyDocsExamined
+	}
+	return 0
+}
+
+// PlanCategory returns the first stage of a plan summary, e.g. IXSCAN for "IXSCAN { a: 1 }".
+func PlanCategory(planSummary string) string {
+	if planSummary == "" {
+		return "none"
+	}
+	if i := strings.IndexAny(planSummary, " ,"); i >= 0 {
+		return planSummary[:i]
+	}
+	return planSummary
+}
+
+type profileKey struct {
+	ns, op string
+}
+
+type profileExaminedKey struct {
+	ns, op, kind string
+}
+
+type profilePlanKey struct {
+	ns, plan string
+}
+
+// ProfileTracker tails the system.profile collection of the databases across scrapes, and accumulates
+// the counters and histograms of the profiled operations. Only the entries added since the last
+// read are read, so that every entry is counted once.
+type ProfileTracker struct {
+	mu sync.Mutex
+
+	cursors map[string]*shared.LogCursor
+
+	durations    map[profileKey]*shared.Histogram
+	collscans    map[string]float64
+	plans        map[profilePlanKey]float64
+	examined     map[profileExaminedKey]float64
+	docsPerKey   map[string]*shared.Histogram
+	entriesCount map[string]float64
+}
+
+// NewProfileTracker returns a tracker counting the profiler entries from its first update.
+func NewProfileTracker() *ProfileTracker {
+	return &ProfileTracker{
+		cursors:      map[string]*shared.LogCursor{},
+		durations:    map[profileKey]*shared.Histogram{},
+		collscans:    map[string]float64{},
+		plans:        map[profilePlanKey]float64{},
+		examined:     map[profileExaminedKey]float64{},
+		docsPerKey:   map[string]*shared.Histogram{},
+		entriesCount: map[string]float64{},
+	}
+}
+
+// Add accumulates a profiler entry of the database.
+func (tracker *ProfileTracker) Add(database string, entry *ProfileEntry) {
+	tracker.entriesCount[database]++
+
+	key := profileKey{entry.Namespace, entry.Op}
+	if tracker.durations[key] == nil {
+		tracker.durations[key] = shared.NewHistogram(profileDurationSeconds)
+	}
+	tracker.durations[key].Observe(entry.Millis / 1000)
+
+	plan := PlanCategory(entry.PlanSummary)
+	tracker.plans[profilePlanKey{entry.Namespace, plan}]++
+	if plan == "COLLSCAN" {
+		tracker.collscans[entry.Namespace]++
+	}
+
+	keys, docs := entry.keysExamined(), entry.docsExamined()
+	tracker.examined[profileExaminedKey{entry.Namespace, entry.Op, "keys"}] += keys
+	tracker.examined[profileExaminedKey{entry.Namespace, entry.Op, "docs"}] += docs
+	tracker.examined[profileExaminedKey{entry.Namespace, entry.Op, "returned"}] += entry.Returned
+	if keys > 0 {
+		if tracker.docsPerKey[entry.Namespace] == nil {
+			tracker.docsPerKey[entry.Namespace] = shared.NewHistogram(profileDocsExaminedPerKey)
+		}
+		tracker.docsPerKey[entry.Namespace].Observe(docs / keys)
+	}
+}
+
+// Update reads the new entries of the system.profile collection of the databases, up to
+// profileReadLimit entries per database. The entries of the namespaces not matching the filter
+// are skipped. The first update of a database only remembers its newest entry.
+func (tracker *ProfileTracker) Update(session shared.Session, databases []string, filter *shared.NamespaceFilter) error {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	for _, database := range databases {
+		cursor := tracker.cursors[database]
+		if cursor == nil {
+			// system.profile is a capped collection without index on ts, read in insertion order
+			cursor = &shared.LogCursor{TimeField: "ts", Order: "$natural"}
+			tracker.cursors[database] = cursor
+		}
+		err := cursor.Read(session, database, "system.profile", profileReadLimit, func(raw bson.Raw) error {
+			entry := ProfileEntry{}
+			if err := raw.Unmarshal(&entry); err != nil {
+				return err
+			}
+			if filter.Match(entry.Namespace) {
+				tracker.Add(database, &entry)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export exports the accumulated profiler metrics to prometheus.
+func (tracker *ProfileTracker) Export(ch *shared.MetricChannel) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	for key, histogram := range tracker.durations {
+		ch.SendHistogram(profileDurationSeconds, histogram, key.ns, key.op)
+	}
+	for ns, count := range tracker.collscans {
+		ch.Send(profileCollscansTotal, count, ns)
+	}
+	for key, count := range tracker.plans {
+		ch.Send(profilePlansTotal, count, key.ns, key.plan)
+	}
+	for key, count := range tracker.examined {
+		ch.Send(profileExaminedTotal, count, key.ns, key.op, key.kind)
+	}
+	for ns, histogram := range tracker.docsPerKey {
+		ch.SendHistogram(profileDocsExaminedPerKey, histogram, ns)
+	}
+	for database, count := range tracker.entriesCount {
+		ch.Send(profileEntriesTotal, count, database)
+	}
+}
+
+// ProfiledDatabases returns the databases to read the profiler entries of: the given databases,
+// or every database but local and config when none is given.
+func ProfiledDatabases(session shared.Session, databases []string) ([]string, error) {
+	if len(databases) > 0 {
+		return databases, nil
+	}
+
+	names, err := session.DatabaseNames()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	databases = []string{}
+	for _, name := range names {
+		if name != "local" && name != "config" {
+			databases = append(databases, name)
+		}
+	}
+	return databases, nil
+}
@@ -0,0 +1,79 @@
+package collector_mongod
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func Test_PlanCategory(t *testing.T) {
+	for summary, expected := range map[string]string{
+		"COLLSCAN":                         "COLLSCAN",
+		"IXSCAN { a: 1 }":                  "IXSCAN",
+		"IXSCAN { a: 1 }, IXSCAN { b: 1 }": "IXSCAN",
+		"IDHACK":                           "IDHACK",
+		"":                                 "none",
+	} {
+		if actual := PlanCategory(summary); actual != expected {
+			t.Errorf("PlanCategory(%q) = %q, expected %q", summary, actual, expected)
+		}
+	}
+}
+
+func Test_ProfileTrackerExport(t *testing.T) {
+	keys, docs := float64(10), float64(50)
+	legacyDocs := float64(1000)
+
+	tracker := NewProfileTracker()
+	tracker.Add("app", &ProfileEntry{Op: "query", Namespace: "app.users", Millis: 120, PlanSummary: "IXSCAN { name: 1 }", KeysExamined: &keys, DocsExamined: &docs, Returned: 5})
+	tracker.Add("app", &ProfileEntry{Op: "query", Namespace: "app.users", Millis: 2500, PlanSummary: "COLLSCAN", LegacyDocsExamined: &legacyDocs, Returned: 1})
+
+	values, err := sessiontest.GatherExport(tracker.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]float64{
+		`mongodb_mongod_profile_duration_seconds{ns="app.users",op="query"}`:               2,
+		`mongodb_mongod_profile_collscans_total{ns="app.users"}`:                           1,
+		`mongodb_mongod_profile_plans_total{ns="app.users",plan="IXSCAN"}`:                 1,
+		`mongodb_mongod_profile_examined_total{ns="app.users",op="query",type="docs"}`:     1050,
+		`mongodb_mongod_profile_examined_total{ns="app.users",op="query",type="keys"}`:     10,
+		`mongodb_mongod_profile_examined_total{ns="app.users",op="query",type="returned"}`: 6,
+		`mongodb_mongod_profile_docs_examined_per_key_examined{ns="app.users"}`:            1,
+		`mongodb_mongod_profile_entries_total{database="app"}`:                             2,
+	} {
+		if values[name] != expected {
+			t.Errorf("expected %s to be %v, got %v", name, expected, values[name])
+		}
+	}
+}
+
+func Test_ProfileTrackerUpdate(t *testing.T) {
+	ts := time.Unix(1600000000, 0).UTC()
+	profileEntry := func(natural int, millis int) bson.M {
+		return bson.M{"$natural": natural, "op": "query", "ns": "app.users", "millis": millis, "ts": ts}
+	}
+	session := &sessiontest.Session{Collections: map[string][]bson.M{"app.system.profile": {profileEntry(1, 10)}}}
+	tracker := NewProfileTracker()
+	if err := tracker.Update(session, []string{"app"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the entries with the time of the newest entry read are counted, once
+	for natural := 2; natural <= 3; natural++ {
+		session.Collections["app.system.profile"] = append(session.Collections["app.system.profile"], profileEntry(natural, 20))
+		if err := tracker.Update(session, []string{"app"}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	values, err := sessiontest.GatherExport(tracker.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessiontest.ExpectValues(t, values, map[string]float64{
+		`mongodb_mongod_profile_entries_total{database="app"}`: 2,
+	})
+}
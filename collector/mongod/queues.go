@@ -0,0 +1,182 @@
+package collector_mongod
+
+import (
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	queuesExecutionOut = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "queues_execution",
+		Name:      "out_tickets",
+	}, []string{"type"})
+	queuesExecutionAvailable = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "queues_execution",
+		Name:      "available_tickets",
+	}, []string{"type"})
+	queuesExecutionTotalTickets = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "queues_execution",
+		Name:      "total_tickets",
+	}, []string{"type"})
+	queuesExecutionUtilization = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "queues_execution",
+		Name:      "utilization_ratio",
+	}, []string{"type"})
+)
+
+var (
+	queuesExecutionQueueLength = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "queues_execution",
+		Name:      "queue_length",
+	}, []string{"type", "priority"})
+	queuesExecutionProcessing = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "queues_execution",
+		Name:      "processing",
+	}, []string{"type", "priority"})
+	queuesExecutionAddedToQueueTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "queues_execution",
+		Name:      "added_to_queue_total",
+	}, []string{"type", "priority"})
+	queuesExecutionStartedProcessingTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "queues_execution",
+		Name:      "started_processing_total",
+	}, []string{"type", "priority"})
+	queuesExecutionCanceledTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "queues_execution",
+		Name:      "canceled_total",
+	}, []string{"type", "priority"})
+	queuesExecutionTimeQueuedMicrosecondsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "queues_execution",
+		Name:      "time_queued_microseconds_total",
+	}, []string{"type", "priority"})
+	queuesExecutionTimeProcessingMicrosecondsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "queues_execution",
+		Name:      "time_processing_microseconds_total",
+	}, []string{"type", "priority"})
+)
+
+var (
+	queuesExecutionConcurrencyAdjustmentsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "queues_execution",
+		Name:      "concurrency_adjustments_total",
+	}, []string{"direction"})
+	queuesExecutionConcurrencyAdjustedTicketsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "queues_execution",
+		Name:      "concurrency_adjusted_tickets_total",
+	}, []string{"direction"})
+)
+
+// ExecutionQueueStats are the admissions of a priority (exempt, normal or low) in an execution queue.
+type ExecutionQueueStats struct {
+	QueueLength               float64 `bson:"queueLength"`
+	Processing                float64 `bson:"processing"`
+	AddedToQueue              float64 `bson:"addedToQueue"`
+	StartedProcessing         float64 `bson:"startedProcessing"`
+	Canceled                  float64 `bson:"canceled"`
+	TotalTimeQueuedMicros     float64 `bson:"totalTimeQueuedMicros"`
+	TotalTimeProcessingMicros float64 `bson:"totalTimeProcessingMicros"`
+}
+
+// Export exports the queue stats of a ticket type and a priority.
+func (stats *ExecutionQueueStats) Export(ch *shared.MetricChannel, ticketType, priority string) {
+	ch.Send(queuesExecutionQueueLength, stats.QueueLength, ticketType, priority)
+	ch.Send(queuesExecutionProcessing, stats.Processing, ticketType, priority)
+	ch.Send(queuesExecutionAddedToQueueTotal, stats.AddedToQueue, ticketType, priority)
+	ch.Send(queuesExecutionStartedProcessingTotal, stats.StartedProcessing, ticketType, priority)
+	ch.Send(queuesExecutionCanceledTotal, stats.Canceled, ticketType, priority)
+	ch.Send(queuesExecutionTimeQueuedMicrosecondsTotal, stats.TotalTimeQueuedMicros, ticketType, priority)
+	ch.Send(queuesExecutionTimeProcessingMicrosecondsTotal, stats.TotalTimeProcessingMicros, ticketType, priority)
+}
+
+// ExecutionTicketStats are the read or write tickets of the storage engine, which replace the
+// wiredTiger.concurrentTransactions in 7.0.
+type ExecutionTicketStats struct {
+	WTConcurrentTransactionsTypeStats `bson:",inline"`
+
+	Exempt         *ExecutionQueueStats `bson:"exempt"`
+	NormalPriority *ExecutionQueueStats `bson:"normalPriority"`
+	LowPriority    *ExecutionQueueStats `bson:"lowPriority"`
+}
+
+// ConcurrencyAdjustmentStats are the adjustments of the number of tickets by the dynamic
+// (throughput probing) ticket algorithm.
+type ConcurrencyAdjustmentStats struct {
+	TimesIncreased       float64 `bson:"timesIncreased"`
+	TimesDecreased       float64 `bson:"timesDecreased"`
+	TotalAmountIncreased float64 `bson:"totalAmountIncreased"`
+	TotalAmountDecreased float64 `bson:"totalAmountDecreased"`
+}
+
+// Export exports the concurrency adjustments to prometheus.
+func (stats *ConcurrencyAdjustmentStats) Export(ch *shared.MetricChannel) {
+	ch.Send(queuesExecutionConcurrencyAdjustmentsTotal, stats.TimesIncreased, "increase")
+	ch.Send(queuesExecutionConcurrencyAdjustmentsTotal, stats.TimesDecreased, "decrease")
+	ch.Send(queuesExecutionConcurrencyAdjustedTicketsTotal, stats.TotalAmountIncreased, "increase")
+	ch.Send(queuesExecutionConcurrencyAdjustedTicketsTotal, stats.TotalAmountDecreased, "decrease")
+}
+
+// ExecutionQueuesStats are the execution tickets of the storage engine. The adjustments of the dynamic
+// ticket algorithm are reported as monitor in 7.0 and as throughputProbing since.
+type ExecutionQueuesStats struct {
+	Read              *ExecutionTicketStats       `bson:"read"`
+	Write             *ExecutionTicketStats       `bson:"write"`
+	Monitor           *ConcurrencyAdjustmentStats `bson:"monitor"`
+	ThroughputProbing *ConcurrencyAdjustmentStats `bson:"throughputProbing"`
+}
+
+// Export exports the execution tickets to prometheus.
+func (stats *ExecutionQueuesStats) Export(ch *shared.MetricChannel) {
+	for _, tickets := range []struct {
+		name  string
+		stats *ExecutionTicketStats
+	}{{"read", stats.Read}, {"write", stats.Write}} {
+		if tickets.stats == nil {
+			continue
+		}
+		ch.Send(queuesExecutionOut, tickets.stats.Out, tickets.name)
+		ch.Send(queuesExecutionAvailable, tickets.stats.Available, tickets.name)
+		ch.Send(queuesExecutionTotalTickets, tickets.stats.TotalTickets, tickets.name)
+		if ratio, ok := tickets.stats.Utilization(); ok {
+			ch.Send(queuesExecutionUtilization, ratio, tickets.name)
+		}
+		for _, queue := range []struct {
+			priority string
+			stats    *ExecutionQueueStats
+		}{{"exempt", tickets.stats.Exempt}, {"normal", tickets.stats.NormalPriority}, {"low", tickets.stats.LowPriority}} {
+			if queue.stats != nil {
+				queue.stats.Export(ch, tickets.name, queue.priority)
+			}
+		}
+	}
+
+	if stats.ThroughputProbing != nil {
+		stats.ThroughputProbing.Export(ch)
+	} else if stats.Monitor != nil {
+		stats.Monitor.Export(ch)
+	}
+}
+
+// QueuesStats are the admission queues of the server, since 7.0.
+type QueuesStats struct {
+	Execution *ExecutionQueuesStats `bson:"execution"`
+}
+
+// Export exports the queues to prometheus.
+func (stats *QueuesStats) Export(ch *shared.MetricChannel) {
+	if stats.Execution != nil {
+		stats.Execution.Export(ch)
+	}
+}
@@ -1,9 +1,9 @@
 package collector_mongod
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"time"
 
-	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 
 	"github.com/golang/glog"
@@ -12,102 +12,91 @@ import (
 
 var (
 	subsystem = "replset"
-	myName    = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	myName    = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "my_name",
-		Help:      "The replica state name of the current member",
 	}, []string{"set", "name"})
-	myState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	myState = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "my_state",
-		Help:      "An integer between 0 and 10 that represents the replica state of the current member",
 	}, []string{"set"})
-	date = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	date = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "date",
-		Help:      "The value of the date field is an ISODate of the current time, according to the current server.",
 	}, []string{"set"})
-	term = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	term = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "term",
-		Help:      "The election count for the replica set, as known to this replica set member",
 	}, []string{"set"})
-	numberOfMembers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	numberOfMembers = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "number_of_members",
-		Help:      "The number of replica set mebers",
 	}, []string{"set"})
-	heartbeatIntervalMillis = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	heartbeatIntervalMillis = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "heatbeat_interval_millis",
-		Help:      "The frequency in milliseconds of the heartbeats",
 	}, []string{"set"})
-	memberHealth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	memberHealth = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "member_health",
-		Help:      "This field conveys if the member is up (1) or down (0).",
 	}, []string{"set", "name", "state"})
-	memberState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	memberState = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "member_state",
-		Help:      "The value of state is an integer between 0 and 10 that represents the replica state of the member.",
 	}, []string{"set", "name", "state"})
-	memberUptime = prometheus.NewCounterVec(prometheus.CounterOpts{
+	memberUptime = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "member_uptime",
-		Help:      "The uptime field holds a value that reflects the number of seconds that this member has been online.",
 	}, []string{"set", "name", "state"})
-	memberOptimeDate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	memberOptimeDate = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "member_optime_date",
-		Help:      "The timestamp of the last oplog entry that this member applied.",
 	}, []string{"set", "name", "state"})
-	memberElectionDate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	memberElectionDate = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "member_election_date",
-		Help:      "The timestamp the node was elected as replica leader",
 	}, []string{"set", "name", "state"})
-	memberLastHeartbeat = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	memberLastHeartbeat = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "member_last_heartbeat",
-		Help:      "The lastHeartbeat value provides an ISODate formatted date and time of the transmission time of last heartbeat received from this member",
 	}, []string{"set", "name", "state"})
-	memberLastHeartbeatRecv = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	memberLastHeartbeatRecv = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "member_last_heartbeat_recv",
-		Help:      "The lastHeartbeatRecv value provides an ISODate formatted date and time that the last heartbeat was received from this member",
 	}, []string{"set", "name", "state"})
-	memberPingMs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	memberPingMs = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "member_ping_ms",
-		Help:      "The pingMs represents the number of milliseconds (ms) that a round-trip packet takes to travel between the remote member and the local instance.",
 	}, []string{"set", "name", "state"})
-	memberConfigVersion = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	memberConfigVersion = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
 		Name:      "member_config_version",
-		Help:      "The configVersion value is the replica set configuration version.",
 	}, []string{"set", "name", "state"})
-	memberOptime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	memberReplicationLag = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: subsystem,
-		Name:      "member_optime",
-		Help:      "Information regarding the last operation from the operation log that this member has applied.",
+		Name:      "member_replication_lag_seconds",
 	}, []string{"set", "name", "state"})
+	hasPrimary = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: subsystem,
+		Name:      "has_primary",
+	}, []string{"set"})
 )
 
 // ReplSetStatus keeps the data returned by the GetReplSetStatus method
@@ -120,6 +109,12 @@ type ReplSetStatus struct {
 	Members                 []Member  `bson:"members"`
 }
 
+const (
+	memberStatePrimary   = 1
+	memberStateSecondary = 2
+	memberStateArbiter   = 7
+)
+
 // Member represents an array element of ReplSetStatus.Members
 type Member struct {
 	Name                 string      `bson:"name"`
@@ -141,118 +136,98 @@ type Member struct {
 }
 
 // Export exports the replSetGetStatus stati to be consumed by prometheus
-func (replStatus *ReplSetStatus) Export(ch chan<- prometheus.Metric) {
-	myName.Reset()
-	myState.Reset()
-	term.Reset()
-	numberOfMembers.Reset()
-	heartbeatIntervalMillis.Reset()
-	memberState.Reset()
-	memberHealth.Reset()
-	memberUptime.Reset()
-	memberOptimeDate.Reset()
-	memberElectionDate.Reset()
-	memberLastHeartbeat.Reset()
-	memberLastHeartbeatRecv.Reset()
-	memberPingMs.Reset()
-	memberConfigVersion.Reset()
-
-	myState.WithLabelValues(replStatus.Set).Set(float64(replStatus.MyState))
-	date.WithLabelValues(replStatus.Set).Set(float64(replStatus.Date.Unix()))
+func (replStatus *ReplSetStatus) Export(ch *shared.MetricChannel) {
+	ch.Send(myState, float64(replStatus.MyState), replStatus.Set)
+	ch.Send(date, float64(replStatus.Date.Unix()), replStatus.Set)
 
 	// new in version 3.2
 	if replStatus.Term != nil {
-		term.WithLabelValues(replStatus.Set).Set(float64(*replStatus.Term))
+		ch.Send(term, float64(*replStatus.Term), replStatus.Set)
 	}
-	numberOfMembers.WithLabelValues(replStatus.Set).Set(float64(len(replStatus.Members)))
+	ch.Send(numberOfMembers, float64(len(replStatus.Members)), replStatus.Set)
 
 	// new in version 3.2
 	if replStatus.HeartbeatIntervalMillis != nil {
-		heartbeatIntervalMillis.WithLabelValues(replStatus.Set).Set(*replStatus.HeartbeatIntervalMillis)
+		ch.Send(heartbeatIntervalMillis, *replStatus.HeartbeatIntervalMillis, replStatus.Set)
+	}
+
+	lags, primaryFound := replStatus.ReplicationLag()
+	if primaryFound {
+		ch.Send(hasPrimary, 1, replStatus.Set)
+	} else {
+		ch.Send(hasPrimary, 0, replStatus.Set)
 	}
 
 	for _, member := range replStatus.Members {
 		if member.Self != nil {
-			labels := prometheus.Labels{
-				"set":  replStatus.Set,
-				"name": member.Name,
-			}
-			myName.With(labels).Set(1)
-		}
-		ls := prometheus.Labels{
-			"set":   replStatus.Set,
-			"name":  member.Name,
-			"state": member.StateStr,
+			ch.Send(myName, 1, replStatus.Set, member.Name)
 		}
+		ls := []string{replStatus.Set, member.Name, member.StateStr}
 
-		memberState.With(ls).Set(float64(member.State))
+		ch.Send(memberState, float64(member.State), ls...)
 
 		// ReplSetStatus.Member.Health is not available on the node you're connected to
 		if member.Health != nil {
-			memberHealth.With(ls).Set(float64(*member.Health))
+			ch.Send(memberHealth, float64(*member.Health), ls...)
 		}
 
-		memberUptime.With(ls).Add(member.Uptime)
+		ch.Send(memberUptime, member.Uptime, ls...)
 
-		memberOptimeDate.With(ls).Set(float64(member.OptimeDate.Unix()))
+		ch.Send(memberOptimeDate, float64(member.OptimeDate.Unix()), ls...)
 
 		// ReplSetGetStatus.Member.ElectionTime is only available on the PRIMARY
 		if member.ElectionDate != nil {
-			memberElectionDate.With(ls).Set(float64((*member.ElectionDate).Unix()))
+			ch.Send(memberElectionDate, float64((*member.ElectionDate).Unix()), ls...)
 		}
 		if member.LastHeartbeat != nil {
-			memberLastHeartbeat.With(ls).Set(float64((*member.LastHeartbeat).Unix()))
+			ch.Send(memberLastHeartbeat, float64((*member.LastHeartbeat).Unix()), ls...)
 		}
 		if member.LastHeartbeatRecv != nil {
-			memberLastHeartbeatRecv.With(ls).Set(float64((*member.LastHeartbeatRecv).Unix()))
+			ch.Send(memberLastHeartbeatRecv, float64((*member.LastHeartbeatRecv).Unix()), ls...)
 		}
 		if member.PingMs != nil {
-			memberPingMs.With(ls).Set(*member.PingMs)
+			ch.Send(memberPingMs, *member.PingMs, ls...)
 		}
 		if member.ConfigVersion != nil {
-			memberConfigVersion.With(ls).Set(float64(*member.ConfigVersion))
+			ch.Send(memberConfigVersion, float64(*member.ConfigVersion), ls...)
+		}
+		if lag, ok := lags[member.Name]; ok {
+			ch.Send(memberReplicationLag, lag, ls...)
 		}
 	}
-	// collect metrics
-	myName.Collect(ch)
-	myState.Collect(ch)
-	term.Collect(ch)
-	date.Collect(ch)
-	numberOfMembers.Collect(ch)
-	heartbeatIntervalMillis.Collect(ch)
-	memberState.Collect(ch)
-	memberHealth.Collect(ch)
-	memberUptime.Collect(ch)
-	memberOptimeDate.Collect(ch)
-	memberElectionDate.Collect(ch)
-	memberLastHeartbeat.Collect(ch)
-	memberLastHeartbeatRecv.Collect(ch)
-	memberPingMs.Collect(ch)
-	memberConfigVersion.Collect(ch)
 }
 
-// Describe describes the replSetGetStatus metrics for prometheus
-func (replStatus *ReplSetStatus) Describe(ch chan<- *prometheus.Desc) {
-	myName.Describe(ch)
-	myState.Describe(ch)
-	term.Describe(ch)
-	date.Describe(ch)
-	numberOfMembers.Describe(ch)
-	heartbeatIntervalMillis.Describe(ch)
-	memberState.Describe(ch)
-	memberHealth.Describe(ch)
-	memberUptime.Describe(ch)
-	memberOptimeDate.Describe(ch)
-	memberElectionDate.Describe(ch)
-	memberLastHeartbeatRecv.Describe(ch)
-	memberPingMs.Describe(ch)
-	memberConfigVersion.Describe(ch)
+// ReplicationLag returns the replication lag in seconds of every secondary by member name, relative
+// to the optime of the primary, or of the most advanced member when there is no primary. Arbiters are
+// skipped. The returned boolean reports if a primary was found.
+func (replStatus *ReplSetStatus) ReplicationLag() (map[string]float64, bool) {
+	var reference time.Time
+	primaryFound := false
+	for _, member := range replStatus.Members {
+		if member.State == memberStatePrimary {
+			reference = member.OptimeDate
+			primaryFound = true
+			break
+		}
+		if member.State != memberStateArbiter && member.OptimeDate.After(reference) {
+			reference = member.OptimeDate
+		}
+	}
+
+	lags := make(map[string]float64)
+	for _, member := range replStatus.Members {
+		if member.State != memberStateSecondary {
+			continue
+		}
+		lags[member.Name] = reference.Sub(member.OptimeDate).Seconds()
+	}
+	return lags, primaryFound
 }
 
 // GetReplSetStatus returns the replica status info
-func GetReplSetStatus(session *mgo.Session) *ReplSetStatus {
+func GetReplSetStatus(session shared.Session) *ReplSetStatus {
 	result := &ReplSetStatus{}
-	err := session.DB("admin").Run(bson.D{{"replSetGetStatus", 1}}, result)
+	err := session.Run("admin", bson.D{{"replSetGetStatus", 1}}, result)
 	if err != nil {
 		glog.Error("Failed to get replSet status.")
 		return nil
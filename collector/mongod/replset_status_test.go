@@ -0,0 +1,59 @@
+package collector_mongod
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_ReplicationLag(t *testing.T) {
+	now := time.Now()
+	status := &ReplSetStatus{
+		Set: "rs0",
+		Members: []Member{
+			{Name: "a:27017", State: memberStateSecondary, OptimeDate: now.Add(-5 * time.Second)},
+			{Name: "b:27017", State: memberStatePrimary, OptimeDate: now},
+			{Name: "c:27017", State: memberStateArbiter},
+			{Name: "d:27017", State: memberStateSecondary, OptimeDate: now.Add(-30 * time.Second)},
+		},
+	}
+
+	lags, primaryFound := status.ReplicationLag()
+	if !primaryFound {
+		t.Error("primary was not found")
+	}
+	if len(lags) != 2 {
+		t.Errorf("expected the lag of 2 secondaries, got %d", len(lags))
+	}
+	if lags["a:27017"] != 5 {
+		t.Errorf("expected a lag of 5s for a:27017, got %v", lags["a:27017"])
+	}
+	if lags["d:27017"] != 30 {
+		t.Errorf("expected a lag of 30s for d:27017, got %v", lags["d:27017"])
+	}
+}
+
+func Test_ReplicationLagWithoutPrimary(t *testing.T) {
+	now := time.Now()
+	status := &ReplSetStatus{
+		Set: "rs0",
+		Members: []Member{
+			{Name: "a:27017", State: memberStateSecondary, OptimeDate: now.Add(-5 * time.Second)},
+			{Name: "b:27017", State: memberStateSecondary, OptimeDate: now.Add(-2 * time.Second)},
+			{Name: "c:27017", State: memberStateArbiter},
+		},
+	}
+
+	lags, primaryFound := status.ReplicationLag()
+	if primaryFound {
+		t.Error("no primary should be found")
+	}
+	if lags["a:27017"] != 3 {
+		t.Errorf("expected a lag of 3s for a:27017, got %v", lags["a:27017"])
+	}
+	if lags["b:27017"] != 0 {
+		t.Errorf("expected no lag for the most advanced member, got %v", lags["b:27017"])
+	}
+	if _, ok := lags["c:27017"]; ok {
+		t.Error("arbiters should be skipped")
+	}
+}
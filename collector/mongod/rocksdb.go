@@ -1,8 +1,10 @@
 package collector_mongod
 
-import(
-	"strings"
+import (
 	"strconv"
+	"strings"
+
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -14,324 +16,283 @@ var (
 	terabyte float64 = gigabyte * 1024
 	petabyte float64 = terabyte * 1024
 	thousand float64 = 1000
-	million	 float64 = thousand * 1000
+	million  float64 = thousand * 1000
 	billion  float64 = million * 1000
 	trillion float64 = billion * 1000
 
-	rocksDbStalledSecs = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"stalled_seconds_total",
-		Help:		"The total number of seconds RocksDB has spent stalled",
-	})
-	rocksDbStalls = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"stalls_total",
-		Help:		"The total number of stalls in RocksDB",
+	rocksDbStalledSecs = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "stalled_seconds_total",
+	}, nil)
+	rocksDbStalls = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "stalls_total",
 	}, []string{"type"})
-	rocksDbCompactionBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"compaction_bytes_total",
-		Help:		"Total bytes processed during compaction between levels N and N+1 in RocksDB",
+	rocksDbCompactionBytes = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "compaction_bytes_total",
 	}, []string{"level", "type"})
-	rocksDbCompactionSecondsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"compaction_seconds_total",
-		Help:		"The time spent doing compactions between levels N and N+1 in RocksDB",
+	rocksDbCompactionSecondsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "compaction_seconds_total",
 	}, []string{"level"})
-	rocksDbCompactionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"compactions_total",
-		Help:		"The total number of compactions between levels N and N+1 in RocksDB",
+	rocksDbCompactionsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "compactions_total",
 	}, []string{"level"})
-	rocksDbBlockCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"block_cache_hits_total",
-		Help:		"The total number of hits to the RocksDB Block Cache",
-	})
-	rocksDbBlockCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"block_cache_misses_total",
-		Help:		"The total number of misses to the RocksDB Block Cache",
-	})
-	rocksDbKeys = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"keys_total",
-		Help:		"The total number of RocksDB key operations",
+	rocksDbBlockCacheHits = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "block_cache_hits_total",
+	}, nil)
+	rocksDbBlockCacheMisses = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "block_cache_misses_total",
+	}, nil)
+	rocksDbKeys = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "keys_total",
 	}, []string{"type"})
-	rocksDbSeeks = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"seeks_total",
-		Help:		"The total number of seeks performed by RocksDB",
-	})
-	rocksDbIterations = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"iterations_total",
-		Help:		"The total number of iterations performed by RocksDB",
+	rocksDbSeeks = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "seeks_total",
+	}, nil)
+	rocksDbIterations = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "iterations_total",
 	}, []string{"type"})
-	rocksDbBloomFilterUseful = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"bloom_filter_useful_total",
-		Help:		"The total number of times the RocksDB Bloom Filter was useful",
-	})
-	rocksDbBytesWritten = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"bytes_written_total",
-		Help:		"The total number of bytes written by RocksDB",
+	rocksDbBloomFilterUseful = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "bloom_filter_useful_total",
+	}, nil)
+	rocksDbBytesWritten = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "bytes_written_total",
 	}, []string{"type"})
-	rocksDbBytesRead = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"bytes_read_total",
-		Help:		"The total number of bytes read by RocksDB",
+	rocksDbBytesRead = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "bytes_read_total",
 	}, []string{"type"})
-	rocksDbReadOps = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"reads_total",
-		Help:		"The total number of read operations in RocksDB",
+	rocksDbReadOps = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "reads_total",
 	}, []string{"level"})
 )
 
 var (
-	rocksDbNumImmutableMemTable = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"immutable_memtables",
-		Help:		"The total number of immutable MemTables in RocksDB",
-	})
-	rocksDbMemTableFlushPending = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"pending_memtable_flushes",
-		Help:		"The total number of MemTable flushes pending in RocksDB",
-	}) 
-	rocksDbCompactionPending = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"pending_compactions",
-		Help:		"The total number of compactions pending in RocksDB",
-	}) 
-	rocksDbBackgroundErrors = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"background_errors",
-		Help:		"The total number of background errors in RocksDB",
-	}) 
-	rocksDbMemTableBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"memtable_bytes",
-		Help:		"The current number of MemTable bytes in RocksDB",
-	}, []string{"type"}) 
-	rocksDbMemtableEntries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"memtable_entries",
-		Help:		"The current number of Memtable entries in RocksDB",
-	}, []string{"type"}) 
-	rocksDbEstimateTableReadersMem = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"estimate_table_readers_memory_bytes",
-		Help:		"The estimate RocksDB table-reader memory bytes",
-	}) 
-	rocksDbNumSnapshots = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"snapshots",
-		Help:		"The current number of snapshots in RocksDB",
-	}) 
-	rocksDbOldestSnapshotTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"oldest_snapshot_timestamp",
-		Help:		"The timestamp of the oldest snapshot in RocksDB",
-	}) 
-	rocksDbNumLiveVersions = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"live_versions",
-		Help:		"The current number of live versions in RocksDB",
-	}) 
-	rocksDbTotalLiveRecoveryUnits = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"total_live_recovery_units",
-		Help:		"The total number of live recovery units in RocksDB",
-	}) 
-	rocksDbBlockCacheUsage = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"block_cache_bytes",
-		Help:		"The current bytes used in the RocksDB Block Cache",
-	}) 
-	rocksDbTransactionEngineKeys = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"transaction_engine_keys",
-		Help:		"The current number of transaction engine keys in RocksDB",
-	}) 
-	rocksDbTransactionEngineSnapshots = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"transaction_engine_snapshots",
-		Help:		"The current number of transaction engine snapshots in RocksDB",
-	}) 
-	rocksDbWritesPerBatch = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"writes_per_batch",
-		Help:		"The number of writes per batch in RocksDB",
-	}) 
-	rocksDbWritesPerSec = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"writes_per_second",
-		Help:		"The number of writes per second in RocksDB",
-	}) 
-	rocksDbStallPercent = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"stall_percent",
-		Help:		"The percentage of time RocksDB has been stalled",
-	}) 
-	rocksDbWALWritesPerSync = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"write_ahead_log_writes_per_sync",
-		Help:		"The number of writes per Write-Ahead-Log sync in RocksDB",
-	}) 
-	rocksDbWALBytesPerSecs = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"write_ahead_log_bytes_per_second",
-		Help:		"The number of bytes written per second by the Write-Ahead-Log in RocksDB",
-	}) 
-	rocksDbLevelFiles = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"files",
-		Help:		"The number of files in a RocksDB level",
+	rocksDbNumImmutableMemTable = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "immutable_memtables",
+	}, nil)
+	rocksDbMemTableFlushPending = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "pending_memtable_flushes",
+	}, nil)
+	rocksDbCompactionPending = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "pending_compactions",
+	}, nil)
+	rocksDbBackgroundErrors = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "background_errors",
+	}, nil)
+	rocksDbMemTableBytes = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "memtable_bytes",
+	}, []string{"type"})
+	rocksDbMemtableEntries = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "memtable_entries",
+	}, []string{"type"})
+	rocksDbEstimateTableReadersMem = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "estimate_table_readers_memory_bytes",
+	}, nil)
+	rocksDbNumSnapshots = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "snapshots",
+	}, nil)
+	rocksDbOldestSnapshotTimestamp = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "oldest_snapshot_timestamp",
+	}, nil)
+	rocksDbNumLiveVersions = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "live_versions",
+	}, nil)
+	rocksDbTotalLiveRecoveryUnits = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "total_live_recovery_units",
+	}, nil)
+	rocksDbBlockCacheUsage = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "block_cache_bytes",
+	}, nil)
+	rocksDbTransactionEngineKeys = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "transaction_engine_keys",
+	}, nil)
+	rocksDbTransactionEngineSnapshots = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "transaction_engine_snapshots",
+	}, nil)
+	rocksDbWritesPerBatch = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "writes_per_batch",
+	}, nil)
+	rocksDbWritesPerSec = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "writes_per_second",
+	}, nil)
+	rocksDbStallPercent = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "stall_percent",
+	}, nil)
+	rocksDbWALWritesPerSync = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "write_ahead_log_writes_per_sync",
+	}, nil)
+	rocksDbWALBytesPerSecs = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "write_ahead_log_bytes_per_second",
+	}, nil)
+	rocksDbLevelFiles = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "files",
 	}, []string{"level"})
-	rocksDbCompactionThreads = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"compaction_file_threads",
-		Help:		"The number of threads currently doing compaction for levels in RocksDB",
+	rocksDbCompactionThreads = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "compaction_file_threads",
 	}, []string{"level"})
-	rocksDbLevelScore = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"compaction_score",
-		Help:		"The compaction score of RocksDB levels",
+	rocksDbLevelScore = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "compaction_score",
 	}, []string{"level"})
-	rocksDbLevelSizeBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"size_bytes",
-		Help:		"The total byte size of levels in RocksDB",
+	rocksDbLevelSizeBytes = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "size_bytes",
 	}, []string{"level"})
-	rocksDbCompactionBytesPerSec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"compaction_bytes_per_second",
-		Help:		"The rate at which data is processed during compaction between levels N and N+1 in RocksDB",
+	rocksDbCompactionBytesPerSec = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "compaction_bytes_per_second",
 	}, []string{"level", "type"})
-	rocksDbCompactionWriteAmplification = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"compaction_write_amplification",
-		Help:		"The write amplification factor from compaction between levels N and N+1 in RocksDB",
+	rocksDbCompactionWriteAmplification = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "compaction_write_amplification",
 	}, []string{"level"})
-	rocksDbCompactionAvgSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"compaction_average_seconds",
-		Help:		"The average time per compaction between levels N and N+1 in RocksDB",
+	rocksDbCompactionAvgSeconds = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "compaction_average_seconds",
 	}, []string{"level"})
-	rocksDbReadLatencyMicros = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace:	Namespace,
-		Subsystem:	"rocksdb",
-		Name:		"read_latency_microseconds",
-		Help:		"The read latency in RocksDB in microseconds by level",
+	rocksDbReadLatencyMicros = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "rocksdb",
+		Name:      "read_latency_microseconds",
 	}, []string{"level", "type"})
 )
 
 type RocksDbStatsCounters struct {
-	NumKeysWritten		float64	`bson:"num-keys-written"`
-	NumKeysRead		float64	`bson:"num-keys-read"`
-	NumSeeks		float64	`bson:"num-seeks"`
-	NumForwardIter		float64	`bson:"num-forward-iterations"`	
-	NumBackwardIter		float64	`bson:"num-backward-iterations"`	
-	BlockCacheMisses	float64	`bson:"block-cache-misses"`
-	BlockCacheHits		float64	`bson:"block-cache-hits"`
-	BloomFilterUseful	float64 `bson:"bloom-filter-useful"`
-	BytesWritten		float64 `bson:"bytes-written"`
-	BytesReadPointLookup	float64	`bson:"bytes-read-point-lookup"`
-	BytesReadIteration	float64 `bson:"bytes-read-iteration"`
-	FlushBytesWritten	float64 `bson:"flush-bytes-written"`
-	CompactionBytesRead	float64	`bson:"compaction-bytes-read"`
-	CompactionBytesWritten	float64	`bson:"compaction-bytes-written"`
+	NumKeysWritten         float64 `bson:"num-keys-written"`
+	NumKeysRead            float64 `bson:"num-keys-read"`
+	NumSeeks               float64 `bson:"num-seeks"`
+	NumForwardIter         float64 `bson:"num-forward-iterations"`
+	NumBackwardIter        float64 `bson:"num-backward-iterations"`
+	BlockCacheMisses       float64 `bson:"block-cache-misses"`
+	BlockCacheHits         float64 `bson:"block-cache-hits"`
+	BloomFilterUseful      float64 `bson:"bloom-filter-useful"`
+	BytesWritten           float64 `bson:"bytes-written"`
+	BytesReadPointLookup   float64 `bson:"bytes-read-point-lookup"`
+	BytesReadIteration     float64 `bson:"bytes-read-iteration"`
+	FlushBytesWritten      float64 `bson:"flush-bytes-written"`
+	CompactionBytesRead    float64 `bson:"compaction-bytes-read"`
+	CompactionBytesWritten float64 `bson:"compaction-bytes-written"`
 }
 
 type RocksDbStats struct {
-	NumImmutableMemTable		string			`bson:"num-immutable-mem-table"`
-	MemTableFlushPending		string			`bson:"mem-table-flush-pending"`
-	CompactionPending		string			`bson:"compaction-pending"`
-	BackgroundErrors		string			`bson:"background-errors"`
-	CurSizeMemTableActive		string			`bson:"cur-size-active-mem-table"`
-	CurSizeAllMemTables		string			`bson:"cur-size-all-mem-tables"`
-	NumEntriesMemTableActive	string			`bson:"num-entries-active-mem-table"`
-	NumEntriesImmMemTables		string			`bson:"num-entries-imm-mem-tables"`
-	EstimateTableReadersMem		string			`bson:"estimate-table-readers-mem"`
-	NumSnapshots			string			`bson:"num-snapshots"`
-	OldestSnapshotTime		string			`bson:"oldest-snapshot-time"`
-	NumLiveVersions			string			`bson:"num-live-versions"`
-	BlockCacheUsage			string			`bson:"block-cache-usage"`
-	TotalLiveRecoveryUnits		float64			`bson:"total-live-recovery-units"`
-	TransactionEngineKeys		float64			`bson:"transaction-engine-keys"`
-	TransactionEngineSnapshots	float64			`bson:"transaction-engine-snapshots"`
-	Stats				[]string		`bson:"stats"`
-	ThreadStatus			[]string		`bson:"thread-status"`
-	Counters			*RocksDbStatsCounters	`bson:"counters,omitempty"`
+	NumImmutableMemTable       string                `bson:"num-immutable-mem-table"`
+	MemTableFlushPending       string                `bson:"mem-table-flush-pending"`
+	CompactionPending          string                `bson:"compaction-pending"`
+	BackgroundErrors           string                `bson:"background-errors"`
+	CurSizeMemTableActive      string                `bson:"cur-size-active-mem-table"`
+	CurSizeAllMemTables        string                `bson:"cur-size-all-mem-tables"`
+	NumEntriesMemTableActive   string                `bson:"num-entries-active-mem-table"`
+	NumEntriesImmMemTables     string                `bson:"num-entries-imm-mem-tables"`
+	EstimateTableReadersMem    string                `bson:"estimate-table-readers-mem"`
+	NumSnapshots               string                `bson:"num-snapshots"`
+	OldestSnapshotTime         string                `bson:"oldest-snapshot-time"`
+	NumLiveVersions            string                `bson:"num-live-versions"`
+	BlockCacheUsage            string                `bson:"block-cache-usage"`
+	TotalLiveRecoveryUnits     float64               `bson:"total-live-recovery-units"`
+	TransactionEngineKeys      float64               `bson:"transaction-engine-keys"`
+	TransactionEngineSnapshots float64               `bson:"transaction-engine-snapshots"`
+	Stats                      []string              `bson:"stats"`
+	ThreadStatus               []string              `bson:"thread-status"`
+	Counters                   *RocksDbStatsCounters `bson:"counters,omitempty"`
 }
 
 type RocksDbLevelStatsFiles struct {
-	Num		float64
-	CompThreads	float64
+	Num         float64
+	CompThreads float64
 }
 
 type RocksDbLevelStats struct {
-	Level		string
-	Files		*RocksDbLevelStatsFiles
-	Score		float64
-	SizeMB		float64
-	ReadGB		float64
-	RnGB		float64
-	Rnp1GB		float64
-	WriteGB		float64
-	WnewGB		float64
-	MovedGB		float64
-	WAmp		float64
-	RdMBPSec	float64
-	WrMBPSec	float64
-	CompSec		float64
-	CompCnt		float64
-	AvgSec		float64
-	KeyIn		float64
-	KeyDrop		float64
+	Level    string
+	Files    *RocksDbLevelStatsFiles
+	Score    float64
+	SizeMB   float64
+	ReadGB   float64
+	RnGB     float64
+	Rnp1GB   float64
+	WriteGB  float64
+	WnewGB   float64
+	MovedGB  float64
+	WAmp     float64
+	RdMBPSec float64
+	WrMBPSec float64
+	CompSec  float64
+	CompCnt  float64
+	AvgSec   float64
+	KeyIn    float64
+	KeyDrop  float64
 }
 
 // rocksdb time-format string parser: returns float64 of seconds:
@@ -352,7 +313,7 @@ func ParseStr(str string) float64 {
 	var multiply float64 = 1
 	var str_remove string = ""
 	if strings.Contains(str, " KB") || strings.HasSuffix(str, "KB") {
-		multiply = kilobyte 
+		multiply = kilobyte
 		str_remove = "KB"
 	} else if strings.Contains(str, " MB") || strings.HasSuffix(str, "MB") {
 		multiply = megabyte
@@ -430,7 +391,7 @@ func ProcessLevelStatsLineFiles(str string) *RocksDbLevelStatsFiles {
 		return &RocksDbLevelStatsFiles{}
 	}
 	return &RocksDbLevelStatsFiles{
-		Num: numFiles,
+		Num:         numFiles,
 		CompThreads: compThreads,
 	}
 }
@@ -440,24 +401,24 @@ func ProcessLevelStatsLine(line string) *RocksDbLevelStats {
 	if strings.HasPrefix(line, " ") {
 		fields := SplitByWs(line)
 		stats = &RocksDbLevelStats{
-			Level: fields[0],
-			Files: ProcessLevelStatsLineFiles(fields[1]),
-			SizeMB: ParseStr(fields[2]),
-			Score: ParseStr(fields[3]),
-			ReadGB: ParseStr(fields[4]),
-			RnGB: ParseStr(fields[5]),
-			Rnp1GB: ParseStr(fields[6]),
-			WriteGB: ParseStr(fields[7]),
-			WnewGB: ParseStr(fields[8]),
-			MovedGB: ParseStr(fields[9]),
-			WAmp: ParseStr(fields[10]),
+			Level:    fields[0],
+			Files:    ProcessLevelStatsLineFiles(fields[1]),
+			SizeMB:   ParseStr(fields[2]),
+			Score:    ParseStr(fields[3]),
+			ReadGB:   ParseStr(fields[4]),
+			RnGB:     ParseStr(fields[5]),
+			Rnp1GB:   ParseStr(fields[6]),
+			WriteGB:  ParseStr(fields[7]),
+			WnewGB:   ParseStr(fields[8]),
+			MovedGB:  ParseStr(fields[9]),
+			WAmp:     ParseStr(fields[10]),
 			RdMBPSec: ParseStr(fields[11]),
 			WrMBPSec: ParseStr(fields[12]),
-			CompSec: ParseStr(fields[13]),
-			CompCnt: ParseStr(fields[14]),
-			AvgSec: ParseStr(fields[15]),
-			KeyIn: ParseStr(fields[16]),
-			KeyDrop: ParseStr(fields[17]),
+			CompSec:  ParseStr(fields[13]),
+			CompCnt:  ParseStr(fields[14]),
+			AvgSec:   ParseStr(fields[15]),
+			KeyIn:    ParseStr(fields[16]),
+			KeyDrop:  ParseStr(fields[17]),
 		}
 	}
 	return stats
@@ -504,7 +465,7 @@ func (stats *RocksDbStats) GetStatsLineField(section_prefix string, line_prefix
 	return field
 }
 
-func (stats *RocksDbStats) ProcessLevelStats() {
+func (stats *RocksDbStats) ProcessLevelStats(ch *shared.MetricChannel) {
 	var levels []*RocksDbLevelStats
 	var is_section bool
 	for _, line := range stats.Stats {
@@ -524,209 +485,114 @@ func (stats *RocksDbStats) ProcessLevelStats() {
 			levelName = "total"
 		}
 		if levelName != "L0" {
-			rocksDbCompactionBytes.With(prometheus.Labels{"level": levelName, "type": "read"}).Add(level.ReadGB * gigabyte)
-			rocksDbCompactionBytes.With(prometheus.Labels{"level": levelName, "type": "read_n"}).Add(level.RnGB * gigabyte)
-			rocksDbCompactionBytes.With(prometheus.Labels{"level": levelName, "type": "read_np1"}).Add(level.Rnp1GB * gigabyte)
-			rocksDbCompactionBytes.With(prometheus.Labels{"level": levelName, "type": "moved"}).Add(level.MovedGB * gigabyte)
-			rocksDbCompactionBytesPerSec.With(prometheus.Labels{"level": levelName, "type": "read"}).Set(level.RdMBPSec * megabyte)
-			rocksDbCompactionWriteAmplification.WithLabelValues(levelName).Set(level.WAmp)
+			ch.Send(rocksDbCompactionBytes, level.ReadGB*gigabyte, levelName, "read")
+			ch.Send(rocksDbCompactionBytes, level.RnGB*gigabyte, levelName, "read_n")
+			ch.Send(rocksDbCompactionBytes, level.Rnp1GB*gigabyte, levelName, "read_np1")
+			ch.Send(rocksDbCompactionBytes, level.MovedGB*gigabyte, levelName, "moved")
+			ch.Send(rocksDbCompactionBytesPerSec, level.RdMBPSec*megabyte, levelName, "read")
+			ch.Send(rocksDbCompactionWriteAmplification, level.WAmp, levelName)
 		}
-		rocksDbLevelScore.WithLabelValues(levelName).Set(level.Score)
-		rocksDbLevelFiles.WithLabelValues(levelName).Set(level.Files.Num)
-		rocksDbCompactionThreads.WithLabelValues(levelName).Set(level.Files.CompThreads)
-		rocksDbLevelSizeBytes.WithLabelValues(levelName).Set(level.SizeMB * megabyte)
-		rocksDbCompactionSecondsTotal.WithLabelValues(levelName).Add(level.CompSec)
-		rocksDbCompactionAvgSeconds.WithLabelValues(levelName).Set(level.AvgSec)
-		rocksDbCompactionBytes.With(prometheus.Labels{"level": levelName, "type": "write"}).Add(level.WriteGB * gigabyte)
-		rocksDbCompactionBytes.With(prometheus.Labels{"level": levelName, "type": "write_new_np1"}).Add(level.WriteGB * gigabyte)
-		rocksDbCompactionBytesPerSec.With(prometheus.Labels{"level": levelName, "type": "write"}).Set(level.WrMBPSec * megabyte)
-		rocksDbCompactionsTotal.WithLabelValues(levelName).Add(level.CompCnt)
+		ch.Send(rocksDbLevelScore, level.Score, levelName)
+		ch.Send(rocksDbLevelFiles, level.Files.Num, levelName)
+		ch.Send(rocksDbCompactionThreads, level.Files.CompThreads, levelName)
+		ch.Send(rocksDbLevelSizeBytes, level.SizeMB*megabyte, levelName)
+		ch.Send(rocksDbCompactionSecondsTotal, level.CompSec, levelName)
+		ch.Send(rocksDbCompactionAvgSeconds, level.AvgSec, levelName)
+		ch.Send(rocksDbCompactionBytes, level.WriteGB*gigabyte, levelName, "write")
+		ch.Send(rocksDbCompactionBytes, level.WriteGB*gigabyte, levelName, "write_new_np1")
+		ch.Send(rocksDbCompactionBytesPerSec, level.WrMBPSec*megabyte, levelName, "write")
+		ch.Send(rocksDbCompactionsTotal, level.CompCnt, levelName)
 	}
 }
 
-func (stats *RocksDbStats) ProcessStalls() {
+func (stats *RocksDbStats) ProcessStalls(ch *shared.MetricChannel) {
 	for _, stall_line := range stats.GetStatsLine("** Compaction Stats [default] **", "Stalls(count): ") {
 		stall_split := strings.Split(stall_line, " ")
 		if len(stall_split) == 2 {
 			stall_type := stall_split[1]
 			stall_count := stall_split[0]
-			rocksDbStalls.WithLabelValues(stall_type).Add(ParseStr(stall_count))
+			ch.Send(rocksDbStalls, ParseStr(stall_count), stall_type)
 		}
 	}
 }
 
-func (stats *RocksDbStats) ProcessReadLatencyStats() {
+func (stats *RocksDbStats) ProcessReadLatencyStats(ch *shared.MetricChannel) {
 	for _, level_num := range []string{"0", "1", "2", "3", "4", "5", "6"} {
-		level := "L"+level_num
-		section := "** Level "+level_num+" read latency histogram (micros):"
+		level := "L" + level_num
+		section := "** Level " + level_num + " read latency histogram (micros):"
 		if len(stats.GetStatsSection(section)) > 0 {
-			rocksDbReadOps.With(prometheus.Labels{"level": level}).Add(stats.GetStatsLineField(section, "Count: ", 0))
-			rocksDbReadLatencyMicros.With(prometheus.Labels{"level": level, "type": "avg"}).Set(stats.GetStatsLineField(section, "Count: ", 2))
-			rocksDbReadLatencyMicros.With(prometheus.Labels{"level": level, "type": "stddev"}).Set(stats.GetStatsLineField(section, "Count: ", 4))
-			rocksDbReadLatencyMicros.With(prometheus.Labels{"level": level, "type": "min"}).Set(stats.GetStatsLineField(section, "Min: ", 0))
-			rocksDbReadLatencyMicros.With(prometheus.Labels{"level": level, "type": "median"}).Set(stats.GetStatsLineField(section, "Min: ", 2))
-			rocksDbReadLatencyMicros.With(prometheus.Labels{"level": level, "type": "max"}).Set(stats.GetStatsLineField(section, "Min: ", 4))
-			rocksDbReadLatencyMicros.With(prometheus.Labels{"level": level, "type": "P50"}).Set(stats.GetStatsLineField(section, "Percentiles: ", 1))
-			rocksDbReadLatencyMicros.With(prometheus.Labels{"level": level, "type": "P75"}).Set(stats.GetStatsLineField(section, "Percentiles: ", 3))
-			rocksDbReadLatencyMicros.With(prometheus.Labels{"level": level, "type": "P99"}).Set(stats.GetStatsLineField(section, "Percentiles: ", 5))
-			rocksDbReadLatencyMicros.With(prometheus.Labels{"level": level, "type": "P99.9"}).Set(stats.GetStatsLineField(section, "Percentiles: ", 7))
-			rocksDbReadLatencyMicros.With(prometheus.Labels{"level": level, "type": "P99.99"}).Set(stats.GetStatsLineField(section, "Percentiles: ", 9))
+			ch.Send(rocksDbReadOps, stats.GetStatsLineField(section, "Count: ", 0), level)
+			ch.Send(rocksDbReadLatencyMicros, stats.GetStatsLineField(section, "Count: ", 2), level, "avg")
+			ch.Send(rocksDbReadLatencyMicros, stats.GetStatsLineField(section, "Count: ", 4), level, "stddev")
+			ch.Send(rocksDbReadLatencyMicros, stats.GetStatsLineField(section, "Min: ", 0), level, "min")
+			ch.Send(rocksDbReadLatencyMicros, stats.GetStatsLineField(section, "Min: ", 2), level, "median")
+			ch.Send(rocksDbReadLatencyMicros, stats.GetStatsLineField(section, "Min: ", 4), level, "max")
+			ch.Send(rocksDbReadLatencyMicros, stats.GetStatsLineField(section, "Percentiles: ", 1), level, "P50")
+			ch.Send(rocksDbReadLatencyMicros, stats.GetStatsLineField(section, "Percentiles: ", 3), level, "P75")
+			ch.Send(rocksDbReadLatencyMicros, stats.GetStatsLineField(section, "Percentiles: ", 5), level, "P99")
+			ch.Send(rocksDbReadLatencyMicros, stats.GetStatsLineField(section, "Percentiles: ", 7), level, "P99.9")
+			ch.Send(rocksDbReadLatencyMicros, stats.GetStatsLineField(section, "Percentiles: ", 9), level, "P99.99")
 		}
 	}
 }
 
-func (stats *RocksDbStatsCounters) Describe(ch chan<- *prometheus.Desc) {
-	rocksDbBlockCacheHits.Describe(ch)
-	rocksDbBlockCacheMisses.Describe(ch)
-	rocksDbKeys.Describe(ch)
-	rocksDbSeeks.Describe(ch)
-	rocksDbIterations.Describe(ch)
-	rocksDbBloomFilterUseful.Describe(ch)
-	rocksDbBytesWritten.Describe(ch)
-	rocksDbBytesRead.Describe(ch)
+func (stats *RocksDbStatsCounters) Export(ch *shared.MetricChannel) {
+	ch.Send(rocksDbBlockCacheHits, stats.BlockCacheHits)
+	ch.Send(rocksDbBlockCacheMisses, stats.BlockCacheMisses)
+	ch.Send(rocksDbKeys, stats.NumKeysWritten, "written")
+	ch.Send(rocksDbKeys, stats.NumKeysRead, "read")
+	ch.Send(rocksDbSeeks, stats.NumSeeks)
+	ch.Send(rocksDbIterations, stats.NumForwardIter, "forward")
+	ch.Send(rocksDbIterations, stats.NumBackwardIter, "backward")
+	ch.Send(rocksDbBloomFilterUseful, stats.BloomFilterUseful)
+	ch.Send(rocksDbBytesWritten, stats.BytesWritten, "total")
+	ch.Send(rocksDbBytesWritten, stats.FlushBytesWritten, "flush")
+	ch.Send(rocksDbBytesWritten, stats.CompactionBytesWritten, "compaction")
+	ch.Send(rocksDbBytesRead, stats.BytesReadPointLookup, "point_lookup")
+	ch.Send(rocksDbBytesRead, stats.BytesReadIteration, "iteration")
+	ch.Send(rocksDbBytesRead, stats.CompactionBytesRead, "compation")
 }
 
-func (stats *RocksDbStatsCounters) Export(ch chan<- prometheus.Metric) {
-	rocksDbBlockCacheHits.Add(stats.BlockCacheHits)
-	rocksDbBlockCacheMisses.Add(stats.BlockCacheMisses)
-	rocksDbKeys.WithLabelValues("written").Add(stats.NumKeysWritten)
-	rocksDbKeys.WithLabelValues("read").Add(stats.NumKeysRead)
-	rocksDbSeeks.Add(stats.NumSeeks)
-	rocksDbIterations.WithLabelValues("forward").Add(stats.NumForwardIter)
-	rocksDbIterations.WithLabelValues("backward").Add(stats.NumBackwardIter)
-	rocksDbBloomFilterUseful.Add(stats.BloomFilterUseful)
-	rocksDbBytesWritten.WithLabelValues("total").Add(stats.BytesWritten)
-	rocksDbBytesWritten.WithLabelValues("flush").Add(stats.FlushBytesWritten)
-	rocksDbBytesWritten.WithLabelValues("compaction").Add(stats.CompactionBytesWritten)
-	rocksDbBytesRead.WithLabelValues("point_lookup").Add(stats.BytesReadPointLookup)
-	rocksDbBytesRead.WithLabelValues("iteration").Add(stats.BytesReadIteration)
-	rocksDbBytesRead.WithLabelValues("compation").Add(stats.CompactionBytesRead)
-
-	rocksDbBlockCacheHits.Collect(ch)
-	rocksDbBlockCacheMisses.Collect(ch)
-	rocksDbKeys.Collect(ch)
-	rocksDbSeeks.Collect(ch)
-	rocksDbIterations.Collect(ch)
-	rocksDbBloomFilterUseful.Collect(ch)
-	rocksDbBytesWritten.Collect(ch)
-	rocksDbBytesRead.Collect(ch)
-}
-
-func (stats *RocksDbStats) Describe(ch chan<- *prometheus.Desc) {
-	rocksDbWritesPerBatch.Describe(ch)
-	rocksDbWritesPerSec.Describe(ch)
-	rocksDbWALBytesPerSecs.Describe(ch)
-	rocksDbWALWritesPerSync.Describe(ch)
-	rocksDbStallPercent.Describe(ch)
-	rocksDbStalledSecs.Describe(ch)
-	rocksDbLevelFiles.Describe(ch)
-	rocksDbCompactionThreads.Describe(ch)
-	rocksDbLevelSizeBytes.Describe(ch)
-	rocksDbLevelScore.Describe(ch)
-	rocksDbCompactionBytes.Describe(ch)
-	rocksDbCompactionBytesPerSec.Describe(ch)
-	rocksDbCompactionWriteAmplification.Describe(ch)
-	rocksDbCompactionSecondsTotal.Describe(ch)
-	rocksDbCompactionAvgSeconds.Describe(ch)
-	rocksDbCompactionsTotal.Describe(ch)
-	rocksDbNumImmutableMemTable.Describe(ch)
-	rocksDbMemTableFlushPending.Describe(ch)
-	rocksDbCompactionPending.Describe(ch)
-	rocksDbBackgroundErrors.Describe(ch)
-	rocksDbMemTableBytes.Describe(ch)
-	rocksDbMemtableEntries.Describe(ch)
-	rocksDbEstimateTableReadersMem.Describe(ch)
-	rocksDbNumSnapshots.Describe(ch)
-	rocksDbOldestSnapshotTimestamp.Describe(ch)
-	rocksDbNumLiveVersions.Describe(ch)
-	rocksDbBlockCacheUsage.Describe(ch)
-	rocksDbTotalLiveRecoveryUnits.Describe(ch)
-	rocksDbTransactionEngineKeys.Describe(ch)
-	rocksDbTransactionEngineSnapshots.Describe(ch)
-
-	// optional RocksDB counters
-	if stats.Counters != nil {
-		stats.Counters.Describe(ch)
-
-		// read latency stats get added to 'stats' when in counter-mode
-		rocksDbReadOps.Describe(ch)
-		rocksDbReadLatencyMicros.Describe(ch)
-	}
-}
-
-func (stats *RocksDbStats) Export(ch chan<- prometheus.Metric) {
+func (stats *RocksDbStats) Export(ch *shared.MetricChannel) {
 	// cumulative stats from db.serverStatus().rocksdb.stats (parsed):
-	rocksDbWritesPerBatch.Set(stats.GetStatsLineField("** DB Stats **", "Cumulative writes: ", 4))
-	rocksDbWritesPerSec.Set(stats.GetStatsLineField("** DB Stats **", "Cumulative writes: ", 5))
-	rocksDbWALBytesPerSecs.Set(stats.GetStatsLineField("** DB Stats **", "Cumulative WAL: ", 4))
-	rocksDbWALWritesPerSync.Set(stats.GetStatsLineField("** DB Stats **", "Cumulative WAL: ", 2))
-	rocksDbStalledSecs.Add(stats.GetStatsLineField("** DB Stats **", "Cumulative stall: ", 0))
-	rocksDbStallPercent.Set(stats.GetStatsLineField("** DB Stats **", "Cumulative stall: ", 1))
+	ch.Send(rocksDbWritesPerBatch, stats.GetStatsLineField("** DB Stats **", "Cumulative writes: ", 4))
+	ch.Send(rocksDbWritesPerSec, stats.GetStatsLineField("** DB Stats **", "Cumulative writes: ", 5))
+	ch.Send(rocksDbWALBytesPerSecs, stats.GetStatsLineField("** DB Stats **", "Cumulative WAL: ", 4))
+	ch.Send(rocksDbWALWritesPerSync, stats.GetStatsLineField("** DB Stats **", "Cumulative WAL: ", 2))
+	ch.Send(rocksDbStalledSecs, stats.GetStatsLineField("** DB Stats **", "Cumulative stall: ", 0))
+	ch.Send(rocksDbStallPercent, stats.GetStatsLineField("** DB Stats **", "Cumulative stall: ", 1))
 
 	// stats from db.serverStatus().rocksdb (parsed):
-	rocksDbNumImmutableMemTable.Set(ParseStr(stats.NumImmutableMemTable))
-	rocksDbMemTableFlushPending.Set(ParseStr(stats.MemTableFlushPending))
-	rocksDbCompactionPending.Set(ParseStr(stats.CompactionPending))
-	rocksDbBackgroundErrors.Set(ParseStr(stats.BackgroundErrors))
-	rocksDbMemtableEntries.WithLabelValues("active").Set(ParseStr(stats.NumEntriesMemTableActive))
-	rocksDbMemtableEntries.WithLabelValues("immutable").Set(ParseStr(stats.NumEntriesImmMemTables))
-	rocksDbNumSnapshots.Set(ParseStr(stats.NumSnapshots))
-	rocksDbOldestSnapshotTimestamp.Set(ParseStr(stats.OldestSnapshotTime))
-	rocksDbNumLiveVersions.Set(ParseStr(stats.NumLiveVersions))
-	rocksDbBlockCacheUsage.Set(ParseStr(stats.BlockCacheUsage))
-	rocksDbEstimateTableReadersMem.Set(ParseStr(stats.EstimateTableReadersMem))
-	rocksDbMemTableBytes.WithLabelValues("active").Set(ParseStr(stats.CurSizeMemTableActive))
-	rocksDbMemTableBytes.WithLabelValues("total").Set(ParseStr(stats.CurSizeAllMemTables))
+	ch.Send(rocksDbNumImmutableMemTable, ParseStr(stats.NumImmutableMemTable))
+	ch.Send(rocksDbMemTableFlushPending, ParseStr(stats.MemTableFlushPending))
+	ch.Send(rocksDbCompactionPending, ParseStr(stats.CompactionPending))
+	ch.Send(rocksDbBackgroundErrors, ParseStr(stats.BackgroundErrors))
+	ch.Send(rocksDbMemtableEntries, ParseStr(stats.NumEntriesMemTableActive), "active")
+	ch.Send(rocksDbMemtableEntries, ParseStr(stats.NumEntriesImmMemTables), "immutable")
+	ch.Send(rocksDbNumSnapshots, ParseStr(stats.NumSnapshots))
+	ch.Send(rocksDbOldestSnapshotTimestamp, ParseStr(stats.OldestSnapshotTime))
+	ch.Send(rocksDbNumLiveVersions, ParseStr(stats.NumLiveVersions))
+	ch.Send(rocksDbBlockCacheUsage, ParseStr(stats.BlockCacheUsage))
+	ch.Send(rocksDbEstimateTableReadersMem, ParseStr(stats.EstimateTableReadersMem))
+	ch.Send(rocksDbMemTableBytes, ParseStr(stats.CurSizeMemTableActive), "active")
+	ch.Send(rocksDbMemTableBytes, ParseStr(stats.CurSizeAllMemTables), "total")
 
 	// stats from db.serverStatus().rocksdb (unparsed - somehow these aren't real types!):
-	rocksDbTotalLiveRecoveryUnits.Set(stats.TotalLiveRecoveryUnits)
-	rocksDbTransactionEngineKeys.Set(stats.TransactionEngineKeys)
-	rocksDbTransactionEngineSnapshots.Set(stats.TransactionEngineSnapshots)
+	ch.Send(rocksDbTotalLiveRecoveryUnits, stats.TotalLiveRecoveryUnits)
+	ch.Send(rocksDbTransactionEngineKeys, stats.TransactionEngineKeys)
+	ch.Send(rocksDbTransactionEngineSnapshots, stats.TransactionEngineSnapshots)
 
 	// process per-level stats in to vectors:
-	stats.ProcessLevelStats()
+	stats.ProcessLevelStats(ch)
 
 	// process stall counts into a vector:
-	stats.ProcessStalls()
-
-	rocksDbWritesPerBatch.Collect(ch)
-	rocksDbWritesPerSec.Collect(ch)
-	rocksDbWALBytesPerSecs.Collect(ch)
-	rocksDbWALWritesPerSync.Collect(ch)
-	rocksDbStallPercent.Collect(ch)
-	rocksDbStalledSecs.Collect(ch)
-	rocksDbLevelFiles.Collect(ch)
-	rocksDbCompactionThreads.Collect(ch)
-	rocksDbLevelSizeBytes.Collect(ch)
-	rocksDbLevelScore.Collect(ch)
-	rocksDbCompactionBytesPerSec.Collect(ch)
-	rocksDbCompactionWriteAmplification.Collect(ch)
-	rocksDbCompactionSecondsTotal.Collect(ch)
-	rocksDbCompactionAvgSeconds.Collect(ch)
-	rocksDbCompactionsTotal.Collect(ch)
-	rocksDbNumImmutableMemTable.Collect(ch)
-	rocksDbMemTableFlushPending.Collect(ch)
-	rocksDbCompactionPending.Collect(ch)
-	rocksDbBackgroundErrors.Collect(ch)
-	rocksDbMemtableEntries.Collect(ch)
-	rocksDbNumSnapshots.Collect(ch)
-	rocksDbOldestSnapshotTimestamp.Collect(ch)
-	rocksDbNumLiveVersions.Collect(ch)
-	rocksDbTotalLiveRecoveryUnits.Collect(ch)
-	rocksDbTransactionEngineKeys.Collect(ch)
-	rocksDbTransactionEngineSnapshots.Collect(ch)
-	rocksDbMemTableBytes.Collect(ch)
-	rocksDbEstimateTableReadersMem.Collect(ch)
-	rocksDbBlockCacheUsage.Collect(ch)
-	rocksDbStalls.Collect(ch)
+	stats.ProcessStalls(ch)
 
 	// optional RocksDB counters
 	if stats.Counters != nil {
 		stats.Counters.Export(ch)
 
 		// read latency stats get added to 'stats' when in counter-mode
-		stats.ProcessReadLatencyStats()
-		rocksDbReadOps.Collect(ch)
-		rocksDbReadLatencyMicros.Collect(ch)
+		stats.ProcessReadLatencyStats(ch)
 	}
 }
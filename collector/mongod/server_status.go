@@ -3,33 +3,75 @@ package collector_mongod
 import (
 	"time"
 
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
-	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
 var (
-	instanceUptimeSeconds = prometheus.NewCounter(prometheus.CounterOpts{
+	instanceUptimeSeconds = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "instance",
 		Name:      "uptime_seconds",
-		Help:      "The value of the uptime field corresponds to the number of seconds that the mongos or mongod process has been active.",
-	})
-	instanceUptimeEstimateSeconds = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	instanceUptimeEstimateSeconds = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "instance",
 		Name:      "uptime_estimate_seconds",
-		Help:      "uptimeEstimate provides the uptime as calculated from MongoDB's internal course-grained time keeping system.",
-	})
-	instanceLocalTime = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	instanceLocalTime = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "instance",
 		Name:      "local_time",
-		Help:      "The localTime value is the current time, according to the server, in UTC specified in an ISODate format.",
-	})
+	}, nil)
 )
 
+// serverStatusGroups maps the group names accepted by -groups.enabled to the serverStatus sub-document they control.
+// The storageEngine sub-document, which decides the storage engine capabilities, is always asked for, the
+// storage_engine group only controls its export.
+var serverStatusGroups = map[string]string{
+	"asserts":             "asserts",
+	"durability":          "dur",
+	"background_flushing": "backgroundFlushing",
+	"connections":         "connections",
+	"extra_info":          "extra_info",
+	"global_lock":         "globalLock",
+	"index_counters":      "indexCounters",
+	"locks":               "locks",
+	"network":             "network",
+	"op_counters":         "opcounters",
+	"op_counters_repl":    "opcountersRepl",
+	"memory":              "mem",
+	"metrics":             "metrics",
+	"cursors":             "cursors",
+	"in_memory":           "inMemory",
+	"rocksdb":             "rocksdb",
+	"wiredtiger":          "wiredTiger",
+	"queues":              "queues",
+}
+
+func init() {
+	for group := range serverStatusGroups {
+		shared.RegisterGroup(group)
+	}
+	shared.RegisterGroup("storage_engine")
+	shared.RegisterGroup("replset")
+	shared.RegisterGroup("oplog")
+
+	// the serverStatus fields of a storage engine or of the removed MMAPv1 engine
+	for _, capability := range []shared.Capability{
+		{Name: "serverStatus.backgroundFlushing", MaxVersion: shared.Version{Major: 4, Minor: 2}, StorageEngines: []string{"mmapv1"}},
+		{Name: "serverStatus.dur", MaxVersion: shared.Version{Major: 4, Minor: 2}, StorageEngines: []string{"mmapv1"}},
+		{Name: "serverStatus.indexCounters", MaxVersion: shared.Version{Major: 3}},
+		{Name: "serverStatus.inMemory", MinVersion: shared.Version{Major: 3, Minor: 2}, StorageEngines: []string{"inMemory"}},
+		{Name: "serverStatus.rocksdb", StorageEngines: []string{"rocksdb"}},
+		{Name: "serverStatus.wiredTiger", MinVersion: shared.Version{Major: 3}, StorageEngines: []string{"wiredTiger"}},
+	} {
+		shared.RegisterCapability(capability)
+	}
+}
+
 // ServerStatus keeps the data returned by the serverStatus() method.
 type ServerStatus struct {
 	Uptime         float64   `bson:"uptime"`
@@ -65,16 +107,19 @@ type ServerStatus struct {
 	InMemory      *WiredTigerStats    `bson:"inMemory"`
 	RocksDb       *RocksDbStats       `bson:"rocksdb"`
 	WiredTiger    *WiredTigerStats    `bson:"wiredTiger"`
+
+	Queues *QueuesStats `bson:"queues"`
+
+	// Raw is the whole serverStatus document, for the dynamic metrics of the fields not decoded above.
+	Raw bson.Raw `bson:"-"`
 }
 
-// Export exports the server status to be consumed by prometheus.
-func (status *ServerStatus) Export(ch chan<- prometheus.Metric) {
-	instanceUptimeSeconds.Add(status.Uptime)
-	instanceUptimeEstimateSeconds.Add(status.Uptime)
-	instanceLocalTime.Add(float64(status.LocalTime.Unix()))
-	instanceUptimeSeconds.Collect(ch)
-	instanceUptimeEstimateSeconds.Collect(ch)
-	instanceLocalTime.Collect(ch)
+// Export exports the server status to be consumed by prometheus. The channel drops the metrics of
+// the disabled groups, as given by the catalog.
+func (status *ServerStatus) Export(ch *shared.MetricChannel) {
+	ch.Send(instanceUptimeSeconds, status.Uptime)
+	ch.Send(instanceUptimeEstimateSeconds, status.UptimeEstimate)
+	ch.Send(instanceLocalTime, float64(status.LocalTime.Unix()))
 
 	if status.Asserts != nil {
 		status.Asserts.Export(ch)
@@ -127,6 +172,9 @@ func (status *ServerStatus) Export(ch chan<- prometheus.Metric) {
 	if status.WiredTiger != nil {
 		status.WiredTiger.Export(ch)
 	}
+	if status.Queues != nil {
+		status.Queues.Export(ch)
+	}
 
 	// If db.serverStatus().storageEngine does not exist (3.0+ only) and status.BackgroundFlushing does (MMAPv1 only), default to mmapv1
 	// https://docs.mongodb.com/v3.0/reference/command/serverStatus/#storageengine
@@ -140,76 +188,32 @@ func (status *ServerStatus) Export(ch chan<- prometheus.Metric) {
 	}
 }
 
-// Describe describes the server status for prometheus.
-func (status *ServerStatus) Describe(ch chan<- *prometheus.Desc) {
-	instanceUptimeSeconds.Describe(ch)
-	instanceUptimeEstimateSeconds.Describe(ch)
-	instanceLocalTime.Describe(ch)
-
-	if status.Asserts != nil {
-		status.Asserts.Describe(ch)
-	}
-	if status.Dur != nil {
-		status.Dur.Describe(ch)
-	}
-	if status.BackgroundFlushing != nil {
-		status.BackgroundFlushing.Describe(ch)
-	}
-	if status.Connections != nil {
-		status.Connections.Describe(ch)
-	}
-	if status.ExtraInfo != nil {
-		status.ExtraInfo.Describe(ch)
-	}
-	if status.GlobalLock != nil {
-		status.GlobalLock.Describe(ch)
-	}
-	if status.IndexCounter != nil {
-		status.IndexCounter.Describe(ch)
-	}
-	if status.Network != nil {
-		status.Network.Describe(ch)
-	}
-	if status.Opcounters != nil {
-		status.Opcounters.Describe(ch)
-	}
-	if status.OpcountersRepl != nil {
-		status.OpcountersRepl.Describe(ch)
-	}
-	if status.Mem != nil {
-		status.Mem.Describe(ch)
-	}
-	if status.Locks != nil {
-		status.Locks.Describe(ch)
-	}
-	if status.Metrics != nil {
-		status.Metrics.Describe(ch)
-	}
-	if status.Cursors != nil {
-		status.Cursors.Describe(ch)
-	}
-	if status.StorageEngine != nil {
-		status.StorageEngine.Describe(ch)
-	}
-	if status.InMemory != nil {
-		status.InMemory.Describe(ch)
-	}
-	if status.RocksDb != nil {
-		status.RocksDb.Describe(ch)
-	}
-	if status.WiredTiger != nil {
-		status.WiredTiger.Describe(ch)
-	}
-}
-
-// GetServerStatus returns the server status info.
-func GetServerStatus(session *mgo.Session) *ServerStatus {
-	result := &ServerStatus{}
-	err := session.DB("admin").Run(bson.D{{"serverStatus", 1}, {"recordStats", 0}}, result)
+// GetServerStatus returns the server status info, without the fields the server does not support
+// (caps may be nil).
+func GetServerStatus(session shared.Session, caps *shared.ServerCapabilities) *ServerStatus {
+	raw := bson.Raw{}
+	err := session.Run("admin", serverStatusCommand(caps), &raw)
 	if err != nil {
 		glog.Error("Failed to get server status.")
 		return nil
 	}
+	result := &ServerStatus{Raw: raw}
+	if err := raw.Unmarshal(result); err != nil {
+		glog.Errorf("Failed to decode server status: %s", err)
+		return nil
+	}
 
 	return result
 }
+
+// serverStatusCommand builds the serverStatus command, excluding the sub-documents of disabled groups
+// and the ones the server does not support.
+func serverStatusCommand(caps *shared.ServerCapabilities) bson.D {
+	cmd := bson.D{{"serverStatus", 1}, {"recordStats", 0}}
+	for group, field := range serverStatusGroups {
+		if !shared.IsGroupEnabled(group) || !caps.Supports("serverStatus."+field) {
+			cmd = append(cmd, bson.DocElem{field, 0})
+		}
+	}
+	return cmd
+}
@@ -1,8 +1,11 @@
 package collector_mongod
 
 import (
+	"regexp"
 	"testing"
 
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -65,9 +68,117 @@ func Test_ParserServerStatus(t *testing.T) {
 	}
 }
 
+func Test_ExportServerStatus(t *testing.T) {
+	data := LoadFixture("server_status.bson")
+
+	serverStatus := &ServerStatus{}
+	loadServerStatusFromBson(data, serverStatus)
+
+	// counters are the server totals, however many times they are exported
+	for i := 0; i < 2; i++ {
+		values, err := sessiontest.GatherExport(serverStatus.Export)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if values["mongodb_mongod_instance_uptime_seconds"] != serverStatus.Uptime {
+			t.Errorf("expected an uptime of %v, got %v", serverStatus.Uptime, values["mongodb_mongod_instance_uptime_seconds"])
+		}
+		if values[`mongodb_mongod_asserts_total{type="user"}`] != serverStatus.Asserts.User {
+			t.Errorf("expected %v user asserts, got %v", serverStatus.Asserts.User, values[`mongodb_mongod_asserts_total{type="user"}`])
+		}
+	}
+}
+
 func loadServerStatusFromBson(data []byte, status *ServerStatus) {
 	err := bson.Unmarshal(data, status)
 	if err != nil {
 		panic(err)
 	}
 }
+
+func Test_DynamicServerStatus(t *testing.T) {
+	raw := bson.Raw{}
+	if err := bson.Unmarshal(LoadFixture("server_status.bson"), &raw); err != nil {
+		t.Fatal(err)
+	}
+	walker := shared.NewStatusWalker(shared.StatusWalkerOpts{
+		Prefix: "mongodb_ss",
+		Deny:   regexp.MustCompile(`^pid$`),
+	}, ServerStatus{})
+	values, err := sessiontest.GatherExport(func(ch *shared.MetricChannel) {
+		walker.Export(ch, raw)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values["mongodb_ss_uptime_millis"] != 127859430 {
+		t.Errorf("expected uptimeMillis to be exported, got %v", values["mongodb_ss_uptime_millis"])
+	}
+	for _, name := range []string{
+		// decoded by ServerStatus, and exported by the hand-written metrics
+		"mongodb_ss_uptime",
+		"mongodb_ss_asserts_regular",
+		"mongodb_ss_connections_current",
+		"mongodb_ss_extra_info_page_faults",
+		"mongodb_ss_metrics_document_inserted",
+		// denied
+		"mongodb_ss_pid",
+	} {
+		if _, ok := values[name]; ok {
+			t.Errorf("expected %s not to be exported", name)
+		}
+	}
+}
+
+func Test_ExportUptime(t *testing.T) {
+	serverStatus := &ServerStatus{}
+	loadServerStatusFromBson(LoadFixture("server_status.bson"), serverStatus)
+
+	values, err := sessiontest.GatherExport(serverStatus.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["mongodb_mongod_instance_uptime_seconds"] != 127859 || values["mongodb_mongod_instance_uptime_estimate_seconds"] != 13850 {
+		t.Errorf("unexpected uptime %v and uptime estimate %v", values["mongodb_mongod_instance_uptime_seconds"], values["mongodb_mongod_instance_uptime_estimate_seconds"])
+	}
+}
+
+func Test_ServerStatusCommandKeepsStorageEngine(t *testing.T) {
+	shared.ParseEnabledGroups("connections")
+	// no parsed groups enable every group
+	defer shared.ParseEnabledGroups("")
+
+	excluded := map[string]bool{}
+	for _, elem := range serverStatusCommand(nil)[2:] {
+		excluded[elem.Name] = true
+	}
+	if excluded["storageEngine"] {
+		t.Error("expected the storage engine to be asked for without the storage_engine group")
+	}
+	if !excluded["wiredTiger"] || excluded["connections"] {
+		t.Errorf("expected the sub-documents of the disabled groups only to be excluded, got %v", excluded)
+	}
+}
+
+func Test_ExportMetricsAndGlobalLock(t *testing.T) {
+	serverStatus := &ServerStatus{}
+	loadServerStatusFromBson(LoadFixture("server_status.bson"), serverStatus)
+	// the totalTime of the fixture is a string, metrics.repl.oplog is not in the fixture
+	serverStatus.GlobalLock.TotalTime = 127859430000
+	serverStatus.Metrics.Repl.Oplog = &ReplOplogStats{Insert: &BenchmarkStats{Num: 42, TotalMillis: 7}, InsertBytes: 12800}
+
+	values, err := sessiontest.GatherExport(serverStatus.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessiontest.ExpectValues(t, values, map[string]float64{
+		"mongodb_mongod_global_lock_total":                            127859430000,
+		"mongodb_mongod_global_lock_lock_total":                       7097013,
+		"mongodb_mongod_metrics_ttl_passes_total":                     1130,
+		"mongodb_mongod_metrics_ttl_deleted_documents_total":          0,
+		"mongodb_mongod_metrics_repl_oplog_insert_num_total":          42,
+		"mongodb_mongod_metrics_repl_oplog_insert_total_milliseconds": 7,
+		"mongodb_mongod_metrics_repl_oplog_insert_bytes_total":        12800,
+	})
+}
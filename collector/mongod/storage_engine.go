@@ -1,14 +1,14 @@
 package collector_mongod
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	storageEngine = prometheus.NewCounterVec(prometheus.CounterOpts{
+	storageEngine = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "storage_engine",
-		Help:      "The storage engine used by the MongoDB instance",
 	}, []string{"engine"})
 )
 
@@ -18,12 +18,6 @@ type StorageEngineStats struct {
 }
 
 // Export exports the data to prometheus.
-func (stats *StorageEngineStats) Export(ch chan<- prometheus.Metric) {
-	storageEngine.WithLabelValues(stats.Name).Add(1)
-	storageEngine.Collect(ch)
-}
-
-// Describe describes the metrics for prometheus
-func (stats *StorageEngineStats) Describe(ch chan<- *prometheus.Desc) {
-	storageEngine.Describe(ch)
+func (stats *StorageEngineStats) Export(ch *shared.MetricChannel) {
+	ch.Send(storageEngine, 1, stats.Name)
 }
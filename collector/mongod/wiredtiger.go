@@ -1,156 +1,140 @@
 package collector_mongod
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	wtBlockManagerBlocksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	wtBlockManagerBlocksTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_blockmanager",
 		Name:      "blocks_total",
-		Help:      "The total number of blocks read by the WiredTiger BlockManager",
 	}, []string{"type"})
-	wtBlockManagerBytesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	wtBlockManagerBytesTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_blockmanager",
 		Name:      "bytes_total",
-		Help:      "The total number of bytes read by the WiredTiger BlockManager",
 	}, []string{"type"})
 )
 
 var (
-	wtCachePages = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	wtCachePages = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_cache",
 		Name:      "pages",
-		Help:      "The current number of pages in the WiredTiger Cache",
 	}, []string{"type"})
-	wtCachePagesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	wtCachePagesTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_cache",
 		Name:      "pages_total",
-		Help:      "The total number of pages read into/from the WiredTiger Cache",
 	}, []string{"type"})
-	wtCacheBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	wtCacheBytes = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_cache",
 		Name:      "bytes",
-		Help:      "The current size of data in the WiredTiger Cache in bytes",
 	}, []string{"type"})
-	wtCacheMaxBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+	wtCacheMaxBytes = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_cache",
 		Name:      "max_bytes",
-		Help:      "The maximum size of data in the WiredTiger Cache in bytes",
-	})
-	wtCacheBytesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	}, nil)
+	wtCacheBytesTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_cache",
 		Name:      "bytes_total",
-		Help:      "The total number of bytes read into/from the WiredTiger Cache",
 	}, []string{"type"})
-	wtCacheEvictedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	wtCacheEvictedTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_cache",
 		Name:      "evicted_total",
-		Help:      "The total number of pages evicted from the WiredTiger Cache",
 	}, []string{"type"})
-	wtCachePercentOverhead = prometheus.NewGauge(prometheus.GaugeOpts{
+	wtCachePercentOverhead = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_cache",
 		Name:      "overhead_percent",
-		Help:      "The percentage overhead of the WiredTiger Cache",
-	})
+	}, nil)
 )
 
 var (
-	wtTransactionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	wtTransactionsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_transactions",
 		Name:      "total",
-		Help:      "The total number of transactions WiredTiger has handled",
 	}, []string{"type"})
-	wtTransactionsTotalCheckpointMs = prometheus.NewCounter(prometheus.CounterOpts{
+	wtTransactionsTotalCheckpointMs = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_transactions",
 		Name:      "checkpoint_milliseconds_total",
-		Help:      "The total time in milliseconds transactions have checkpointed in WiredTiger",
-	})
-	wtTransactionsCheckpointMs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	}, nil)
+	wtTransactionsCheckpointMs = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_transactions",
 		Name:      "checkpoint_milliseconds",
-		Help:      "The time in milliseconds transactions have checkpointed in WiredTiger",
 	}, []string{"type"})
-	wtTransactionsCheckpointsRunning = prometheus.NewGauge(prometheus.GaugeOpts{
+	wtTransactionsCheckpointsRunning = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_transactions",
 		Name:      "running_checkpoints",
-		Help:      "The number of currently running checkpoints in WiredTiger",
-	})
+	}, nil)
 )
 
 var (
-	wtLogRecordsScannedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	wtLogRecordsScannedTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_log",
 		Name:      "records_scanned_total",
-		Help:      "The total number of records scanned by log scan in the WiredTiger log",
-	})
-	wtLogRecordsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	}, nil)
+	wtLogRecordsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_log",
 		Name:      "records_total",
-		Help:      "The total number of compressed/uncompressed records written to the WiredTiger log",
 	}, []string{"type"})
-	wtLogBytesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	wtLogBytesTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_log",
 		Name:      "bytes_total",
-		Help:      "The total number of bytes written to the WiredTiger log",
 	}, []string{"type"})
-	wtLogOperationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	wtLogOperationsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_log",
 		Name:      "operations_total",
-		Help:      "The total number of WiredTiger log operations",
 	}, []string{"type"})
 )
 
 var (
-	wtOpenCursors = prometheus.NewGauge(prometheus.GaugeOpts{
+	wtOpenCursors = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_session",
 		Name:      "open_cursors_total",
-		Help:      "The total number of cursors opened in WiredTiger",
-	})
-	wtOpenSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+	}, nil)
+	wtOpenSessions = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_session",
 		Name:      "open_sessions_total",
-		Help:      "The total number of sessions opened in WiredTiger",
-	})
+	}, nil)
 )
 
 var (
-	wtConcurrentTransactionsOut = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	wtConcurrentTransactionsOut = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_concurrent_transactions",
 		Name:      "out_tickets",
-		Help:      "The number of tickets that are currently in use (out) in WiredTiger",
 	}, []string{"type"})
-	wtConcurrentTransactionsAvailable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	wtConcurrentTransactionsAvailable = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_concurrent_transactions",
 		Name:      "available_tickets",
-		Help:      "The number of tickets that are available in WiredTiger",
 	}, []string{"type"})
-	wtConcurrentTransactionsTotalTickets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	wtConcurrentTransactionsTotalTickets = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "wiredtiger_concurrent_transactions",
 		Name:      "total_tickets",
-		Help:      "The total number of tickets that are available in WiredTiger",
+	}, []string{"type"})
+	wtConcurrentTransactionsUtilization = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "wiredtiger_concurrent_transactions",
+		Name:      "utilization_ratio",
 	}, []string{"type"})
 )
 
@@ -165,19 +149,14 @@ type WTBlockManagerStats struct {
 	BlocksWritten    float64 `bson:"blocks written"`
 }
 
-func (stats *WTBlockManagerStats) Export(ch chan<- prometheus.Metric) {
-	wtBlockManagerBlocksTotal.WithLabelValues("read").Add(stats.BlocksRead)
-	wtBlockManagerBlocksTotal.WithLabelValues("read_mapped").Add(stats.MappedBlocksRead)
-	wtBlockManagerBlocksTotal.WithLabelValues("pre_loaded").Add(stats.BlocksPreLoaded)
-	wtBlockManagerBlocksTotal.WithLabelValues("written").Add(stats.BlocksWritten)
-	wtBlockManagerBytesTotal.WithLabelValues("read").Add(stats.BytesRead)
-	wtBlockManagerBytesTotal.WithLabelValues("read_mapped").Add(stats.MappedBytesRead)
-	wtBlockManagerBytesTotal.WithLabelValues("written").Add(stats.BytesWritten)
-}
-
-func (stats *WTBlockManagerStats) Describe(ch chan<- *prometheus.Desc) {
-	wtBlockManagerBlocksTotal.Describe(ch)
-	wtBlockManagerBytesTotal.Describe(ch)
+func (stats *WTBlockManagerStats) Export(ch *shared.MetricChannel) {
+	ch.Send(wtBlockManagerBlocksTotal, stats.BlocksRead, "read")
+	ch.Send(wtBlockManagerBlocksTotal, stats.MappedBlocksRead, "read_mapped")
+	ch.Send(wtBlockManagerBlocksTotal, stats.BlocksPreLoaded, "pre_loaded")
+	ch.Send(wtBlockManagerBlocksTotal, stats.BlocksWritten, "written")
+	ch.Send(wtBlockManagerBytesTotal, stats.BytesRead, "read")
+	ch.Send(wtBlockManagerBytesTotal, stats.MappedBytesRead, "read_mapped")
+	ch.Send(wtBlockManagerBytesTotal, stats.BytesWritten, "written")
 }
 
 // cache stats
@@ -198,30 +177,21 @@ type WTCacheStats struct {
 	PagesDirty         float64 `bson:"tracked dirty pages in the cache"`
 }
 
-func (stats *WTCacheStats) Export(ch chan<- prometheus.Metric) {
-	wtCachePagesTotal.WithLabelValues("read").Add(stats.PagesReadInto)
-	wtCachePagesTotal.WithLabelValues("written").Add(stats.PagesWrittenFrom)
-	wtCacheBytesTotal.WithLabelValues("read").Add(stats.BytesReadInto)
-	wtCacheBytesTotal.WithLabelValues("written").Add(stats.BytesWrittenFrom)
-	wtCacheEvictedTotal.WithLabelValues("modified").Add(stats.EvictedModified)
-	wtCacheEvictedTotal.WithLabelValues("unmodified").Add(stats.EvictedUnmodified)
-	wtCachePages.WithLabelValues("total").Set(stats.PagesTotal)
-	wtCachePages.WithLabelValues("dirty").Set(stats.PagesDirty)
-	wtCacheBytes.WithLabelValues("total").Set(stats.BytesTotal)
-	wtCacheBytes.WithLabelValues("dirty").Set(stats.BytesDirty)
-	wtCacheBytes.WithLabelValues("internal_pages").Set(stats.BytesInternalPages)
-	wtCacheBytes.WithLabelValues("leaf_pages").Set(stats.BytesLeafPages)
-	wtCacheMaxBytes.Set(stats.MaxBytes)
-	wtCachePercentOverhead.Set(stats.PercentOverhead)
-}
-
-func (stats *WTCacheStats) Describe(ch chan<- *prometheus.Desc) {
-	wtCachePagesTotal.Describe(ch)
-	wtCacheEvictedTotal.Describe(ch)
-	wtCachePages.Describe(ch)
-	wtCacheBytes.Describe(ch)
-	wtCacheMaxBytes.Describe(ch)
-	wtCachePercentOverhead.Describe(ch)
+func (stats *WTCacheStats) Export(ch *shared.MetricChannel) {
+	ch.Send(wtCachePagesTotal, stats.PagesReadInto, "read")
+	ch.Send(wtCachePagesTotal, stats.PagesWrittenFrom, "written")
+	ch.Send(wtCacheBytesTotal, stats.BytesReadInto, "read")
+	ch.Send(wtCacheBytesTotal, stats.BytesWrittenFrom, "written")
+	ch.Send(wtCacheEvictedTotal, stats.EvictedModified, "modified")
+	ch.Send(wtCacheEvictedTotal, stats.EvictedUnmodified, "unmodified")
+	ch.Send(wtCachePages, stats.PagesTotal, "total")
+	ch.Send(wtCachePages, stats.PagesDirty, "dirty")
+	ch.Send(wtCacheBytes, stats.BytesTotal, "total")
+	ch.Send(wtCacheBytes, stats.BytesDirty, "dirty")
+	ch.Send(wtCacheBytes, stats.BytesInternalPages, "internal_pages")
+	ch.Send(wtCacheBytes, stats.BytesLeafPages, "leaf_pages")
+	ch.Send(wtCacheMaxBytes, stats.MaxBytes)
+	ch.Send(wtCachePercentOverhead, stats.PercentOverhead)
 }
 
 // log stats
@@ -243,26 +213,19 @@ type WTLogStats struct {
 	LogWrites               float64 `bson:"log write operations"`
 }
 
-func (stats *WTLogStats) Export(ch chan<- prometheus.Metric) {
-	wtLogRecordsTotal.WithLabelValues("compressed").Add(stats.RecordsCompressed)
-	wtLogRecordsTotal.WithLabelValues("uncompressed").Add(stats.RecordsUncompressed)
-	wtLogBytesTotal.WithLabelValues("payload").Add(stats.BytesPayloadData)
-	wtLogBytesTotal.WithLabelValues("written").Add(stats.BytesWritten)
-	wtLogOperationsTotal.WithLabelValues("read").Add(stats.LogReads)
-	wtLogOperationsTotal.WithLabelValues("write").Add(stats.LogWrites)
-	wtLogOperationsTotal.WithLabelValues("scan").Add(stats.LogScans)
-	wtLogOperationsTotal.WithLabelValues("scan_double").Add(stats.LogScansDouble)
-	wtLogOperationsTotal.WithLabelValues("sync").Add(stats.LogSyncs)
-	wtLogOperationsTotal.WithLabelValues("sync_dir").Add(stats.LogSyncDirs)
-	wtLogOperationsTotal.WithLabelValues("flush").Add(stats.LogFlushes)
-	wtLogRecordsScannedTotal.Add(stats.RecordsProcessedLogScan)
-}
-
-func (stats *WTLogStats) Describe(ch chan<- *prometheus.Desc) {
-	wtLogRecordsTotal.Describe(ch)
-	wtLogBytesTotal.Describe(ch)
-	wtLogOperationsTotal.Describe(ch)
-	wtLogRecordsScannedTotal.Describe(ch)
+func (stats *WTLogStats) Export(ch *shared.MetricChannel) {
+	ch.Send(wtLogRecordsTotal, stats.RecordsCompressed, "compressed")
+	ch.Send(wtLogRecordsTotal, stats.RecordsUncompressed, "uncompressed")
+	ch.Send(wtLogBytesTotal, stats.BytesPayloadData, "payload")
+	ch.Send(wtLogBytesTotal, stats.BytesWritten, "written")
+	ch.Send(wtLogOperationsTotal, stats.LogReads, "read")
+	ch.Send(wtLogOperationsTotal, stats.LogWrites, "write")
+	ch.Send(wtLogOperationsTotal, stats.LogScans, "scan")
+	ch.Send(wtLogOperationsTotal, stats.LogScansDouble, "scan_double")
+	ch.Send(wtLogOperationsTotal, stats.LogSyncs, "sync")
+	ch.Send(wtLogOperationsTotal, stats.LogSyncDirs, "sync_dir")
+	ch.Send(wtLogOperationsTotal, stats.LogFlushes, "flush")
+	ch.Send(wtLogRecordsScannedTotal, stats.RecordsProcessedLogScan)
 }
 
 // session stats
@@ -271,14 +234,9 @@ type WTSessionStats struct {
 	Sessions float64 `bson:"open session count"`
 }
 
-func (stats *WTSessionStats) Export(ch chan<- prometheus.Metric) {
-	wtOpenCursors.Set(stats.Cursors)
-	wtOpenSessions.Set(stats.Sessions)
-}
-
-func (stats *WTSessionStats) Describe(ch chan<- *prometheus.Desc) {
-	wtOpenCursors.Describe(ch)
-	wtOpenSessions.Describe(ch)
+func (stats *WTSessionStats) Export(ch *shared.MetricChannel) {
+	ch.Send(wtOpenCursors, stats.Cursors)
+	ch.Send(wtOpenSessions, stats.Sessions)
 }
 
 // transaction stats
@@ -295,22 +253,15 @@ type WTTransactionStats struct {
 	RolledBack           float64 `bson:"transactions rolled back"`
 }
 
-func (stats *WTTransactionStats) Export(ch chan<- prometheus.Metric) {
-	wtTransactionsTotal.WithLabelValues("begins").Add(stats.Begins)
-	wtTransactionsTotal.WithLabelValues("checkpoints").Add(stats.Checkpoints)
-	wtTransactionsTotal.WithLabelValues("committed").Add(stats.Committed)
-	wtTransactionsTotal.WithLabelValues("rolledback").Add(stats.RolledBack)
-	wtTransactionsCheckpointMs.WithLabelValues("min").Set(stats.CheckpointMinMs)
-	wtTransactionsCheckpointMs.WithLabelValues("max").Set(stats.CheckpointMaxMs)
-	wtTransactionsTotalCheckpointMs.Add(stats.CheckpointTotalMs)
-	wtTransactionsCheckpointsRunning.Set(stats.CheckpointsRunning)
-}
-
-func (stats *WTTransactionStats) Describe(ch chan<- *prometheus.Desc) {
-	wtTransactionsTotal.Describe(ch)
-	wtTransactionsTotalCheckpointMs.Describe(ch)
-	wtTransactionsCheckpointMs.Describe(ch)
-	wtTransactionsCheckpointsRunning.Describe(ch)
+func (stats *WTTransactionStats) Export(ch *shared.MetricChannel) {
+	ch.Send(wtTransactionsTotal, stats.Begins, "begins")
+	ch.Send(wtTransactionsTotal, stats.Checkpoints, "checkpoints")
+	ch.Send(wtTransactionsTotal, stats.Committed, "committed")
+	ch.Send(wtTransactionsTotal, stats.RolledBack, "rolledback")
+	ch.Send(wtTransactionsCheckpointMs, stats.CheckpointMinMs, "min")
+	ch.Send(wtTransactionsCheckpointMs, stats.CheckpointMaxMs, "max")
+	ch.Send(wtTransactionsTotalCheckpointMs, stats.CheckpointTotalMs)
+	ch.Send(wtTransactionsCheckpointsRunning, stats.CheckpointsRunning)
 }
 
 // concurrenttransaction stats
@@ -320,24 +271,34 @@ type WTConcurrentTransactionsTypeStats struct {
 	TotalTickets float64 `bson:"totalTickets"`
 }
 
-type WTConcurrentTransactionsStats struct {
-	Write *WTConcurrentTransactionsTypeStats `bson:"read"`
-	Read  *WTConcurrentTransactionsTypeStats `bson:"write"`
+// Utilization returns the ratio of the tickets in use, and false when there are no tickets.
+func (stats *WTConcurrentTransactionsTypeStats) Utilization() (float64, bool) {
+	if stats.TotalTickets <= 0 {
+		return 0, false
+	}
+	return stats.Out / stats.TotalTickets, true
 }
 
-func (stats *WTConcurrentTransactionsStats) Export(ch chan<- prometheus.Metric) {
-	wtConcurrentTransactionsOut.WithLabelValues("read").Set(stats.Read.Out)
-	wtConcurrentTransactionsOut.WithLabelValues("write").Set(stats.Write.Out)
-	wtConcurrentTransactionsAvailable.WithLabelValues("read").Set(stats.Read.Available)
-	wtConcurrentTransactionsAvailable.WithLabelValues("write").Set(stats.Write.Available)
-	wtConcurrentTransactionsTotalTickets.WithLabelValues("read").Set(stats.Read.TotalTickets)
-	wtConcurrentTransactionsTotalTickets.WithLabelValues("write").Set(stats.Write.TotalTickets)
+type WTConcurrentTransactionsStats struct {
+	Read  *WTConcurrentTransactionsTypeStats `bson:"read"`
+	Write *WTConcurrentTransactionsTypeStats `bson:"write"`
 }
 
-func (stats *WTConcurrentTransactionsStats) Describe(ch chan<- *prometheus.Desc) {
-	wtConcurrentTransactionsOut.Describe(ch)
-	wtConcurrentTransactionsAvailable.Describe(ch)
-	wtConcurrentTransactionsTotalTickets.Describe(ch)
+func (stats *WTConcurrentTransactionsStats) Export(ch *shared.MetricChannel) {
+	for _, tickets := range []struct {
+		name  string
+		stats *WTConcurrentTransactionsTypeStats
+	}{{"read", stats.Read}, {"write", stats.Write}} {
+		if tickets.stats == nil {
+			continue
+		}
+		ch.Send(wtConcurrentTransactionsOut, tickets.stats.Out, tickets.name)
+		ch.Send(wtConcurrentTransactionsAvailable, tickets.stats.Available, tickets.name)
+		ch.Send(wtConcurrentTransactionsTotalTickets, tickets.stats.TotalTickets, tickets.name)
+		if ratio, ok := tickets.stats.Utilization(); ok {
+			ch.Send(wtConcurrentTransactionsUtilization, ratio, tickets.name)
+		}
+	}
 }
 
 // WiredTiger stats
@@ -350,28 +311,7 @@ type WiredTigerStats struct {
 	ConcurrentTransactions *WTConcurrentTransactionsStats `bson:"concurrentTransactions"`
 }
 
-func (stats *WiredTigerStats) Describe(ch chan<- *prometheus.Desc) {
-	if stats.BlockManager != nil {
-		stats.BlockManager.Describe(ch)
-	}
-	if stats.Cache != nil {
-		stats.Cache.Describe(ch)
-	}
-	if stats.Transaction != nil {
-		stats.Transaction.Describe(ch)
-	}
-	if stats.Log != nil {
-		stats.Log.Describe(ch)
-	}
-	if stats.Session != nil {
-		stats.Session.Describe(ch)
-	}
-	if stats.ConcurrentTransactions != nil {
-		stats.ConcurrentTransactions.Describe(ch)
-	}
-}
-
-func (stats *WiredTigerStats) Export(ch chan<- prometheus.Metric) {
+func (stats *WiredTigerStats) Export(ch *shared.MetricChannel) {
 	if stats.BlockManager != nil {
 		stats.BlockManager.Export(ch)
 	}
@@ -390,32 +330,4 @@ func (stats *WiredTigerStats) Export(ch chan<- prometheus.Metric) {
 	if stats.ConcurrentTransactions != nil {
 		stats.ConcurrentTransactions.Export(ch)
 	}
-
-	wtBlockManagerBlocksTotal.Collect(ch)
-	wtBlockManagerBytesTotal.Collect(ch)
-
-	wtCachePagesTotal.Collect(ch)
-	wtCacheBytesTotal.Collect(ch)
-	wtCacheEvictedTotal.Collect(ch)
-	wtCachePages.Collect(ch)
-	wtCacheBytes.Collect(ch)
-	wtCacheMaxBytes.Collect(ch)
-	wtCachePercentOverhead.Collect(ch)
-
-	wtTransactionsTotal.Collect(ch)
-	wtTransactionsTotalCheckpointMs.Collect(ch)
-	wtTransactionsCheckpointMs.Collect(ch)
-	wtTransactionsCheckpointsRunning.Collect(ch)
-
-	wtLogRecordsTotal.Collect(ch)
-	wtLogBytesTotal.Collect(ch)
-	wtLogOperationsTotal.Collect(ch)
-	wtLogRecordsScannedTotal.Collect(ch)
-
-	wtOpenCursors.Collect(ch)
-	wtOpenSessions.Collect(ch)
-
-	wtConcurrentTransactionsOut.Collect(ch)
-	wtConcurrentTransactionsAvailable.Collect(ch)
-	wtConcurrentTransactionsTotalTickets.Collect(ch)
 }
@@ -0,0 +1,66 @@
+package collector_mongod
+
+import (
+	"testing"
+
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
+)
+
+func Test_ExportConcurrentTransactions(t *testing.T) {
+	serverStatus := &ServerStatus{}
+	loadServerStatusFromBson(LoadFixture("server_status_wiredtiger.bson"), serverStatus)
+	if serverStatus.WiredTiger == nil || serverStatus.WiredTiger.ConcurrentTransactions == nil {
+		t.Fatal("WiredTiger concurrentTransactions were not loaded")
+	}
+
+	values, err := sessiontest.GatherExport(serverStatus.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessiontest.ExpectValues(t, values, map[string]float64{
+		`mongodb_mongod_wiredtiger_concurrent_transactions_out_tickets{type="read"}`:        32,
+		`mongodb_mongod_wiredtiger_concurrent_transactions_out_tickets{type="write"}`:       3,
+		`mongodb_mongod_wiredtiger_concurrent_transactions_available_tickets{type="read"}`:  96,
+		`mongodb_mongod_wiredtiger_concurrent_transactions_available_tickets{type="write"}`: 125,
+		`mongodb_mongod_wiredtiger_concurrent_transactions_total_tickets{type="read"}`:      128,
+		`mongodb_mongod_wiredtiger_concurrent_transactions_total_tickets{type="write"}`:     128,
+		`mongodb_mongod_wiredtiger_concurrent_transactions_utilization_ratio{type="read"}`:  0.25,
+		`mongodb_mongod_wiredtiger_concurrent_transactions_utilization_ratio{type="write"}`: 3.0 / 128,
+	})
+}
+
+func Test_ExportExecutionQueues(t *testing.T) {
+	serverStatus := &ServerStatus{}
+	loadServerStatusFromBson(LoadFixture("server_status_queues.bson"), serverStatus)
+	if serverStatus.Queues == nil || serverStatus.Queues.Execution == nil {
+		t.Fatal("Execution queues were not loaded")
+	}
+
+	values, err := sessiontest.GatherExport(serverStatus.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessiontest.ExpectValues(t, values, map[string]float64{
+		`mongodb_mongod_queues_execution_out_tickets{type="read"}`:                                          6,
+		`mongodb_mongod_queues_execution_out_tickets{type="write"}`:                                         2,
+		`mongodb_mongod_queues_execution_available_tickets{type="write"}`:                                   6,
+		`mongodb_mongod_queues_execution_total_tickets{type="read"}`:                                        12,
+		`mongodb_mongod_queues_execution_utilization_ratio{type="read"}`:                                    0.5,
+		`mongodb_mongod_queues_execution_utilization_ratio{type="write"}`:                                   0.25,
+		`mongodb_mongod_queues_execution_queue_length{priority="normal",type="write"}`:                      5,
+		`mongodb_mongod_queues_execution_processing{priority="normal",type="read"}`:                         6,
+		`mongodb_mongod_queues_execution_added_to_queue_total{priority="exempt",type="write"}`:              10,
+		`mongodb_mongod_queues_execution_started_processing_total{priority="normal",type="read"}`:           20006,
+		`mongodb_mongod_queues_execution_canceled_total{priority="normal",type="write"}`:                    1,
+		`mongodb_mongod_queues_execution_time_queued_microseconds_total{priority="normal",type="write"}`:    42000,
+		`mongodb_mongod_queues_execution_time_processing_microseconds_total{priority="normal",type="read"}`: 2400000,
+		`mongodb_mongod_queues_execution_concurrency_adjustments_total{direction="increase"}`:               52,
+		`mongodb_mongod_queues_execution_concurrency_adjusted_tickets_total{direction="decrease"}`:          160,
+	})
+	if _, ok := values[`mongodb_mongod_queues_execution_queue_length{priority="low",type="read"}`]; ok {
+		t.Error("the priorities the server does not report should not be exported")
+	}
+	if _, ok := values[`mongodb_mongod_wiredtiger_concurrent_transactions_out_tickets{type="read"}`]; ok {
+		t.Error("the WiredTiger tickets should not be exported without wiredTiger.concurrentTransactions")
+	}
+}
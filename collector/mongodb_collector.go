@@ -1,12 +1,17 @@
 package collector
 
 import (
-	"github.com/golang/glog"
+	"crypto/tls"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
 	"github.com/elarasu/mongodb_exporter/collector/mongod"
 	"github.com/elarasu/mongodb_exporter/collector/mongos"
 	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
-	"gopkg.in/mgo.v2"
 )
 
 var (
@@ -14,104 +19,457 @@ var (
 	Namespace = "mongodb"
 )
 
+// Namespaces returns the namespaces of the metrics of the collectors, the most specific first.
+func Namespaces() []string {
+	return []string{collector_mongod.Namespace, collector_mongos.Namespace, Namespace}
+}
+
+var (
+	up = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "up",
+	}, nil)
+	versionInfo = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "version_info",
+	}, []string{"version", "git_version", "storage_engine", "node_type"})
+	capabilityInfo = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "capability_info",
+	}, []string{"capability", "supported", "reason"})
+	lastScrapeDurationSeconds = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "exporter",
+		Name:      "last_scrape_duration_seconds",
+	}, nil)
+	scrapesTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "exporter",
+		Name:      "scrapes_total",
+	}, nil)
+	lastScrapeError = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "exporter",
+		Name:      "last_scrape_error",
+	}, nil)
+	collectorSuccess = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "exporter",
+		Name:      "collector_success",
+	}, []string{"collector"})
+	collectorDurationSeconds = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "exporter",
+		Name:      "collector_duration_seconds",
+	}, []string{"collector"})
+)
+
+var errSubCollectorFailed = errors.New("one or more sub-collectors failed")
+
+// serverInfo describes the scraped server, it is exported as mongodb_version_info.
+type serverInfo struct {
+	version, gitVersion, storageEngine, nodeType string
+	// capabilities decide the sub-collectors and serverStatus fields the server supports, they are
+	// exported as mongodb_capability_info
+	capabilities *shared.ServerCapabilities
+}
+
+// Export exports the server info to prometheus.
+func (info *serverInfo) Export(ch *shared.MetricChannel) {
+	ch.Send(versionInfo, 1, info.version, info.gitVersion, info.storageEngine, info.nodeType)
+	if info.capabilities == nil {
+		return
+	}
+	for _, decision := range info.capabilities.Decisions() {
+		ch.Send(capabilityInfo, 1, decision.Name, strconv.FormatBool(decision.Supported), decision.Reason)
+	}
+}
+
 // MongodbCollectorOpts is the options of the mongodb collector.
 type MongodbCollectorOpts struct {
-	URI string
+	URI       string
+	TLSConfig *tls.Config
+	// ConnectTimeout and SyncTimeout are the dial and command timeouts (0 = default).
+	ConnectTimeout time.Duration
+	SyncTimeout    time.Duration
+	// Driver is the MongoDB driver, shared.DriverMgo or shared.DriverOfficial (empty = mgo).
+	Driver string
+	// ConstLabels are added to every metric of the collector, e.g. the address of the host when
+	// several collectors are registered.
+	ConstLabels prometheus.Labels
+
+	// NamespaceFilter selects the collections of the per-collection collectors.
+	NamespaceFilter *shared.NamespaceFilter
+	// CollectionLimit caps the number of collections of the per-collection collectors (0 = no limit).
+	CollectionLimit int
+
+	// CurrentOpFilter selects the namespaces of the operations of the currentOp collector.
+	CurrentOpFilter *shared.NamespaceFilter
+	// CurrentOpMinSecsRunning is the time an operation must have been running for to be collected by the currentOp collector.
+	CurrentOpMinSecsRunning int
+
+	// ProfileDatabases are the databases whose profiler entries are read (empty = all databases).
+	ProfileDatabases []string
+
+	// OplogScanLimit caps the number of oplog changes read per scrape to compute the oplog rates
+	// (0 = collector_mongod.DefaultOplogScanLimit).
+	OplogScanLimit int
+
+	// ShardFanOut makes a mongos collector also collect the server and replica set status of every shard.
+	ShardFanOut bool
+
+	// DynamicServerStatus exports the numeric serverStatus fields the other metrics do not cover (nil = disabled).
+	DynamicServerStatus *shared.StatusWalkerOpts
 }
 
 // MongodbCollector is in charge of collecting mongodb's metrics.
 type MongodbCollector struct {
 	Opts MongodbCollectorOpts
+
+	descs        *shared.Descs
+	sessions     *sessionPool
+	oplogTracker *collector_mongod.OplogRateTracker
+	// profileTracker follows the system.profile collections across scrapes
+	profileTracker *collector_mongod.ProfileTracker
+	// shardingActivity follows the sharding logs of a mongos across scrapes
+	shardingActivity *collector_mongos.ShardingActivityTracker
+	shards           shardCollectors
+	// storageEngine is the storage engine of the last scrape, which decides the serverStatus fields to get
+	storageEngine string
+	// mongodStatus and mongosStatus export the dynamic serverStatus metrics, nil when disabled
+	mongodStatus *shared.StatusWalker
+	mongosStatus *shared.StatusWalker
+
+	mu      sync.Mutex
+	scrapes float64
 }
 
 // NewMongodbCollector returns a new instance of a MongodbCollector.
 func NewMongodbCollector(opts MongodbCollectorOpts) *MongodbCollector {
 	exporter := &MongodbCollector{
-		Opts: opts,
+		Opts:  opts,
+		descs: shared.NewDescs(opts.ConstLabels),
+		sessions: newSessionPool(shared.MongoSessionOpts{
+			URI:            opts.URI,
+			TLSConfig:      opts.TLSConfig,
+			ConnectTimeout: opts.ConnectTimeout,
+			SyncTimeout:    opts.SyncTimeout,
+			Driver:         opts.Driver,
+		}),
+		oplogTracker:     &collector_mongod.OplogRateTracker{ScanLimit: opts.OplogScanLimit},
+		profileTracker:   collector_mongod.NewProfileTracker(),
+		shardingActivity: collector_mongos.NewShardingActivityTracker(),
+		mongodStatus:     newStatusWalker(opts, collector_mongod.ServerStatus{}),
+		mongosStatus:     newStatusWalker(opts, collector_mongos.ServerStatus{}),
 	}
 
 	return exporter
 }
 
+// newStatusWalker returns the walker of the dynamic serverStatus metrics, skipping the fields of
+// status, or nil when they are disabled.
+func newStatusWalker(opts MongodbCollectorOpts, status interface{}) *shared.StatusWalker {
+	if opts.DynamicServerStatus == nil {
+		return nil
+	}
+	return shared.NewStatusWalker(*opts.DynamicServerStatus, status)
+}
+
 // Describe describes all mongodb's metrics.
 func (exporter *MongodbCollector) Describe(ch chan<- *prometheus.Desc) {
-	glog.Info("Describing groups")
-	session := shared.MongoSession(exporter.Opts.URI)
-	if session != nil {
-		serverStatus := collector_mongos.GetServerStatus(session)
-		if serverStatus != nil {
-			serverStatus.Describe(ch)
-		}
-		session.Close()
-	}
+	exporter.descs.Describe(ch)
 }
 
 // Collect collects all mongodb's metrics.
 func (exporter *MongodbCollector) Collect(ch chan<- prometheus.Metric) {
-	mongoSess := shared.MongoSession(exporter.Opts.URI)
-	if mongoSess != nil {
-		defer mongoSess.Close()
-		serverVersion, err := shared.MongoSessionServerVersion(mongoSess)
-		if err != nil {
-			glog.Errorf("Problem gathering the mongo server version: %s", err)
-		}
+	metrics := exporter.descs.Channel(ch)
+	start := time.Now()
 
-		nodeType, err := shared.MongoSessionNodeType(mongoSess)
-		if err != nil {
-			glog.Errorf("Problem gathering the mongo node type: %s", err)
-		}
+	err := exporter.scrape(metrics)
 
-		glog.Infof("Connected to: %s (node type: %s, server version: %s)", shared.RedactMongoUri(exporter.Opts.URI), nodeType, serverVersion)
-		switch {
-		case nodeType == "mongos":
-			exporter.collectMongos(mongoSess, ch)
-		case nodeType == "mongod":
-			exporter.collectMongod(mongoSess, ch)
-		case nodeType == "replset":
-			exporter.collectMongodReplSet(mongoSess, ch)
-		default:
-			glog.Infof("Unrecognized node type %s!", nodeType)
-		}
+	exporter.mu.Lock()
+	exporter.scrapes++
+	scrapes := exporter.scrapes
+	exporter.mu.Unlock()
+
+	metrics.Send(scrapesTotal, scrapes)
+	metrics.Send(lastScrapeDurationSeconds, time.Since(start).Seconds())
+	if err != nil {
+		glog.Errorf("Error scraping %s: %s", shared.RedactMongoUri(exporter.Opts.URI), err)
+		metrics.Send(lastScrapeError, 1)
+	} else {
+		metrics.Send(lastScrapeError, 0)
+	}
+	exporter.sessions.Export(metrics)
+}
+
+// Close closes the long-lived MongoDB sessions of the collector.
+func (exporter *MongodbCollector) Close() {
+	exporter.sessions.Close()
+	exporter.shards.Close()
+}
+
+func (exporter *MongodbCollector) scrape(ch *shared.MetricChannel) error {
+	mongoSess := exporter.sessions.Get()
+	if mongoSess == nil {
+		ch.Send(up, 0)
+		return errors.New("cannot connect to MongoDB")
 	}
+	defer mongoSess.Close()
+	ch.Send(up, 1)
+
+	info := &serverInfo{version: "unknown", gitVersion: "unknown"}
+	buildInfo, err := shared.MongoSessionBuildInfo(mongoSess)
+	if err != nil {
+		glog.Errorf("Problem gathering the mongo server version: %s", err)
+	} else {
+		info.version, info.gitVersion = buildInfo.Version, buildInfo.GitVersion
+	}
+
+	exporter.mu.Lock()
+	info.capabilities = shared.NewServerCapabilities(info.version, exporter.storageEngine)
+	exporter.mu.Unlock()
+
+	nodeType, err := shared.MongoSessionNodeType(mongoSess, info.capabilities)
+	if err != nil {
+		glog.Errorf("Problem gathering the mongo node type: %s", err)
+		return err
+	}
+	info.nodeType = nodeType
+
+	glog.Infof("Connected to: %s (node type: %s, server version: %s)", shared.RedactMongoUri(exporter.Opts.URI), nodeType, info.version)
+	var ok bool
+	switch {
+	case nodeType == "mongos":
+		ok = exporter.collectMongos(mongoSess, ch, info)
+	case nodeType == "mongod":
+		ok = exporter.collectMongod(mongoSess, ch, info)
+	case nodeType == "replset":
+		ok = exporter.collectMongodReplSet(mongoSess, ch, info)
+	default:
+		glog.Infof("Unrecognized node type %s!", nodeType)
+		ok = true
+	}
+	info.Export(ch)
+	if info.storageEngine != "" {
+		exporter.mu.Lock()
+		exporter.storageEngine = info.storageEngine
+		exporter.mu.Unlock()
+	}
+	if !ok {
+		return errSubCollectorFailed
+	}
+	return nil
 }
 
-func (exporter *MongodbCollector) collectMongos(session *mgo.Session, ch chan<- prometheus.Metric) {
+// collectWith runs a sub-collector, records its success and duration, and returns whether it succeeded.
+func collectWith(ch *shared.MetricChannel, name string, collect func() bool) bool {
+	start := time.Now()
+	ok := collect()
+	ch.Send(collectorDurationSeconds, time.Since(start).Seconds(), name)
+	if ok {
+		ch.Send(collectorSuccess, 1, name)
+	} else {
+		ch.Send(collectorSuccess, 0, name)
+	}
+	return ok
+}
+
+func (exporter *MongodbCollector) collectMongos(session shared.Session, ch *shared.MetricChannel, info *serverInfo) bool {
 	// read from primaries only when using mongos to avoid SERVER-27864
-	session.SetMode(mgo.Strong, true)
+	session.SetMode(shared.Primary)
 
-	glog.Info("Collecting Server Status")
-	serverStatus := collector_mongos.GetServerStatus(session)
-	if serverStatus != nil {
+	ok := collectWith(ch, "serverStatus", func() bool {
+		glog.Info("Collecting Server Status")
+		serverStatus := collector_mongos.GetServerStatus(session)
+		if serverStatus == nil {
+			return false
+		}
 		serverStatus.Export(ch)
+		if exporter.mongosStatus != nil {
+			exporter.mongosStatus.Export(ch, serverStatus.Raw)
+		}
+		return true
+	})
+
+	if shared.IsGroupEnabled("sharding") {
+		ok = collectWith(ch, "sharding", func() bool {
+			glog.Info("Collecting Sharding Status")
+			shardingStatus := collector_mongos.GetShardingStatus(session)
+			if shardingStatus == nil {
+				return false
+			}
+			shardingStatus.Export(ch)
+			return true
+		}) && ok
+
+		ok = collectWith(ch, "shardingChunks", func() bool {
+			glog.Info("Collecting Sharding Chunk Distribution")
+			chunkStats := collector_mongos.GetShardingChunkStats(session, info.capabilities, exporter.Opts.NamespaceFilter, exporter.Opts.CollectionLimit)
+			if chunkStats == nil {
+				return false
+			}
+			chunkStats.Export(ch)
+			return true
+		}) && ok
+
+		ok = collectWith(ch, "shardingActivity", func() bool {
+			glog.Info("Collecting Sharding Activity")
+			err := exporter.shardingActivity.Update(session, exporter.Opts.NamespaceFilter)
+			if err != nil {
+				glog.Errorf("Failed to update the sharding activity: %s", err)
+			}
+			exporter.shardingActivity.Export(ch)
+			return err == nil
+		}) && ok
+	}
+
+	if exporter.Opts.ShardFanOut {
+		ok = collectWith(ch, "shards", func() bool {
+			return exporter.collectShards(session, ch)
+		}) && ok
+	}
+
+	return ok
+}
+
+// collectShards collects the metrics of every shard of the cluster in parallel.
+func (exporter *MongodbCollector) collectShards(session shared.Session, ch *shared.MetricChannel) bool {
+	glog.Info("Collecting Shards")
+	var shards []collector_mongos.ShardingTopoShardInfo
+	if err := session.Find("config", "shards", nil).All(&shards); err != nil {
+		glog.Errorf("Failed to get the shards: %s", err)
+		return false
 	}
 
-	glog.Info("Collecting Sharding Status")
-	shardingStatus := collector_mongos.GetShardingStatus(session)
-	if shardingStatus != nil {
-		shardingStatus.Export(ch)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ok := true
+	for _, shard := range exporter.shards.update(shards, exporter.Opts) {
+		wg.Add(1)
+		go func(shard *shardCollector) {
+			defer wg.Done()
+			shardOk := shard.collect(ch)
+			mu.Lock()
+			ok = ok && shardOk
+			mu.Unlock()
+		}(shard)
 	}
+	wg.Wait()
+	return ok
 }
 
-func (exporter *MongodbCollector) collectMongod(session *mgo.Session, ch chan<- prometheus.Metric) {
-	glog.Info("Collecting Server Status")
-	serverStatus := collector_mongod.GetServerStatus(session)
-	if serverStatus != nil {
+// collectMongod collects the metrics of a mongod, and fills the storage engine of info.
+func (exporter *MongodbCollector) collectMongod(session shared.Session, ch *shared.MetricChannel, info *serverInfo) bool {
+	ok := collectWith(ch, "serverStatus", func() bool {
+		glog.Info("Collecting Server Status")
+		serverStatus := collector_mongod.GetServerStatus(session, info.capabilities)
+		if serverStatus == nil {
+			return false
+		}
 		serverStatus.Export(ch)
+		if exporter.mongodStatus != nil {
+			exporter.mongodStatus.Export(ch, serverStatus.Raw)
+		}
+		// Export fills the storage engine of the servers which do not report it
+		if serverStatus.StorageEngine != nil {
+			info.storageEngine = serverStatus.StorageEngine.Name
+		}
+		return true
+	})
+
+	if shared.IsGroupEnabled("db_stats") {
+		ok = collectWith(ch, "dbStats", func() bool {
+			glog.Info("Collecting Database Stats")
+			databaseStats := collector_mongod.GetDatabaseStats(session)
+			if databaseStats == nil {
+				return false
+			}
+			databaseStats.Export(ch)
+			return true
+		}) && ok
 	}
+
+	if shared.IsGroupEnabled("coll_stats") {
+		ok = collectWith(ch, "collStats", func() bool {
+			glog.Info("Collecting Collection Stats")
+			collectionStats := collector_mongod.GetCollectionStats(session, exporter.Opts.NamespaceFilter, exporter.Opts.CollectionLimit)
+			if collectionStats == nil {
+				return false
+			}
+			collectionStats.Export(ch)
+			return true
+		}) && ok
+	}
+
+	if shared.IsGroupEnabled("index_stats") && info.capabilities.Supports("indexStats") {
+		ok = collectWith(ch, "indexStats", func() bool {
+			glog.Info("Collecting Index Stats")
+			indexStats := collector_mongod.GetIndexStats(session, exporter.Opts.NamespaceFilter, exporter.Opts.CollectionLimit)
+			if indexStats == nil {
+				return false
+			}
+			indexStats.Export(ch)
+			return true
+		}) && ok
+	}
+
+	if shared.IsGroupEnabled("current_op") && info.capabilities.Supports("currentOp") {
+		ok = collectWith(ch, "currentOp", func() bool {
+			glog.Info("Collecting Current Operations")
+			currentOpStatus := collector_mongod.GetCurrentOpStatus(session, exporter.Opts.CurrentOpFilter, exporter.Opts.CurrentOpMinSecsRunning)
+			if currentOpStatus == nil {
+				return false
+			}
+			currentOpStatus.Export(ch)
+			return true
+		}) && ok
+	}
+
+	if shared.IsGroupEnabled("profile") {
+		ok = collectWith(ch, "profile", func() bool {
+			glog.Info("Collecting Profiler Entries")
+			databases, err := collector_mongod.ProfiledDatabases(session, exporter.Opts.ProfileDatabases)
+			if err == nil {
+				err = exporter.profileTracker.Update(session, databases, exporter.Opts.NamespaceFilter)
+			}
+			if err != nil {
+				glog.Errorf("Failed to read the profiler entries: %s", err)
+			}
+			exporter.profileTracker.Export(ch)
+			return err == nil
+		}) && ok
+	}
+
+	return ok
 }
 
-func (exporter *MongodbCollector) collectMongodReplSet(session *mgo.Session, ch chan<- prometheus.Metric) {
-	exporter.collectMongod(session, ch)
+func (exporter *MongodbCollector) collectMongodReplSet(session shared.Session, ch *shared.MetricChannel, info *serverInfo) bool {
+	ok := exporter.collectMongod(session, ch, info)
 
-	glog.Info("Collecting Replset Status")
-	replSetStatus := collector_mongod.GetReplSetStatus(session)
-	if replSetStatus != nil {
-		replSetStatus.Export(ch)
+	if shared.IsGroupEnabled("replset") {
+		ok = collectWith(ch, "replSetGetStatus", func() bool {
+			glog.Info("Collecting Replset Status")
+			replSetStatus := collector_mongod.GetReplSetStatus(session)
+			if replSetStatus == nil {
+				return false
+			}
+			replSetStatus.Export(ch)
+			return true
+		}) && ok
 	}
 
-	glog.Info("Collecting Replset Oplog Status")
-	oplogStatus := collector_mongod.GetOplogStatus(session)
-	if oplogStatus != nil {
-		oplogStatus.Export(ch)
+	if shared.IsGroupEnabled("oplog") {
+		ok = collectWith(ch, "oplog", func() bool {
+			glog.Info("Collecting Replset Oplog Status")
+			oplogStatus := collector_mongod.GetOplogStatus(session, exporter.oplogTracker)
+			if oplogStatus == nil {
+				return false
+			}
+			oplogStatus.Export(ch)
+			return true
+		}) && ok
 	}
+
+	return ok
 }
@@ -1,10 +1,14 @@
 package collector
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"gopkg.in/mgo.v2/bson"
 )
 
 func Test_CollectServerStatus(t *testing.T) {
@@ -26,3 +30,112 @@ func Test_CollectCollector(t *testing.T) {
 	ch := make(chan prometheus.Metric)
 	go collector.Collect(ch)
 }
+
+func Test_CollectUnreachable(t *testing.T) {
+	collector := NewMongodbCollector(MongodbCollectorOpts{URI: "mongodb://localhost:27017/?unknownOption=1"})
+
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
+	close(ch)
+
+	values := map[string]float64{}
+	for metric := range ch {
+		m := &dto.Metric{}
+		metric.Write(m)
+		if m.Gauge != nil {
+			values[metric.Desc().String()] = m.Gauge.GetValue()
+		}
+	}
+	if values[collector.descs.Desc(up).String()] != 0 {
+		t.Error("mongodb_up should be 0 when the server cannot be reached")
+	}
+	if values[collector.descs.Desc(lastScrapeError).String()] != 1 {
+		t.Error("mongodb_exporter_last_scrape_error should be 1 when the server cannot be reached")
+	}
+}
+
+func Test_RegisterCollectors(t *testing.T) {
+	// every collector instance owns its descriptors, so several can be registered side by side
+	for i := 0; i < 2; i++ {
+		registry := prometheus.NewRegistry()
+		collector := NewMongodbCollector(MongodbCollectorOpts{URI: "mongodb://localhost:27017/?unknownOption=1"})
+		if err := registry.Register(collector); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := registry.Gather(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_ServerInfoExport(t *testing.T) {
+	descs := shared.NewDescs(nil)
+	ch := make(chan prometheus.Metric, 1)
+	info := &serverInfo{version: "4.4.6", gitVersion: "72e66213c2c3eab37d9358d5e78ad7f5c1d0d0d7", storageEngine: "wiredTiger", nodeType: "replset"}
+	info.Export(descs.Channel(ch))
+	close(ch)
+
+	m := &dto.Metric{}
+	(<-ch).Write(m)
+	labels := map[string]string{}
+	for _, label := range m.Label {
+		labels[label.GetName()] = label.GetValue()
+	}
+	if m.Gauge.GetValue() != 1 || labels["version"] != "4.4.6" || labels["storage_engine"] != "wiredTiger" || labels["node_type"] != "replset" {
+		t.Errorf("unexpected version info %v", m)
+	}
+}
+
+func Test_ServerInfoExportCapabilities(t *testing.T) {
+	descs := shared.NewDescs(nil)
+	decisions := shared.NewServerCapabilities("3.0.15", "mmapv1").Decisions()
+	ch := make(chan prometheus.Metric, len(decisions)+1)
+	info := &serverInfo{version: "3.0.15", storageEngine: "mmapv1", nodeType: "mongod", capabilities: shared.NewServerCapabilities("3.0.15", "mmapv1")}
+	info.Export(descs.Channel(ch))
+	close(ch)
+
+	<-ch // version info
+	supported := map[string]string{}
+	for metric := range ch {
+		m := &dto.Metric{}
+		metric.Write(m)
+		labels := map[string]string{}
+		for _, label := range m.Label {
+			labels[label.GetName()] = label.GetValue()
+		}
+		supported[labels["capability"]] = labels["supported"]
+	}
+	if len(supported) != len(decisions) {
+		t.Fatalf("expected %d capabilities, got %v", len(decisions), supported)
+	}
+	if supported["indexStats"] != "false" || supported["currentOp"] != "false" {
+		t.Errorf("3.0 should not support indexStats and currentOp: %v", supported)
+	}
+}
+
+func Test_MongoSessionNodeType(t *testing.T) {
+	for _, test := range []struct {
+		version  string
+		reply    bson.M
+		command  string
+		runs     []string
+		nodeType string
+	}{
+		{version: "6.0.4", reply: bson.M{"setName": "rs0", "hosts": []string{"db1:27017"}}, command: "admin.hello", runs: []string{"admin.hello"}, nodeType: "replset"},
+		{version: "4.4.2", reply: bson.M{"msg": "isdbgrid"}, command: "admin.hello", runs: []string{"admin.hello"}, nodeType: "mongos"},
+		{version: "4.4.1", reply: bson.M{"msg": "isdbgrid"}, command: "admin.isMaster", runs: []string{"admin.isMaster"}, nodeType: "mongos"},
+		{version: "3.6.8", reply: bson.M{"ismaster": true}, command: "admin.isMaster", runs: []string{"admin.isMaster"}, nodeType: "mongod"},
+		// buildInfo failed, the server may predate hello
+		{version: "unknown", reply: bson.M{"ismaster": true}, command: "admin.isMaster", runs: []string{"admin.hello", "admin.isMaster"}, nodeType: "mongod"},
+	} {
+		session := &sessiontest.Session{Commands: map[string]interface{}{test.command: test.reply}}
+		nodeType, err := shared.MongoSessionNodeType(session, shared.NewServerCapabilities(test.version, ""))
+		if err != nil {
+			t.Errorf("%s: %s", test.version, err)
+			continue
+		}
+		if nodeType != test.nodeType || !reflect.DeepEqual(session.Runs, test.runs) {
+			t.Errorf("%s: expected %s from %v, got %s from %v", test.version, test.nodeType, test.runs, nodeType, session.Runs)
+		}
+	}
+}
@@ -1,14 +1,14 @@
 package collector_mongos
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	assertsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	assertsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "asserts_total",
-		Help:      "The asserts document reports the number of asserts on the database. While assert errors are typically uncommon, if there are non-zero values for the asserts, you should check the log file for the mongod process for more information. In many cases these errors are trivial, but are worth investigating.",
 	}, []string{"type"})
 )
 
@@ -22,16 +22,10 @@ type AssertsStats struct {
 }
 
 // Export exports the metrics to prometheus.
-func (asserts *AssertsStats) Export(ch chan<- prometheus.Metric) {
-	assertsTotal.WithLabelValues("regular").Add(asserts.Regular)
-	assertsTotal.WithLabelValues("warning").Add(asserts.Warning)
-	assertsTotal.WithLabelValues("msg").Add(asserts.Msg)
-	assertsTotal.WithLabelValues("user").Add(asserts.User)
-	assertsTotal.WithLabelValues("rollovers").Add(asserts.Rollovers)
-	assertsTotal.Collect(ch)
-}
-
-// Describe describes the metrics for prometheus
-func (asserts *AssertsStats) Describe(ch chan<- *prometheus.Desc) {
-	assertsTotal.Describe(ch)
+func (asserts *AssertsStats) Export(ch *shared.MetricChannel) {
+	ch.Send(assertsTotal, asserts.Regular, "regular")
+	ch.Send(assertsTotal, asserts.Warning, "warning")
+	ch.Send(assertsTotal, asserts.Msg, "msg")
+	ch.Send(assertsTotal, asserts.User, "user")
+	ch.Send(assertsTotal, asserts.Rollovers, "rollovers")
 }
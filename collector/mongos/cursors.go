@@ -1,14 +1,14 @@
 package collector_mongos
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	cursorsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	cursorsGauge = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Name:      "cursors",
-		Help:      "The cursors data structure contains data regarding cursor state and use",
 	}, []string{"state"})
 )
 
@@ -21,15 +21,9 @@ type Cursors struct {
 }
 
 // Export exports the data to prometheus.
-func (cursors *Cursors) Export(ch chan<- prometheus.Metric) {
-	cursorsGauge.WithLabelValues("total_open").Set(cursors.TotalOpen)
-	cursorsGauge.WithLabelValues("timed_out").Set(cursors.TimeOut)
-	cursorsGauge.WithLabelValues("total_no_timeout").Set(cursors.TotalNoTimeout)
-	cursorsGauge.WithLabelValues("pinned").Set(cursors.Pinned)
-	cursorsGauge.Collect(ch)
-}
-
-// Describe describes the metrics for prometheus
-func (cursors *Cursors) Describe(ch chan<- *prometheus.Desc) {
-	cursorsGauge.Describe(ch)
+func (cursors *Cursors) Export(ch *shared.MetricChannel) {
+	ch.Send(cursorsGauge, cursors.TotalOpen, "total_open")
+	ch.Send(cursorsGauge, cursors.TimeOut, "timed_out")
+	ch.Send(cursorsGauge, cursors.TotalNoTimeout, "total_no_timeout")
+	ch.Send(cursorsGauge, cursors.Pinned, "pinned")
 }
@@ -1,22 +1,21 @@
 package collector_mongos
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	extraInfopageFaultsTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+	extraInfopageFaultsTotal = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "extra_info",
 		Name:      "page_faults_total",
-		Help:      "The page_faults Reports the total number of page faults that require disk operations. Page faults refer to operations that require the database server to access data which isn’t available in active memory. The page_faults counter may increase dramatically during moments of poor performance and may correlate with limited memory environments and larger data sets. Limited and sporadic page faults do not necessarily indicate an issue",
-	})
-	extraInfoheapUsageBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+	}, nil)
+	extraInfoheapUsageBytes = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Subsystem: "extra_info",
 		Name:      "heap_usage_bytes",
-		Help:      "The heap_usage_bytes field is only available on Unix/Linux systems, and reports the total size in bytes of heap space used by the database process",
-	})
+	}, nil)
 )
 
 // ExtraInfo has extra info metrics
@@ -26,17 +25,7 @@ type ExtraInfo struct {
 }
 
 // Export exports the metrics to prometheus.
-func (extraInfo *ExtraInfo) Export(ch chan<- prometheus.Metric) {
-	extraInfoheapUsageBytes.Set(extraInfo.HeapUsageBytes)
-	extraInfopageFaultsTotal.Set(extraInfo.PageFaults)
-
-	extraInfoheapUsageBytes.Collect(ch)
-	extraInfopageFaultsTotal.Collect(ch)
-
-}
-
-// Describe describes the metrics for prometheus
-func (extraInfo *ExtraInfo) Describe(ch chan<- *prometheus.Desc) {
-	extraInfoheapUsageBytes.Describe(ch)
-	extraInfopageFaultsTotal.Describe(ch)
+func (extraInfo *ExtraInfo) Export(ch *shared.MetricChannel) {
+	ch.Send(extraInfoheapUsageBytes, extraInfo.HeapUsageBytes)
+	ch.Send(extraInfopageFaultsTotal, extraInfo.PageFaults)
 }
@@ -1,14 +1,14 @@
 package collector_mongos
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	memory = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	memory = shared.NewGaugeDesc(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Name:      "memory",
-		Help:      "The mem data structure holds information regarding the target system architecture of mongod and current memory use",
 	}, []string{"type"})
 )
 
@@ -22,15 +22,9 @@ type MemStats struct {
 }
 
 // Export exports the data to prometheus.
-func (memStats *MemStats) Export(ch chan<- prometheus.Metric) {
-	memory.WithLabelValues("resident").Set(memStats.Resident)
-	memory.WithLabelValues("virtual").Set(memStats.Virtual)
-	memory.WithLabelValues("mapped").Set(memStats.Mapped)
-	memory.WithLabelValues("mapped_with_journal").Set(memStats.MappedWithJournal)
-	memory.Collect(ch)
-}
-
-// Describe describes the metrics for prometheus
-func (memStats *MemStats) Describe(ch chan<- *prometheus.Desc) {
-	memory.Describe(ch)
+func (memStats *MemStats) Export(ch *shared.MetricChannel) {
+	ch.Send(memory, memStats.Resident, "resident")
+	ch.Send(memory, memStats.Virtual, "virtual")
+	ch.Send(memory, memStats.Mapped, "mapped")
+	ch.Send(memory, memStats.MappedWithJournal, "mapped_with_journal")
 }
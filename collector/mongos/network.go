@@ -1,26 +1,25 @@
 package collector_mongos
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	networkBytesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	networkBytesTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "network_bytes_total",
-		Help:      "The network data structure contains data regarding MongoDB’s network use",
 	}, []string{"state"})
 )
 var (
-	networkMetricsNumRequestsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	networkMetricsNumRequestsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "network_metrics",
 		Name:      "num_requests_total",
-		Help:      "The numRequests field is a counter of the total number of distinct requests that the server has received. Use this value to provide context for the bytesIn and bytesOut values to ensure that MongoDB’s network utilization is consistent with expectations and application use",
-	})
+	}, nil)
 )
 
-//NetworkStats network stats
+// NetworkStats network stats
 type NetworkStats struct {
 	BytesIn     float64 `bson:"bytesIn"`
 	BytesOut    float64 `bson:"bytesOut"`
@@ -28,18 +27,9 @@ type NetworkStats struct {
 }
 
 // Export exports the data to prometheus
-func (networkStats *NetworkStats) Export(ch chan<- prometheus.Metric) {
-	networkBytesTotal.WithLabelValues("in_bytes").Add(networkStats.BytesIn)
-	networkBytesTotal.WithLabelValues("out_bytes").Add(networkStats.BytesOut)
+func (networkStats *NetworkStats) Export(ch *shared.MetricChannel) {
+	ch.Send(networkBytesTotal, networkStats.BytesIn, "in_bytes")
+	ch.Send(networkBytesTotal, networkStats.BytesOut, "out_bytes")
 
-	networkMetricsNumRequestsTotal.Add(networkStats.NumRequests)
-
-	networkMetricsNumRequestsTotal.Collect(ch)
-	networkBytesTotal.Collect(ch)
-}
-
-// Describe describes the metrics for prometheus
-func (networkStats *NetworkStats) Describe(ch chan<- *prometheus.Desc) {
-	networkMetricsNumRequestsTotal.Describe(ch)
-	networkBytesTotal.Describe(ch)
+	ch.Send(networkMetricsNumRequestsTotal, networkStats.NumRequests)
 }
@@ -1,21 +1,20 @@
 package collector_mongos
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	opCountersTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	opCountersTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "op_counters_total",
-		Help:      "The opcounters data structure provides an overview of database operations by type and makes it possible to analyze the load on the database in more granular manner. These numbers will grow over time and in response to database use. Analyze these values over time to track database utilization",
 	}, []string{"type"})
 )
 var (
-	opCountersReplTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	opCountersReplTotal = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "op_counters_repl_total",
-		Help:      "The opcountersRepl data structure, similar to the opcounters data structure, provides an overview of database replication operations by type and makes it possible to analyze the load on the replica in more granular manner. These values only appear when the current host has replication enabled",
 	}, []string{"type"})
 )
 
@@ -30,20 +29,13 @@ type OpcountersStats struct {
 }
 
 // Export exports the data to prometheus.
-func (opCounters *OpcountersStats) Export(ch chan<- prometheus.Metric) {
-	opCountersTotal.WithLabelValues("insert").Add(opCounters.Insert)
-	opCountersTotal.WithLabelValues("query").Add(opCounters.Query)
-	opCountersTotal.WithLabelValues("update").Add(opCounters.Update)
-	opCountersTotal.WithLabelValues("delete").Add(opCounters.Delete)
-	opCountersTotal.WithLabelValues("getmore").Add(opCounters.GetMore)
-	opCountersTotal.WithLabelValues("command").Add(opCounters.Command)
-
-	opCountersTotal.Collect(ch)
-}
-
-// Describe describes the metrics for prometheus
-func (opCounters *OpcountersStats) Describe(ch chan<- *prometheus.Desc) {
-	opCountersTotal.Describe(ch)
+func (opCounters *OpcountersStats) Export(ch *shared.MetricChannel) {
+	ch.Send(opCountersTotal, opCounters.Insert, "insert")
+	ch.Send(opCountersTotal, opCounters.Query, "query")
+	ch.Send(opCountersTotal, opCounters.Update, "update")
+	ch.Send(opCountersTotal, opCounters.Delete, "delete")
+	ch.Send(opCountersTotal, opCounters.GetMore, "getmore")
+	ch.Send(opCountersTotal, opCounters.Command, "command")
 }
 
 // OpcountersReplStats opcounters stats
@@ -57,18 +49,11 @@ type OpcountersReplStats struct {
 }
 
 // Export exports the data to prometheus.
-func (opCounters *OpcountersReplStats) Export(ch chan<- prometheus.Metric) {
-	opCountersReplTotal.WithLabelValues("insert").Add(opCounters.Insert)
-	opCountersReplTotal.WithLabelValues("query").Add(opCounters.Query)
-	opCountersReplTotal.WithLabelValues("update").Add(opCounters.Update)
-	opCountersReplTotal.WithLabelValues("delete").Add(opCounters.Delete)
-	opCountersReplTotal.WithLabelValues("getmore").Add(opCounters.GetMore)
-	opCountersReplTotal.WithLabelValues("command").Add(opCounters.Command)
-
-	opCountersReplTotal.Collect(ch)
-}
-
-// Describe describes the metrics for prometheus
-func (opCounters *OpcountersReplStats) Describe(ch chan<- *prometheus.Desc) {
-	opCountersReplTotal.Describe(ch)
+func (opCounters *OpcountersReplStats) Export(ch *shared.MetricChannel) {
+	ch.Send(opCountersReplTotal, opCounters.Insert, "insert")
+	ch.Send(opCountersReplTotal, opCounters.Query, "query")
+	ch.Send(opCountersReplTotal, opCounters.Update, "update")
+	ch.Send(opCountersReplTotal, opCounters.Delete, "delete")
+	ch.Send(opCountersReplTotal, opCounters.GetMore, "getmore")
+	ch.Send(opCountersReplTotal, opCounters.Command, "command")
 }
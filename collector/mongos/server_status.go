@@ -3,33 +3,49 @@ package collector_mongos
 import (
 	"time"
 
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
-	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
 var (
-	instanceUptimeSeconds = prometheus.NewCounter(prometheus.CounterOpts{
+	instanceUptimeSeconds = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "instance",
 		Name:      "uptime_seconds",
-		Help:      "The value of the uptime field corresponds to the number of seconds that the mongos or mongod process has been active.",
-	})
-	instanceUptimeEstimateSeconds = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	instanceUptimeEstimateSeconds = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "instance",
 		Name:      "uptime_estimate_seconds",
-		Help:      "uptimeEstimate provides the uptime as calculated from MongoDB's internal course-grained time keeping system.",
-	})
-	instanceLocalTime = prometheus.NewCounter(prometheus.CounterOpts{
+	}, nil)
+	instanceLocalTime = shared.NewCounterDesc(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "instance",
 		Name:      "local_time",
-		Help:      "The localTime value is the current time, according to the server, in UTC specified in an ISODate format.",
-	})
+	}, nil)
 )
 
+// serverStatusGroups maps the group names accepted by -groups.enabled to the serverStatus sub-document they control.
+var serverStatusGroups = map[string]string{
+	"asserts":     "asserts",
+	"connections": "connections",
+	"extra_info":  "extra_info",
+	"network":     "network",
+	"op_counters": "opcounters",
+	"memory":      "mem",
+	"metrics":     "metrics",
+	"cursors":     "cursors",
+}
+
+func init() {
+	for group := range serverStatusGroups {
+		shared.RegisterGroup(group)
+	}
+	shared.RegisterGroup("sharding")
+}
+
 // ServerStatus keeps the data returned by the serverStatus() method.
 type ServerStatus struct {
 	Uptime         float64   `bson:"uptime"`
@@ -44,21 +60,22 @@ type ServerStatus struct {
 
 	Network *NetworkStats `bson:"network"`
 
-	Opcounters     *OpcountersStats     `bson:"opcounters"`
-	Mem            *MemStats            `bson:"mem"`
-	Metrics        *MetricsStats        `bson:"metrics"`
+	Opcounters *OpcountersStats `bson:"opcounters"`
+	Mem        *MemStats        `bson:"mem"`
+	Metrics    *MetricsStats    `bson:"metrics"`
 
 	Cursors *Cursors `bson:"cursors"`
+
+	// Raw is the whole serverStatus document, for the dynamic metrics of the fields not decoded above.
+	Raw bson.Raw `bson:"-"`
 }
 
-// Export exports the server status to be consumed by prometheus.
-func (status *ServerStatus) Export(ch chan<- prometheus.Metric) {
-	instanceUptimeSeconds.Add(status.Uptime)
-	instanceUptimeEstimateSeconds.Add(status.Uptime)
-	instanceLocalTime.Add(float64(status.LocalTime.Unix()))
-	instanceUptimeSeconds.Collect(ch)
-	instanceUptimeEstimateSeconds.Collect(ch)
-	instanceLocalTime.Collect(ch)
+// Export exports the server status to be consumed by prometheus. The channel drops the metrics of
+// the disabled groups, as given by the catalog.
+func (status *ServerStatus) Export(ch *shared.MetricChannel) {
+	ch.Send(instanceUptimeSeconds, status.Uptime)
+	ch.Send(instanceUptimeEstimateSeconds, status.UptimeEstimate)
+	ch.Send(instanceLocalTime, float64(status.LocalTime.Unix()))
 
 	if status.Asserts != nil {
 		status.Asserts.Export(ch)
@@ -86,46 +103,30 @@ func (status *ServerStatus) Export(ch chan<- prometheus.Metric) {
 	}
 }
 
-// Describe describes the server status for prometheus.
-func (status *ServerStatus) Describe(ch chan<- *prometheus.Desc) {
-	instanceUptimeSeconds.Describe(ch)
-	instanceUptimeEstimateSeconds.Describe(ch)
-	instanceLocalTime.Describe(ch)
-
-	if status.Asserts != nil {
-		status.Asserts.Describe(ch)
-	}
-	if status.Connections != nil {
-		status.Connections.Describe(ch)
-	}
-	if status.ExtraInfo != nil {
-		status.ExtraInfo.Describe(ch)
-	}
-	if status.Network != nil {
-		status.Network.Describe(ch)
-	}
-	if status.Opcounters != nil {
-		status.Opcounters.Describe(ch)
-	}
-	if status.Mem != nil {
-		status.Mem.Describe(ch)
-	}
-	if status.Metrics != nil {
-		status.Metrics.Describe(ch)
-	}
-	if status.Cursors != nil {
-		status.Cursors.Describe(ch)
-	}
-}
-
 // GetServerStatus returns the server status info.
-func GetServerStatus(session *mgo.Session) *ServerStatus {
-	result := &ServerStatus{}
-	err := session.DB("admin").Run(bson.D{{"serverStatus", 1}, {"recordStats", 0}}, result)
+func GetServerStatus(session shared.Session) *ServerStatus {
+	raw := bson.Raw{}
+	err := session.Run("admin", serverStatusCommand(), &raw)
 	if err != nil {
 		glog.Error("Failed to get server status.")
 		return nil
 	}
+	result := &ServerStatus{Raw: raw}
+	if err := raw.Unmarshal(result); err != nil {
+		glog.Errorf("Failed to decode server status: %s", err)
+		return nil
+	}
 
 	return result
 }
+
+// serverStatusCommand builds the serverStatus command, excluding the sub-documents of disabled groups.
+func serverStatusCommand() bson.D {
+	cmd := bson.D{{"serverStatus", 1}, {"recordStats", 0}}
+	for group, field := range serverStatusGroups {
+		if !shared.IsGroupEnabled(group) {
+			cmd = append(cmd, bson.DocElem{field, 0})
+		}
+	}
+	return cmd
+}
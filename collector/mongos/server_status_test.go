@@ -3,6 +3,7 @@ package collector_mongos
 import (
 	"testing"
 
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -16,14 +17,6 @@ func Test_ParserServerStatus(t *testing.T) {
 		t.Error("Asserts group was not loaded")
 	}
 
-	if serverStatus.Dur == nil {
-		t.Error("Dur group was not loaded")
-	}
-
-	if serverStatus.BackgroundFlushing == nil {
-		t.Error("BackgroundFlushing group was not loaded")
-	}
-
 	if serverStatus.Connections == nil {
 		t.Error("Connections group was not loaded")
 	}
@@ -32,10 +25,6 @@ func Test_ParserServerStatus(t *testing.T) {
 		t.Error("ExtraInfo group was not loaded")
 	}
 
-	if serverStatus.GlobalLock == nil {
-		t.Error("GlobalLock group was not loaded")
-	}
-
 	if serverStatus.Network == nil {
 		t.Error("Network group was not loaded")
 	}
@@ -44,23 +33,15 @@ func Test_ParserServerStatus(t *testing.T) {
 		t.Error("Opcounters group was not loaded")
 	}
 
-	if serverStatus.OpcountersRepl == nil {
-		t.Error("OpcountersRepl group was not loaded")
-	}
-
 	if serverStatus.Mem == nil {
 		t.Error("Mem group was not loaded")
 	}
 
-	if serverStatus.Connections == nil {
-		t.Error("Connections group was not loaded")
+	if serverStatus.Cursors == nil {
+		t.Error("Cursors group was not loaded")
 	}
 
-	if serverStatus.Locks == nil {
-		t.Error("Locks group was not loaded")
-	}
-
-	if serverStatus.Metrics.Document.Deleted != 45726 {
+	if serverStatus.Metrics == nil || serverStatus.Metrics.GetLastError == nil {
 		t.Error("Metrics group was not loaded correctly")
 	}
 }
@@ -71,3 +52,16 @@ func loadServerStatusFromBson(data []byte, status *ServerStatus) {
 		panic(err)
 	}
 }
+
+func Test_ExportUptime(t *testing.T) {
+	serverStatus := &ServerStatus{}
+	loadServerStatusFromBson(LoadFixture("server_status.bson"), serverStatus)
+
+	values, err := sessiontest.GatherExport(serverStatus.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["mongodb_mongos_instance_uptime_seconds"] != 127859 || values["mongodb_mongos_instance_uptime_estimate_seconds"] != 13850 {
+		t.Errorf("unexpected uptime %v and uptime estimate %v", values["mongodb_mongos_instance_uptime_seconds"], values["mongodb_mongos_instance_uptime_estimate_seconds"])
+	}
+}
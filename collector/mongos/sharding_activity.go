@@ -0,0 +1,204 @@
+package collector_mongos
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// logReadLimit caps the number of log entries read per scrape, the next scrapes catch up with the rest.
+const logReadLimit = 10000
+
+var (
+	shardingChangelogEvents = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "changelog_events_total",
+	}, []string{"event", "ns"})
+	shardingMigrationAborts = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "migration_aborts_total",
+	}, []string{"ns"})
+	shardingMoveChunkStepSeconds = shared.NewHistogramDesc(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "move_chunk_step_duration_seconds",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 600},
+	}, []string{"event", "step"})
+	shardingBalancerRoundSeconds = shared.NewHistogramDesc(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "balancer_round_duration_seconds",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300},
+	}, []string{"result"})
+	shardingBalancerChunksMoved = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "balancer_chunks_moved_total",
+	}, nil)
+)
+
+// ShardingLogEntry is a document of config.changelog or config.actionlog.
+type ShardingLogEntry struct {
+	Id        interface{} `bson:"_id"`
+	What      string      `bson:"what"`
+	Namespace string      `bson:"ns"`
+	Time      time.Time   `bson:"time"`
+	Details   bson.M      `bson:"details"`
+}
+
+type shardingEventKey struct {
+	event, ns string
+}
+
+type moveChunkStepKey struct {
+	event, step string
+}
+
+// ShardingActivityTracker follows config.changelog and config.actionlog across scrapes,
+// and accumulates the counters and histograms of the migrations, splits and balancer rounds.
+type ShardingActivityTracker struct {
+	mu sync.Mutex
+
+	changelog shared.LogCursor
+	actionlog shared.LogCursor
+
+	events        map[shardingEventKey]float64
+	aborts        map[string]float64
+	stepDurations map[moveChunkStepKey]*shared.Histogram
+	rounds        map[string]*shared.Histogram
+	chunksMoved   float64
+}
+
+// NewShardingActivityTracker returns a tracker counting the events from its first update.
+func NewShardingActivityTracker() *ShardingActivityTracker {
+	return &ShardingActivityTracker{
+		changelog:     shared.LogCursor{TimeField: "time", Order: "time"},
+		actionlog:     shared.LogCursor{TimeField: "time", Order: "time"},
+		events:        map[shardingEventKey]float64{},
+		aborts:        map[string]float64{},
+		stepDurations: map[moveChunkStepKey]*shared.Histogram{},
+		rounds:        map[string]*shared.Histogram{},
+	}
+}
+
+// Update reads the new entries of config.changelog and config.actionlog. The changelog events of
+// the namespaces not matching the filter are ignored.
+func (tracker *ShardingActivityTracker) Update(session shared.Session, filter *shared.NamespaceFilter) error {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	err := tracker.changelog.Read(session, "config", "changelog", logReadLimit, func(raw bson.Raw) error {
+		entry := ShardingLogEntry{}
+		if err := raw.Unmarshal(&entry); err != nil {
+			return err
+		}
+		if filter.Match(entry.Namespace) {
+			tracker.addChangelogEntry(&entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot read config.changelog: %s", err)
+	}
+
+	err = tracker.actionlog.Read(session, "config", "actionlog", logReadLimit, func(raw bson.Raw) error {
+		entry := ShardingLogEntry{}
+		if err := raw.Unmarshal(&entry); err != nil {
+			return err
+		}
+		tracker.addActionlogEntry(&entry)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot read config.actionlog: %s", err)
+	}
+	return nil
+}
+
+func (tracker *ShardingActivityTracker) addChangelogEntry(entry *ShardingLogEntry) {
+	switch entry.What {
+	case "moveChunk.start", "moveChunk.commit", "split", "multi-split":
+		tracker.events[shardingEventKey{entry.What, entry.Namespace}]++
+	case "moveChunk.from", "moveChunk.to":
+		tracker.events[shardingEventKey{entry.What, entry.Namespace}]++
+		// a failed migration is also logged by the recipient and with a moveChunk.error, only the
+		// note of the donor counts it
+		if note, _ := entry.Details["note"].(string); entry.What == "moveChunk.from" && note != "" && note != "success" {
+			tracker.aborts[entry.Namespace]++
+		}
+		for name, value := range entry.Details {
+			var step, steps int
+			if _, err := fmt.Sscanf(name, "step %d of %d", &step, &steps); err != nil {
+				continue
+			}
+			millis, ok := logNumber(value)
+			if !ok {
+				continue
+			}
+			key := moveChunkStepKey{entry.What, fmt.Sprint(step)}
+			if tracker.stepDurations[key] == nil {
+				tracker.stepDurations[key] = shared.NewHistogram(shardingMoveChunkStepSeconds)
+			}
+			tracker.stepDurations[key].Observe(millis / 1000)
+		}
+	case "moveChunk.error":
+		tracker.events[shardingEventKey{entry.What, entry.Namespace}]++
+	}
+}
+
+func (tracker *ShardingActivityTracker) addActionlogEntry(entry *ShardingLogEntry) {
+	if entry.What != "balancer.round" {
+		return
+	}
+	result := "success"
+	if failed, _ := entry.Details["errorOccured"].(bool); failed {
+		result = "error"
+	}
+	if tracker.rounds[result] == nil {
+		tracker.rounds[result] = shared.NewHistogram(shardingBalancerRoundSeconds)
+	}
+	millis, _ := logNumber(entry.Details["executionTimeMillis"])
+	tracker.rounds[result].Observe(millis / 1000)
+	if moved, ok := logNumber(entry.Details["chunksMoved"]); ok {
+		tracker.chunksMoved += moved
+	}
+}
+
+// logNumber returns the value of a numeric field of a log entry.
+func logNumber(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case int:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case float64:
+		return number, true
+	}
+	return 0, false
+}
+
+// Export exports the accumulated activity to prometheus.
+func (tracker *ShardingActivityTracker) Export(ch *shared.MetricChannel) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	for key, count := range tracker.events {
+		ch.Send(shardingChangelogEvents, count, key.event, key.ns)
+	}
+	for ns, count := range tracker.aborts {
+		ch.Send(shardingMigrationAborts, count, ns)
+	}
+	for key, histogram := range tracker.stepDurations {
+		ch.SendHistogram(shardingMoveChunkStepSeconds, histogram, key.event, key.step)
+	}
+	for result, histogram := range tracker.rounds {
+		ch.SendHistogram(shardingBalancerRoundSeconds, histogram, result)
+	}
+	ch.Send(shardingBalancerChunksMoved, tracker.chunksMoved)
+}
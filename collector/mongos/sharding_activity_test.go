@@ -0,0 +1,80 @@
+package collector_mongos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func logEntry(id int, seconds int, what string) bson.M {
+	return bson.M{"_id": id, "what": what, "ns": "db.coll", "time": time.Unix(int64(1600000000+seconds), 0).UTC()}
+}
+
+func Test_ShardingActivityTracker(t *testing.T) {
+	session := &sessiontest.Session{Collections: map[string][]bson.M{
+		"config.changelog": {logEntry(1, 0, "split")},
+		"config.actionlog": {},
+	}}
+	tracker := NewShardingActivityTracker()
+	if err := tracker.Update(session, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	abort := logEntry(3, 20, "moveChunk.from")
+	abort["details"] = bson.M{"note": "aborted", "step 1 of 6": 250}
+	round := logEntry(4, 30, "balancer.round")
+	round["details"] = bson.M{"executionTimeMillis": 1200, "chunksMoved": 2, "errorOccured": false}
+	session.Collections["config.changelog"] = append(session.Collections["config.changelog"], logEntry(2, 10, "split"), abort)
+	session.Collections["config.actionlog"] = append(session.Collections["config.actionlog"], round)
+	if err := tracker.Update(session, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := sessiontest.GatherExport(tracker.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]float64{
+		`mongodb_mongos_sharding_changelog_events_total{event="split",ns="db.coll"}`:                1,
+		`mongodb_mongos_sharding_changelog_events_total{event="moveChunk.from",ns="db.coll"}`:       1,
+		`mongodb_mongos_sharding_migration_aborts_total{ns="db.coll"}`:                              1,
+		`mongodb_mongos_sharding_move_chunk_step_duration_seconds{event="moveChunk.from",step="1"}`: 1,
+		`mongodb_mongos_sharding_balancer_round_duration_seconds{result="success"}`:                 1,
+		`mongodb_mongos_sharding_balancer_chunks_moved_total`:                                       2,
+	}
+	sessiontest.ExpectValues(t, values, expected)
+}
+
+func Test_ShardingActivityTrackerAbortedMigration(t *testing.T) {
+	session := &sessiontest.Session{Collections: map[string][]bson.M{
+		"config.changelog": {logEntry(1, 0, "split")},
+		"config.actionlog": {},
+	}}
+	tracker := NewShardingActivityTracker()
+	if err := tracker.Update(session, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// one failed migration is logged by the donor, the recipient and with a moveChunk.error
+	from := logEntry(2, 10, "moveChunk.from")
+	from["details"] = bson.M{"note": "aborted"}
+	to := logEntry(3, 10, "moveChunk.to")
+	to["details"] = bson.M{"note": "aborted"}
+	failure := logEntry(4, 11, "moveChunk.error")
+	failure["details"] = bson.M{"errmsg": "migration aborted"}
+	session.Collections["config.changelog"] = append(session.Collections["config.changelog"], from, to, failure)
+	if err := tracker.Update(session, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := sessiontest.GatherExport(tracker.Export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessiontest.ExpectValues(t, values, map[string]float64{
+		`mongodb_mongos_sharding_changelog_events_total{event="moveChunk.error",ns="db.coll"}`: 1,
+		`mongodb_mongos_sharding_migration_aborts_total{ns="db.coll"}`:                         1,
+	})
+}
@@ -1,87 +1,79 @@
 package collector_mongos
 
 import (
-	"time"
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
-	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
+	"time"
 )
 
 var (
-	shardingChangelogInfo = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace:	Namespace,
-		Subsystem:	"sharding",
-		Name:		"changelog_10min_total",
-		Help:		"Total # of Cluster Balancer log events over the last 10 minutes",
+	shardingChangelogInfo = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "changelog_10min_total",
 	}, []string{"event"})
 )
 
 type ShardingChangelogSummaryId struct {
-	Event	string	`bson:"event"`
-	Note	string	`bson:"note"`
+	Event string `bson:"event"`
+	Note  string `bson:"note"`
 }
 
 type ShardingChangelogSummary struct {
-	Id	*ShardingChangelogSummaryId	`bson:"_id"`
-	Count 	float64				`bson:"count"`
+	Id    *ShardingChangelogSummaryId `bson:"_id"`
+	Count float64                     `bson:"count"`
 }
 
 type ShardingChangelogStats struct {
-	Items	*[]ShardingChangelogSummary
+	Items *[]ShardingChangelogSummary
 }
 
-func (status *ShardingChangelogStats) Export(ch chan<- prometheus.Metric) {
+func (status *ShardingChangelogStats) Export(ch *shared.MetricChannel) {
 	// set all expected event types to zero first, so they show in results if there was no events in the current time period
-	shardingChangelogInfo.WithLabelValues("moveChunk.start").Add(0)
-	shardingChangelogInfo.WithLabelValues("moveChunk.to").Add(0)
-	shardingChangelogInfo.WithLabelValues("moveChunk.to_failed").Add(0)
-	shardingChangelogInfo.WithLabelValues("moveChunk.from").Add(0)
-	shardingChangelogInfo.WithLabelValues("moveChunk.from_failed").Add(0)
-	shardingChangelogInfo.WithLabelValues("moveChunk.commit").Add(0)
-	shardingChangelogInfo.WithLabelValues("addShard").Add(0)
-	shardingChangelogInfo.WithLabelValues("removeShard.start").Add(0)
-	shardingChangelogInfo.WithLabelValues("shardCollection").Add(0)
-	shardingChangelogInfo.WithLabelValues("shardCollection.start").Add(0)
-	shardingChangelogInfo.WithLabelValues("split").Add(0)
-	shardingChangelogInfo.WithLabelValues("multi-split").Add(0)
+	counts := map[string]float64{
+		"moveChunk.start":       0,
+		"moveChunk.to":          0,
+		"moveChunk.to_failed":   0,
+		"moveChunk.from":        0,
+		"moveChunk.from_failed": 0,
+		"moveChunk.commit":      0,
+		"addShard":              0,
+		"removeShard.start":     0,
+		"shardCollection":       0,
+		"shardCollection.start": 0,
+		"split":                 0,
+		"multi-split":           0,
+	}
 
-	// set counts for events found in our query
+	// add counts for events found in our query
 	for _, item := range *status.Items {
 		event := item.Id.Event
-		note  := item.Id.Note
-		count := item.Count
+		note := item.Id.Note
 		switch event {
-			case "moveChunk.to":
-				if note == "success" || note == "" {
-					shardingChangelogInfo.WithLabelValues(event).Add(count)
-				} else {
-					shardingChangelogInfo.WithLabelValues(event + "_failed").Add(count)
-				}
-			case "moveChunk.from":
-				if note == "success" || note == "" {
-					shardingChangelogInfo.WithLabelValues(event).Add(count)
-				} else {
-					shardingChangelogInfo.WithLabelValues(event + "_failed").Add(count)
-				}
-			default:
-				shardingChangelogInfo.WithLabelValues(event).Add(count)
+		case "moveChunk.to", "moveChunk.from":
+			if note == "success" || note == "" {
+				counts[event] += item.Count
+			} else {
+				counts[event+"_failed"] += item.Count
+			}
+		default:
+			counts[event] += item.Count
 		}
 	}
-	shardingChangelogInfo.Collect(ch)
-}
 
-func (status *ShardingChangelogStats) Describe(ch chan<- *prometheus.Desc) {
-	shardingChangelogInfo.Describe(ch)
+	for event, count := range counts {
+		ch.Send(shardingChangelogInfo, count, event)
+	}
 }
 
-func GetShardingChangelogStatus(session *mgo.Session) *ShardingChangelogStats {
+func GetShardingChangelogStatus(session shared.Session) *ShardingChangelogStats {
 	var qresults []ShardingChangelogSummary
-	coll  := session.DB("config").C("changelog")
-	match := bson.M{ "time" : bson.M{ "$gt" : time.Now().Add(-10 * time.Minute) } }
-	group := bson.M{ "_id" : bson.M{ "event" : "$what", "note" : "$details.note" }, "count" : bson.M{ "$sum" : 1 } }
+	match := bson.M{"time": bson.M{"$gt": time.Now().Add(-10 * time.Minute)}}
+	group := bson.M{"_id": bson.M{"event": "$what", "note": "$details.note"}, "count": bson.M{"$sum": 1}}
 
-	err := coll.Pipe([]bson.M{ { "$match" : match }, { "$group" : group } }).All(&qresults)
+	err := session.Pipe("config", "changelog", []bson.M{{"$match": match}, {"$group": group}}).All(&qresults)
 	if err != nil {
 		glog.Error("Failed to execute find query on 'config.changelog'!")
 	}
@@ -0,0 +1,216 @@
+package collector_mongos
+
+import (
+	"strconv"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	shardingCollectionChunks = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "collection_chunks",
+	}, []string{"ns", "shard", "draining"})
+	shardingCollectionJumboChunks = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "collection_jumbo_chunks",
+	}, []string{"ns"})
+	shardingCollectionChunksImbalance = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "collection_chunks_imbalance",
+	}, []string{"ns"})
+	shardingCollectionChunksBalanced = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "collection_chunks_is_balanced",
+	}, []string{"ns"})
+)
+
+func init() {
+	// since 5.0 the chunks are keyed by the uuid of their collection instead of its namespace
+	shared.RegisterCapability(shared.Capability{Name: "shardingChunksByUUID", MinVersion: shared.Version{Major: 5}})
+}
+
+// ShardingCollectionChunks is the number of chunks of a namespace on a shard.
+type ShardingCollectionChunks struct {
+	Id struct {
+		Namespace string `bson:"ns"`
+		Shard     string `bson:"shard"`
+	} `bson:"_id"`
+	Chunks float64 `bson:"chunks"`
+	Jumbo  float64 `bson:"jumbo"`
+}
+
+// ShardingChunkStats is the distribution of the chunks of the sharded collections.
+type ShardingChunkStats struct {
+	Shards      []ShardingTopoShardInfo
+	Collections []ShardingCollectionChunks
+}
+
+// BalanceThreshold returns the difference of chunks between the most and least loaded shards
+// from which the balancer migrates chunks, given the number of chunks.
+// http://docs.mongodb.org/manual/core/sharding-internals/#sharding-migration-thresholds
+func BalanceThreshold(chunks float64) float64 {
+	if chunks < 20 {
+		return 2
+	} else if chunks < 80 && chunks > 21 {
+		return 4
+	}
+	return 8
+}
+
+// Export exports the chunk distribution to prometheus. The draining shards are exported but left
+// out of the imbalance, since their chunks are being moved away.
+func (status *ShardingChunkStats) Export(ch *shared.MetricChannel) {
+	draining := map[string]bool{}
+	for _, shard := range status.Shards {
+		draining[shard.Shard] = shard.Draining
+	}
+	chunks := map[string]map[string]float64{}
+	jumbo := map[string]float64{}
+	for _, item := range status.Collections {
+		if chunks[item.Id.Namespace] == nil {
+			// shards without chunks of the namespace count as empty shards
+			chunks[item.Id.Namespace] = map[string]float64{}
+			for _, shard := range status.Shards {
+				chunks[item.Id.Namespace][shard.Shard] = 0
+			}
+		}
+		chunks[item.Id.Namespace][item.Id.Shard] += item.Chunks
+		jumbo[item.Id.Namespace] += item.Jumbo
+	}
+
+	for ns, shards := range chunks {
+		var total, minChunks, maxChunks float64
+		first := true
+		for shard, count := range shards {
+			ch.Send(shardingCollectionChunks, count, ns, shard, strconv.FormatBool(draining[shard]))
+			total += count
+			if draining[shard] {
+				continue
+			}
+			if first || count < minChunks {
+				minChunks = count
+			}
+			if first || count > maxChunks {
+				maxChunks = count
+			}
+			first = false
+		}
+
+		ch.Send(shardingCollectionJumboChunks, jumbo[ns], ns)
+		ch.Send(shardingCollectionChunksImbalance, maxChunks-minChunks, ns)
+		if maxChunks-minChunks < BalanceThreshold(total) {
+			ch.Send(shardingCollectionChunksBalanced, 1, ns)
+		} else {
+			ch.Send(shardingCollectionChunksBalanced, 0, ns)
+		}
+	}
+}
+
+// ListShardedNamespaces returns the sharded namespaces matching the filter, sorted, up to limit namespaces (0 = no limit).
+func ListShardedNamespaces(session shared.Session, filter *shared.NamespaceFilter, limit int) ([]string, error) {
+	var collections []struct {
+		Namespace string `bson:"_id"`
+	}
+	query := bson.M{"dropped": bson.M{"$ne": true}}
+	err := session.Find("config", "collections", query).Select(bson.M{"_id": 1}).Sort("_id").All(&collections)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := []string{}
+	for _, collection := range collections {
+		if !filter.Match(collection.Namespace) {
+			continue
+		}
+		if limit > 0 && len(namespaces) >= limit {
+			glog.Warningf("More than %d sharded collections match the namespace filter, ignoring the others", limit)
+			break
+		}
+		namespaces = append(namespaces, collection.Namespace)
+	}
+	return namespaces, nil
+}
+
+// GetShardingChunkStats returns the chunk distribution of the sharded collections matching the filter,
+// up to limit collections. The chunks are counted by the config servers on the index of config.chunks,
+// ns/shard before 5.0 and uuid/shard since, so only the chunks of the selected collections are read.
+func GetShardingChunkStats(session shared.Session, caps *shared.ServerCapabilities, filter *shared.NamespaceFilter, limit int) *ShardingChunkStats {
+	namespaces, err := ListShardedNamespaces(session, filter, limit)
+	if err != nil {
+		glog.Errorf("Failed to list the sharded collections: %s", err)
+		return nil
+	}
+
+	status := &ShardingChunkStats{}
+	err = session.Find("config", "shards", bson.M{}).Sort("_id").All(&status.Shards)
+	if err != nil {
+		glog.Errorf("Failed to list the shards: %s", err)
+		return nil
+	}
+	if len(namespaces) == 0 {
+		return status
+	}
+
+	pipeline := chunksByNamespacePipeline(namespaces)
+	if caps.Supports("shardingChunksByUUID") {
+		var collections []struct {
+			UUID interface{} `bson:"uuid"`
+		}
+		err = session.Find("config", "collections", bson.M{"_id": bson.M{"$in": namespaces}}).Select(bson.M{"uuid": 1}).All(&collections)
+		if err != nil {
+			glog.Errorf("Failed to get the uuids of the sharded collections: %s", err)
+			return nil
+		}
+		uuids := make([]interface{}, 0, len(collections))
+		for _, collection := range collections {
+			uuids = append(uuids, collection.UUID)
+		}
+		pipeline = chunksByUUIDPipeline(uuids)
+	}
+	err = session.Pipe("config", "chunks", pipeline).All(&status.Collections)
+	if err != nil {
+		glog.Errorf("Failed to count the chunks of the sharded collections: %s", err)
+		return nil
+	}
+	return status
+}
+
+// chunksByNamespacePipeline counts the chunks of the namespaces by shard, before 5.0.
+func chunksByNamespacePipeline(namespaces []string) []bson.M {
+	return []bson.M{
+		{"$match": bson.M{"ns": bson.M{"$in": namespaces}}},
+		{"$group": bson.M{
+			"_id":    bson.M{"ns": "$ns", "shard": "$shard"},
+			"chunks": bson.M{"$sum": 1},
+			"jumbo":  bson.M{"$sum": bson.M{"$cond": []interface{}{"$jumbo", 1, 0}}},
+		}},
+	}
+}
+
+// chunksByUUIDPipeline counts the chunks of the collections by shard since 5.0, and looks up the
+// namespaces of the counts in config.collections.
+func chunksByUUIDPipeline(uuids []interface{}) []bson.M {
+	return []bson.M{
+		{"$match": bson.M{"uuid": bson.M{"$in": uuids}}},
+		{"$group": bson.M{
+			"_id":    bson.M{"uuid": "$uuid", "shard": "$shard"},
+			"chunks": bson.M{"$sum": 1},
+			"jumbo":  bson.M{"$sum": bson.M{"$cond": []interface{}{"$jumbo", 1, 0}}},
+		}},
+		{"$lookup": bson.M{"from": "collections", "localField": "_id.uuid", "foreignField": "uuid", "as": "collection"}},
+		{"$unwind": "$collection"},
+		{"$project": bson.M{
+			"_id":    bson.M{"ns": "$collection._id", "shard": "$_id.shard"},
+			"chunks": 1,
+			"jumbo":  1,
+		}},
+	}
+}
@@ -0,0 +1,129 @@
+package collector_mongos
+
+import (
+	"testing"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// chunkCounts counts the chunks of config.chunks by namespace (before 5.0) or uuid (since) and shard,
+// like the config servers run the pipelines of GetShardingChunkStats.
+func chunkCounts(session *sessiontest.Session) func(database, collection string, pipeline interface{}) []interface{} {
+	return func(database, collection string, pipeline interface{}) []interface{} {
+		stages := pipeline.([]bson.M)
+		match := stages[0]["$match"].(bson.M)
+		byUUID := match["uuid"] != nil
+		namespaces := map[interface{}]string{}
+		for _, coll := range session.Collections["config.collections"] {
+			if byUUID {
+				namespaces[coll["uuid"]] = coll["_id"].(string)
+			} else {
+				namespaces[coll["_id"]] = coll["_id"].(string)
+			}
+		}
+		selected := map[interface{}]bool{}
+		key := "ns"
+		values := match["ns"]
+		if byUUID {
+			key, values = "uuid", match["uuid"]
+		}
+		for _, value := range toSlice(values) {
+			selected[value] = true
+		}
+
+		type chunkKey struct{ ns, shard string }
+		counts := map[chunkKey]bson.M{}
+		for _, chunk := range session.Collections["config.chunks"] {
+			if !selected[chunk[key]] {
+				continue
+			}
+			k := chunkKey{namespaces[chunk[key]], chunk["shard"].(string)}
+			if counts[k] == nil {
+				counts[k] = bson.M{"_id": bson.M{"ns": k.ns, "shard": k.shard}, "chunks": 0, "jumbo": 0}
+			}
+			counts[k]["chunks"] = counts[k]["chunks"].(int) + 1
+			if chunk["jumbo"] == true {
+				counts[k]["jumbo"] = counts[k]["jumbo"].(int) + 1
+			}
+		}
+		results := []interface{}{}
+		for _, count := range counts {
+			results = append(results, count)
+		}
+		return results
+	}
+}
+
+func toSlice(value interface{}) []interface{} {
+	switch value := value.(type) {
+	case bson.M:
+		return toSlice(value["$in"])
+	case []string:
+		result := []interface{}{}
+		for _, v := range value {
+			result = append(result, v)
+		}
+		return result
+	case []interface{}:
+		return value
+	}
+	return nil
+}
+
+func Test_GetShardingChunkStats(t *testing.T) {
+	tests := []struct {
+		version string
+		chunk   func(ns string, uuid int, shard string) bson.M
+	}{
+		{"4.4.10", func(ns string, uuid int, shard string) bson.M { return bson.M{"ns": ns, "shard": shard} }},
+		{"5.0.3", func(ns string, uuid int, shard string) bson.M { return bson.M{"uuid": uuid, "shard": shard} }},
+	}
+	for _, test := range tests {
+		session := &sessiontest.Session{Collections: map[string][]bson.M{
+			"config.collections": {
+				{"_id": "db.users", "uuid": 1},
+				{"_id": "db.orders", "uuid": 2},
+				{"_id": "db.dropped", "uuid": 3, "dropped": true},
+			},
+			"config.shards": {
+				{"_id": "rs0", "host": "rs0/mongo1:27017"},
+				{"_id": "rs1", "host": "rs1/mongo2:27017"},
+				{"_id": "rs2", "host": "rs2/mongo3:27017", "draining": true},
+			},
+			"config.chunks": {
+				test.chunk("db.users", 1, "rs0"),
+				test.chunk("db.users", 1, "rs0"),
+				test.chunk("db.users", 1, "rs0"),
+				test.chunk("db.users", 1, "rs1"),
+				test.chunk("db.users", 1, "rs2"),
+				test.chunk("db.orders", 2, "rs1"),
+				test.chunk("db.dropped", 3, "rs1"),
+			},
+		}}
+		session.Aggregate = chunkCounts(session)
+
+		status := GetShardingChunkStats(session, shared.NewServerCapabilities(test.version, ""), nil, 0)
+		if status == nil {
+			t.Fatalf("%s: no chunk stats", test.version)
+		}
+		values, err := sessiontest.GatherExport(status.Export)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]float64{
+			`mongodb_mongos_sharding_collection_chunks{draining="false",ns="db.users",shard="rs0"}`:  3,
+			`mongodb_mongos_sharding_collection_chunks{draining="false",ns="db.users",shard="rs1"}`:  1,
+			`mongodb_mongos_sharding_collection_chunks{draining="true",ns="db.users",shard="rs2"}`:   1,
+			`mongodb_mongos_sharding_collection_chunks{draining="false",ns="db.orders",shard="rs1"}`: 1,
+			`mongodb_mongos_sharding_collection_chunks{draining="true",ns="db.orders",shard="rs2"}`:  0,
+			`mongodb_mongos_sharding_collection_chunks_imbalance{ns="db.users"}`:                     2,
+			`mongodb_mongos_sharding_collection_chunks_imbalance{ns="db.orders"}`:                    1,
+		}
+		sessiontest.ExpectValues(t, values, expected)
+		if _, ok := values[`mongodb_mongos_sharding_collection_jumbo_chunks{ns="db.dropped"}`]; ok {
+			t.Errorf("%s: the dropped collections should not be counted", test.version)
+		}
+	}
+}
@@ -1,101 +1,95 @@
 package collector_mongos
 
 import (
-	"time"
-	"strings"
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
-	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
+	"strings"
+	"time"
 )
 
 var (
-	balancerIsEnabled = prometheus.NewGauge(prometheus.GaugeOpts{
-			Namespace:	Namespace,
-			Subsystem:	"sharding",
-			Name:		"balancer_enabled",
-			Help:		"Boolean reporting if cluster balancer is enabled (1 = enabled/0 = disabled)",
-	})
-	balancerChunksBalanced = prometheus.NewGauge(prometheus.GaugeOpts{
-			Namespace:	Namespace,
-			Subsystem:	"sharding",
-			Name:		"chunks_is_balanced",
-			Help:		"Boolean reporting if cluster chunks are evenly balanced across shards (1 = yes/0 = no)",
-	})
-	mongosUpSecs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace:      Namespace,
-			Subsystem:      "sharding",
-			Name:		"mongos_uptime_seconds",
-			Help:		"The uptime of the Mongos processes in seconds",
+	balancerIsEnabled = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "balancer_enabled",
+	}, nil)
+	balancerChunksBalanced = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "chunks_is_balanced",
+	}, nil)
+	mongosUpSecs = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "mongos_uptime_seconds",
 	}, []string{"name"})
-	mongosPing = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace:      Namespace,
-			Subsystem:      "sharding",
-			Name:		"mongos_last_ping_timestamp",
-			Help:		"The unix timestamp of the last Mongos ping to the Cluster config servers",
+	mongosPing = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "mongos_last_ping_timestamp",
 	}, []string{"name"})
-	mongosBalancerLockTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace:      Namespace,
-			Subsystem:      "sharding",
-			Name:		"balancer_lock_timestamp",
-			Help:		"The unix timestamp of the last update to the Cluster balancer lock",
+	mongosBalancerLockTimestamp = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "balancer_lock_timestamp",
 	}, []string{"name"})
-	mongosBalancerLockState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace:      Namespace,
-			Subsystem:      "sharding",
-			Name:		"balancer_lock_state",
-			Help:		"The state of the Cluster balancer lock (-1 = none/0 = unlocked/1 = contention/2 = locked)",
+	mongosBalancerLockState = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "balancer_lock_state",
 	}, []string{"name"})
 )
 
 type MongosInfo struct {
-	Name		string		`bson:"_id"`
-	Ping		time.Time       `bson:"ping"`
-	Up		float64		`bson:"up"`
-	Waiting		bool		`bson:"waiting"`
-	MongoVersion    string		`bson:"mongoVersion"`
+	Name         string    `bson:"_id"`
+	Ping         time.Time `bson:"ping"`
+	Up           float64   `bson:"up"`
+	Waiting      bool      `bson:"waiting"`
+	MongoVersion string    `bson:"mongoVersion"`
 }
 
 type MongosBalancerLock struct {
-	State	float64		`bson:"state"`
-	Process	string		`bson:"process"`
-	Who	string		`bson:"who"`
-	When	time.Time	`bson:"when"`
-	Why	string		`bson:"why"`
+	State   float64   `bson:"state"`
+	Process string    `bson:"process"`
+	Who     string    `bson:"who"`
+	When    time.Time `bson:"when"`
+	Why     string    `bson:"why"`
 }
 
 type ShardingStats struct {
-	IsBalanced	float64	
-	BalancerEnabled	float64
-	Changelog	*ShardingChangelogStats	
-	Topology	*ShardingTopoStats
-	BalancerLock	*MongosBalancerLock
-	Mongos		*[]MongosInfo
+	IsBalanced      float64
+	BalancerEnabled float64
+	Changelog       *ShardingChangelogStats
+	Topology        *ShardingTopoStats
+	BalancerLock    *MongosBalancerLock
+	Mongos          *[]MongosInfo
 }
 
-func GetMongosInfo(session *mgo.Session) *[]MongosInfo {
+func GetMongosInfo(session shared.Session) *[]MongosInfo {
 	mongosInfo := []MongosInfo{}
-	err := session.DB("config").C("mongos").Find(bson.M{ "ping" : bson.M{ "$gte" : time.Now().Add(-10 * time.Minute) } }).All(&mongosInfo)
+	err := session.Find("config", "mongos", bson.M{"ping": bson.M{"$gte": time.Now().Add(-10 * time.Minute)}}).All(&mongosInfo)
 	if err != nil {
 		glog.Error("Failed to execute find query on 'config.mongos'!")
 	}
 	return &mongosInfo
 }
 
-func GetMongosBalancerLock(session *mgo.Session) *MongosBalancerLock {
+func GetMongosBalancerLock(session shared.Session) *MongosBalancerLock {
 	var balancerLock *MongosBalancerLock
-	err := session.DB("config").C("locks").Find(bson.M{ "_id" : "balancer" }).One(&balancerLock)
+	err := session.Find("config", "locks", bson.M{"_id": "balancer"}).One(&balancerLock)
 	if err != nil {
 		glog.Error("Failed to execute find query on 'config.locks'!")
 	}
 	return balancerLock
 }
 
-func IsBalancerEnabled(session *mgo.Session) float64 {
+func IsBalancerEnabled(session shared.Session) float64 {
 	balancerConfig := struct {
-		Stopped	bool	`bson:"stopped"`
+		Stopped bool `bson:"stopped"`
 	}{}
-	err := session.DB("config").C("settings").Find(bson.M{ "_id" : "balancer" }).One(&balancerConfig)
+	err := session.Find("config", "settings", bson.M{"_id": "balancer"}).One(&balancerConfig)
 	if err != nil {
 		return 1
 	}
@@ -105,16 +99,9 @@ func IsBalancerEnabled(session *mgo.Session) float64 {
 	return 1
 }
 
-func IsClusterBalanced(session *mgo.Session) float64 {
+func IsClusterBalanced(session shared.Session) float64 {
 	// Different thresholds based on size
-	// http://docs.mongodb.org/manual/core/sharding-internals/#sharding-migration-thresholds
-	var threshold float64 = 8
-	totalChunkCount := GetTotalChunks(session)
-	if totalChunkCount < 20 {
-		threshold = 2
-	} else if totalChunkCount < 80 && totalChunkCount > 21 {
-		threshold = 4
-	}
+	threshold := BalanceThreshold(GetTotalChunks(session))
 
 	var minChunkCount float64 = -1
 	var maxChunkCount float64 = 0
@@ -137,7 +124,7 @@ func IsClusterBalanced(session *mgo.Session) float64 {
 	return 0
 }
 
-func (status *ShardingStats) Export(ch chan<- prometheus.Metric) {
+func (status *ShardingStats) Export(ch *shared.MetricChannel) {
 	if status.Changelog != nil {
 		status.Changelog.Export(ch)
 	}
@@ -147,48 +134,27 @@ func (status *ShardingStats) Export(ch chan<- prometheus.Metric) {
 	if status.Mongos != nil && status.BalancerLock != nil {
 		mongosBalancerLockWho := strings.Split(status.BalancerLock.Who, ":")
 		mongosBalancerLockHostPort := mongosBalancerLockWho[0] + ":" + mongosBalancerLockWho[1]
-		mongosBalancerLockTimestamp.WithLabelValues(mongosBalancerLockHostPort).Set(float64(status.BalancerLock.When.Unix()))
+		ch.Send(mongosBalancerLockTimestamp, float64(status.BalancerLock.When.Unix()), mongosBalancerLockHostPort)
 		for _, mongos := range *status.Mongos {
-			mongosUpSecs.WithLabelValues(mongos.Name).Set(mongos.Up)
-			mongosPing.WithLabelValues(mongos.Name).Set(float64(mongos.Ping.Unix()))
-			mongosBalancerLockState.WithLabelValues(mongos.Name).Set(-1)
+			ch.Send(mongosUpSecs, mongos.Up, mongos.Name)
+			ch.Send(mongosPing, float64(mongos.Ping.Unix()), mongos.Name)
 			if mongos.Name == mongosBalancerLockHostPort {
-				mongosBalancerLockState.WithLabelValues(mongos.Name).Set(status.BalancerLock.State)
+				ch.Send(mongosBalancerLockState, status.BalancerLock.State, mongos.Name)
+			} else {
+				ch.Send(mongosBalancerLockState, -1, mongos.Name)
 			}
 		}
 	}
-	balancerIsEnabled.Set(status.IsBalanced)
-	balancerChunksBalanced.Set(status.BalancerEnabled)
-
-	balancerIsEnabled.Collect(ch)
-	balancerChunksBalanced.Collect(ch)
-	mongosUpSecs.Collect(ch)
-	mongosPing.Collect(ch)
-	mongosBalancerLockState.Collect(ch)
-	mongosBalancerLockTimestamp.Collect(ch)
-}
-
-func (status *ShardingStats) Describe(ch chan<- *prometheus.Desc) {
-	if status.Changelog != nil {
-		status.Changelog.Describe(ch)
-	}
-	if status.Topology != nil {
-		status.Topology.Describe(ch)
-	}
-	balancerIsEnabled.Describe(ch)
-	balancerChunksBalanced.Describe(ch)
-	mongosUpSecs.Describe(ch)
-	mongosPing.Describe(ch)
-	mongosBalancerLockState.Describe(ch)
-	mongosBalancerLockTimestamp.Describe(ch)
+	ch.Send(balancerIsEnabled, status.IsBalanced)
+	ch.Send(balancerChunksBalanced, status.BalancerEnabled)
 }
 
-func GetShardingStatus(session *mgo.Session) *ShardingStats {
+func GetShardingStatus(session shared.Session) *ShardingStats {
 	results := &ShardingStats{}
 
 	results.IsBalanced = IsClusterBalanced(session)
 	results.BalancerEnabled = IsBalancerEnabled(session)
-	results.Changelog = GetShardingChangelogStatus(session) 
+	results.Changelog = GetShardingChangelogStatus(session)
 	results.Topology = GetShardingTopoStatus(session)
 	results.Mongos = GetMongosInfo(session)
 	results.BalancerLock = GetMongosBalancerLock(session)
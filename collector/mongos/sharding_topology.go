@@ -1,120 +1,114 @@
 package collector_mongos
 
 import (
+	"github.com/elarasu/mongodb_exporter/shared"
 	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
-	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
 var (
-	shardingTopoInfoTotalShards = prometheus.NewGauge(prometheus.GaugeOpts{
-			Namespace:	Namespace,
-			Subsystem:	"sharding",
-			Name:		"shards_total",
-			Help:		"Total # of Shards in the Cluster",
-	})
-	shardingTopoInfoDrainingShards = prometheus.NewGauge(prometheus.GaugeOpts{
-			Namespace:	Namespace,
-			Subsystem:	"sharding",
-			Name:		"shards_draining_total",
-			Help:		"Total # of Shards in the Cluster in draining state",
-	})
-	shardingTopoInfoTotalChunks = prometheus.NewGauge(prometheus.GaugeOpts{
-			Namespace:	Namespace,
-			Subsystem:	"sharding",
-			Name:		"chunks_total",
-			Help:		"Total # of Chunks in the Cluster",
-	})
-	shardingTopoInfoShardChunks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace:      Namespace,
-			Subsystem:      "sharding",
-			Name:		"shard_chunks_total",
-			Help:		"Total number of chunks per shard",
+	shardingTopoInfoTotalShards = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "shards_total",
+	}, nil)
+	shardingTopoInfoDrainingShards = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "shards_draining_total",
+	}, nil)
+	shardingTopoInfoTotalChunks = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "chunks_total",
+	}, nil)
+	shardingTopoInfoShardChunks = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "shard_chunks_total",
 	}, []string{"shard"})
-	shardingTopoInfoTotalDatabases = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace:	Namespace,
-			Subsystem:	"sharding",
-			Name:		"databases_total",
-			Help:		"Total # of Databases in the Cluster",
+	shardingTopoInfoTotalDatabases = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "databases_total",
 	}, []string{"type"})
-	shardingTopoInfoTotalCollections = prometheus.NewGauge(prometheus.GaugeOpts{
-			Namespace:	Namespace,
-			Subsystem:	"sharding",
-			Name:		"collections_total",
-			Help:		"Total # of Collections with Sharding enabled",
-	})
+	shardingTopoInfoTotalCollections = shared.NewGaugeDesc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "sharding",
+		Name:      "collections_total",
+	}, nil)
 )
 
 type ShardingTopoShardInfo struct {
-	Shard		string	`bson:"_id"`
-	Host		string	`bson:"host"`
-	Draining	bool	`bson:"draining",omitifempty`
+	Shard    string `bson:"_id"`
+	Host     string `bson:"host"`
+	Draining bool   `bson:"draining,omitempty"`
 }
 
 type ShardingTopoChunkInfo struct {
-	Shard	string	`bson:"_id"`
-	Chunks	float64	`bson:"count"`
+	Shard  string  `bson:"_id"`
+	Chunks float64 `bson:"count"`
 }
 
 type ShardingTopoStatsTotalDatabases struct {
-	Partitioned	bool	`bson:"_id"`
-	Total		float64	`bson:"total"`
+	Partitioned bool    `bson:"_id"`
+	Total       float64 `bson:"total"`
 }
 
 type ShardingTopoStats struct {
-	TotalChunks		float64
-	TotalCollections	float64
-	TotalDatabases		*[]ShardingTopoStatsTotalDatabases
-	Shards			*[]ShardingTopoShardInfo
-	ShardChunks		*[]ShardingTopoChunkInfo
+	TotalChunks      float64
+	TotalCollections float64
+	TotalDatabases   *[]ShardingTopoStatsTotalDatabases
+	Shards           *[]ShardingTopoShardInfo
+	ShardChunks      *[]ShardingTopoChunkInfo
 }
 
-func GetShards(session *mgo.Session) *[]ShardingTopoShardInfo {
+func GetShards(session shared.Session) *[]ShardingTopoShardInfo {
 	var shards []ShardingTopoShardInfo
-	err := session.DB("config").C("shards").Find(bson.M{}).All(&shards)
+	err := session.Find("config", "shards", bson.M{}).All(&shards)
 	if err != nil {
 		glog.Error("Failed to execute find query on 'config.shards'!")
 	}
 	return &shards
 }
 
-func GetTotalChunks(session *mgo.Session) float64 {
-	chunkCount, err := session.DB("config").C("chunks").Find(bson.M{}).Count()
+func GetTotalChunks(session shared.Session) float64 {
+	chunkCount, err := session.Find("config", "chunks", bson.M{}).Count()
 	if err != nil {
 		glog.Error("Failed to execute find query on 'config.chunks'!")
 	}
 	return float64(chunkCount)
 }
 
-func GetTotalChunksByShard(session *mgo.Session) *[]ShardingTopoChunkInfo {
+func GetTotalChunksByShard(session shared.Session) *[]ShardingTopoChunkInfo {
 	var results []ShardingTopoChunkInfo
-	err := session.DB("config").C("chunks").Pipe([]bson.M{{ "$group" : bson.M{ "_id" : "$shard", "count" : bson.M{ "$sum" : 1  } } }}).All(&results)
+	err := session.Pipe("config", "chunks", []bson.M{{"$group": bson.M{"_id": "$shard", "count": bson.M{"$sum": 1}}}}).All(&results)
 	if err != nil {
 		glog.Error("Failed to execute find query on 'config.chunks'!")
 	}
 	return &results
 }
 
-func GetTotalDatabases(session *mgo.Session) *[]ShardingTopoStatsTotalDatabases {
+func GetTotalDatabases(session shared.Session) *[]ShardingTopoStatsTotalDatabases {
 	results := []ShardingTopoStatsTotalDatabases{}
-	query := []bson.M{ { "$match" : bson.M{ "_id" : bson.M{ "$ne" : "admin" } } },  { "$group" : bson.M{ "_id" : "$partitioned", "total" : bson.M{ "$sum" : 1 } } } }
-	err := session.DB("config").C("databases").Pipe(query).All(&results)
+	query := []bson.M{{"$match": bson.M{"_id": bson.M{"$ne": "admin"}}}, {"$group": bson.M{"_id": "$partitioned", "total": bson.M{"$sum": 1}}}}
+	err := session.Pipe("config", "databases", query).All(&results)
 	if err != nil {
 		glog.Error("Failed to execute find query on 'config.databases'!")
 	}
 	return &results
 }
 
-func GetTotalShardedCollections(session *mgo.Session) float64 {
-	collCount, err := session.DB("config").C("collections").Find(bson.M{ "dropped" : false }).Count()
+func GetTotalShardedCollections(session shared.Session) float64 {
+	collCount, err := session.Find("config", "collections", bson.M{"dropped": false}).Count()
 	if err != nil {
 		glog.Error("Failed to execute find query on 'config.collections'!")
 	}
 	return float64(collCount)
 }
 
-func (status *ShardingTopoStats) Export(ch chan<- prometheus.Metric) {
+func (status *ShardingTopoStats) Export(ch *shared.MetricChannel) {
 	if status.Shards != nil {
 		var drainingShards float64 = 0
 		for _, shard := range *status.Shards {
@@ -122,58 +116,50 @@ func (status *ShardingTopoStats) Export(ch chan<- prometheus.Metric) {
 				drainingShards = drainingShards + 1
 			}
 		}
-		shardingTopoInfoDrainingShards.Set(drainingShards)
-		shardingTopoInfoTotalShards.Set(float64(len(*status.Shards)))
+		ch.Send(shardingTopoInfoDrainingShards, drainingShards)
+		ch.Send(shardingTopoInfoTotalShards, float64(len(*status.Shards)))
 	}
-	shardingTopoInfoTotalChunks.Set(status.TotalChunks)
-	shardingTopoInfoTotalCollections.Set(status.TotalCollections)
+	ch.Send(shardingTopoInfoTotalChunks, status.TotalChunks)
+	ch.Send(shardingTopoInfoTotalCollections, status.TotalCollections)
 
-	shardingTopoInfoTotalDatabases.WithLabelValues("partitioned").Set(0)
-	shardingTopoInfoTotalDatabases.WithLabelValues("unpartitioned").Set(0)
+	databases := map[string]float64{"partitioned": 0, "unpartitioned": 0}
 	if status.TotalDatabases != nil {
 		for _, item := range *status.TotalDatabases {
 			switch item.Partitioned {
-				case true:
-					shardingTopoInfoTotalDatabases.WithLabelValues("partitioned").Set(item.Total)
-				case false:
-					shardingTopoInfoTotalDatabases.WithLabelValues("unpartitioned").Set(item.Total)
+			case true:
+				databases["partitioned"] = item.Total
+			case false:
+				databases["unpartitioned"] = item.Total
 			}
 		}
 	}
+	for partitioned, total := range databases {
+		ch.Send(shardingTopoInfoTotalDatabases, total, partitioned)
+	}
 
 	if status.ShardChunks != nil {
 		// set all known shards to zero first so that shards with zero chunks are still displayed properly
-		for _, shard := range *status.Shards {
-			shardingTopoInfoShardChunks.WithLabelValues(shard.Shard).Set(0)
+		chunks := map[string]float64{}
+		if status.Shards != nil {
+			for _, shard := range *status.Shards {
+				chunks[shard.Shard] = 0
+			}
 		}
 		for _, shard := range *status.ShardChunks {
-			shardingTopoInfoShardChunks.WithLabelValues(shard.Shard).Set(shard.Chunks)
+			chunks[shard.Shard] = shard.Chunks
+		}
+		for shard, total := range chunks {
+			ch.Send(shardingTopoInfoShardChunks, total, shard)
 		}
 	}
-
-	shardingTopoInfoTotalShards.Collect(ch)
-	shardingTopoInfoDrainingShards.Collect(ch)
-	shardingTopoInfoTotalChunks.Collect(ch)
-	shardingTopoInfoShardChunks.Collect(ch)
-	shardingTopoInfoTotalCollections.Collect(ch)
-	shardingTopoInfoTotalDatabases.Collect(ch)
-}
-
-func (status *ShardingTopoStats) Describe(ch chan<- *prometheus.Desc) {
-	shardingTopoInfoTotalShards.Describe(ch)
-	shardingTopoInfoDrainingShards.Describe(ch)
-	shardingTopoInfoTotalChunks.Describe(ch)
-	shardingTopoInfoShardChunks.Describe(ch)
-	shardingTopoInfoTotalDatabases.Describe(ch)
-	shardingTopoInfoTotalCollections.Describe(ch)
 }
 
-func GetShardingTopoStatus(session *mgo.Session) *ShardingTopoStats {
+func GetShardingTopoStatus(session shared.Session) *ShardingTopoStats {
 	results := &ShardingTopoStats{}
 
 	results.Shards = GetShards(session)
 	results.TotalChunks = GetTotalChunks(session)
-	results.ShardChunks = GetTotalChunksByShard(session) 
+	results.ShardChunks = GetTotalChunksByShard(session)
 	results.TotalDatabases = GetTotalDatabases(session)
 	results.TotalCollections = GetTotalShardedCollections(session)
 
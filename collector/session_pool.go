@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	reconnectBackoffMin = 1 * time.Second
+	reconnectBackoffMax = 1 * time.Minute
+)
+
+var (
+	reconnectAttemptsTotal = shared.NewCounterDesc(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "exporter",
+		Name:      "reconnect_attempts_total",
+	}, []string{"result"})
+	dialDurationSeconds = shared.NewHistogramDesc(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "exporter",
+		Name:      "dial_duration_seconds",
+		Buckets:   prometheus.DefBuckets,
+	}, nil)
+)
+
+// sessionPool keeps a long-lived session to MongoDB. The session is health-checked before use,
+// and re-dialed with an exponential backoff when it is broken.
+type sessionPool struct {
+	opts shared.MongoSessionOpts
+
+	mu      sync.Mutex
+	session shared.Session
+	// generation counts the sessions dialed, it changes when the pooled session is replaced
+	generation int
+	backoff    time.Duration
+	nextDial   time.Time
+
+	// dial metrics, guarded by mu
+	dialSuccesses float64
+	dialFailures  float64
+	dialDuration  *shared.Histogram
+}
+
+func newSessionPool(opts shared.MongoSessionOpts) *sessionPool {
+	return &sessionPool{opts: opts, dialDuration: shared.NewHistogram(dialDurationSeconds)}
+}
+
+// Get returns a copy of the pooled session, or nil if MongoDB cannot be reached. The caller must close the copy.
+func (pool *sessionPool) Get() shared.Session {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.session != nil {
+		if pool.session.Ping() == nil {
+			return pool.session.Copy()
+		}
+		glog.Errorf("Lost connection to %s, reconnecting", shared.RedactMongoUri(pool.opts.URI))
+		pool.session.Close()
+		pool.session = nil
+	}
+
+	if time.Now().Before(pool.nextDial) {
+		glog.Infof("Not reconnecting to %s before %s", shared.RedactMongoUri(pool.opts.URI), pool.nextDial.Format(time.RFC3339))
+		return nil
+	}
+
+	start := time.Now()
+	session := shared.MongoSessionWithOpts(pool.opts)
+	pool.dialDuration.Observe(time.Since(start).Seconds())
+	if session == nil {
+		pool.dialFailures++
+		pool.backoff *= 2
+		if pool.backoff < reconnectBackoffMin {
+			pool.backoff = reconnectBackoffMin
+		}
+		if pool.backoff > reconnectBackoffMax {
+			pool.backoff = reconnectBackoffMax
+		}
+		pool.nextDial = time.Now().Add(pool.backoff)
+		return nil
+	}
+	pool.dialSuccesses++
+	pool.generation++
+
+	pool.backoff = 0
+	pool.nextDial = time.Time{}
+	pool.session = session
+	return session.Copy()
+}
+
+// Generation returns the generation of the pooled session, to detect that it was dialed again.
+func (pool *sessionPool) Generation() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.generation
+}
+
+// Close closes the pooled session.
+func (pool *sessionPool) Close() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.session != nil {
+		pool.session.Close()
+		pool.session = nil
+	}
+}
+
+// Export exports the connection metrics to prometheus.
+func (pool *sessionPool) Export(ch *shared.MetricChannel) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	ch.Send(reconnectAttemptsTotal, pool.dialSuccesses, "success")
+	ch.Send(reconnectAttemptsTotal, pool.dialFailures, "failure")
+	ch.SendHistogram(dialDurationSeconds, pool.dialDuration)
+}
@@ -0,0 +1,28 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+)
+
+func Test_SessionPoolBackoff(t *testing.T) {
+	pool := newSessionPool(shared.MongoSessionOpts{URI: "mongodb://localhost:27017/?unknownOption=1"})
+
+	if session := pool.Get(); session != nil {
+		t.Fatal("expected no session for an unparseable uri")
+	}
+	if pool.backoff != reconnectBackoffMin {
+		t.Errorf("expected backoff of %s, got %s", reconnectBackoffMin, pool.backoff)
+	}
+	if !pool.nextDial.After(time.Now()) {
+		t.Error("expected the next dial to be delayed")
+	}
+
+	// a second call inside the backoff window must not dial again
+	pool.Get()
+	if pool.backoff != reconnectBackoffMin {
+		t.Errorf("expected backoff to stay at %s, got %s", reconnectBackoffMin, pool.backoff)
+	}
+}
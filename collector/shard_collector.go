@@ -0,0 +1,197 @@
+package collector
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/elarasu/mongodb_exporter/collector/mongod"
+	"github.com/elarasu/mongodb_exporter/collector/mongos"
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// shardCollector collects the server and replica set status of a shard on behalf of a mongos
+// collector. Its metrics have a shard label.
+type shardCollector struct {
+	name     string
+	host     string
+	descs    *shared.Descs
+	sessions *sessionPool
+	// status exports the dynamic serverStatus metrics of the shard, nil when disabled
+	status *shared.StatusWalker
+
+	// server is the version and storage engine of the shard, detected once per session of the pool
+	mu     sync.Mutex
+	server shardServer
+}
+
+// shardServer is the version and storage engine of the server of a pooled session.
+type shardServer struct {
+	generation    int
+	version       string
+	storageEngine string
+}
+
+func newShardCollector(shard collector_mongos.ShardingTopoShardInfo, opts MongodbCollectorOpts) *shardCollector {
+	replicaSet, hosts := parseShardHost(shard.Host)
+	sessionOpts := shared.MongoSessionOpts{
+		URI:            opts.URI,
+		TLSConfig:      opts.TLSConfig,
+		ConnectTimeout: opts.ConnectTimeout,
+		SyncTimeout:    opts.SyncTimeout,
+		Driver:         opts.Driver,
+	}
+	if shared.IsSRVURI(opts.URI) {
+		// the shards are dialed with the options and TLS setting of the seedlist of the mongos
+		if resolution, err := shared.ResolveSRVURI(opts.URI, shared.DefaultResolver); err != nil {
+			glog.Errorf("Cannot resolve server url %s: %s", shared.RedactMongoUri(opts.URI), err)
+		} else {
+			sessionOpts = resolution.DialOpts(sessionOpts)
+		}
+	}
+	sessionOpts.URI = shared.URIWithHosts(sessionOpts.URI, hosts)
+	sessionOpts.ReplicaSet = replicaSet
+
+	labels := prometheus.Labels{"shard": shard.Shard}
+	for name, value := range opts.ConstLabels {
+		labels[name] = value
+	}
+
+	return &shardCollector{
+		name:     shard.Shard,
+		host:     shard.Host,
+		descs:    shared.NewDescs(labels),
+		sessions: newSessionPool(sessionOpts),
+		status:   newStatusWalker(opts, collector_mongod.ServerStatus{}),
+	}
+}
+
+// parseShardHost splits the host of a config.shards document, "replicaSet/host1:port,host2:port"
+// or "host:port", into the replica set name and the hosts.
+func parseShardHost(host string) (string, []string) {
+	replicaSet := ""
+	if i := strings.Index(host, "/"); i >= 0 {
+		replicaSet, host = host[:i], host[i+1:]
+	}
+	return replicaSet, strings.Split(host, ",")
+}
+
+// collect collects the metrics of the shard, and returns whether it succeeded.
+func (shard *shardCollector) collect(ch *shared.MetricChannel) bool {
+	ch = ch.WithDescs(shard.descs)
+	defer shard.sessions.Export(ch)
+
+	session := shard.sessions.Get()
+	if session == nil {
+		glog.Errorf("Cannot connect to shard %s (%s)", shard.name, shard.host)
+		ch.Send(up, 0)
+		return false
+	}
+	defer session.Close()
+	ch.Send(up, 1)
+	session.SetMode(shared.PrimaryPreferred)
+
+	caps := shard.capabilities(session)
+	ok := collectWith(ch, "serverStatus", func() bool {
+		glog.Infof("Collecting Server Status of shard %s", shard.name)
+		serverStatus := collector_mongod.GetServerStatus(session, caps)
+		if serverStatus == nil {
+			return false
+		}
+		if serverStatus.StorageEngine != nil {
+			shard.setStorageEngine(serverStatus.StorageEngine.Name)
+		}
+		serverStatus.Export(ch)
+		if shard.status != nil {
+			shard.status.Export(ch, serverStatus.Raw)
+		}
+		return true
+	})
+
+	if shared.IsGroupEnabled("replset") {
+		ok = collectWith(ch, "replSetGetStatus", func() bool {
+			glog.Infof("Collecting Replset Status of shard %s", shard.name)
+			replSetStatus := collector_mongod.GetReplSetStatus(session)
+			if replSetStatus == nil {
+				return false
+			}
+			replSetStatus.Export(ch)
+			return true
+		}) && ok
+	}
+
+	return ok
+}
+
+// capabilities returns the capabilities of the server of the shard. Its version is asked once per
+// session, and its storage engine is the one of the first serverStatus of the session.
+func (shard *shardCollector) capabilities(session shared.Session) *shared.ServerCapabilities {
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if generation := shard.sessions.Generation(); shard.server.version == "" || shard.server.generation != generation {
+		shard.server = shardServer{generation: generation}
+		if version, err := shared.MongoSessionServerVersion(session); err != nil {
+			glog.Errorf("Cannot get the version of shard %s: %s", shard.name, err)
+		} else {
+			shard.server.version = version
+		}
+	}
+	return shared.NewServerCapabilities(shard.server.version, shard.server.storageEngine)
+}
+
+// setStorageEngine records the storage engine of the server of the shard.
+func (shard *shardCollector) setStorageEngine(storageEngine string) {
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	shard.server.storageEngine = storageEngine
+}
+
+// shardCollectors keeps a collector per shard of the cluster, following the shards added and removed.
+type shardCollectors struct {
+	mu     sync.Mutex
+	shards map[string]*shardCollector
+}
+
+// update returns the collectors of the shards, creating the collectors of the new shards
+// and closing the collectors of the removed shards.
+func (collectors *shardCollectors) update(shards []collector_mongos.ShardingTopoShardInfo, opts MongodbCollectorOpts) []*shardCollector {
+	collectors.mu.Lock()
+	defer collectors.mu.Unlock()
+
+	if collectors.shards == nil {
+		collectors.shards = map[string]*shardCollector{}
+	}
+	current := map[string]*shardCollector{}
+	for _, shard := range shards {
+		collector, ok := collectors.shards[shard.Shard]
+		if !ok || collector.host != shard.Host {
+			collector = newShardCollector(shard, opts)
+		}
+		current[shard.Shard] = collector
+	}
+	for name, collector := range collectors.shards {
+		if current[name] != collector {
+			collector.sessions.Close()
+		}
+	}
+	collectors.shards = current
+
+	result := make([]*shardCollector, 0, len(current))
+	for _, collector := range current {
+		result = append(result, collector)
+	}
+	return result
+}
+
+// Close closes the sessions of every shard.
+func (collectors *shardCollectors) Close() {
+	collectors.mu.Lock()
+	defer collectors.mu.Unlock()
+
+	for _, collector := range collectors.shards {
+		collector.sessions.Close()
+	}
+	collectors.shards = nil
+}
@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/elarasu/mongodb_exporter/collector/mongos"
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func Test_ParseShardHost(t *testing.T) {
+	replicaSet, hosts := parseShardHost("rs0/h1:27017,h2:27017")
+	if replicaSet != "rs0" || !reflect.DeepEqual(hosts, []string{"h1:27017", "h2:27017"}) {
+		t.Errorf("unexpected replica set %q and hosts %v", replicaSet, hosts)
+	}
+
+	replicaSet, hosts = parseShardHost("h1:27018")
+	if replicaSet != "" || !reflect.DeepEqual(hosts, []string{"h1:27018"}) {
+		t.Errorf("unexpected replica set %q and hosts %v", replicaSet, hosts)
+	}
+}
+
+func Test_UpdateShardCollectors(t *testing.T) {
+	collectors := &shardCollectors{}
+	opts := MongodbCollectorOpts{URI: "mongodb://mongos:27017"}
+
+	shards := collectors.update([]collector_mongos.ShardingTopoShardInfo{
+		{Shard: "rs0", Host: "rs0/h1:27017"},
+		{Shard: "rs1", Host: "rs1/h2:27017"},
+	}, opts)
+	if len(shards) != 2 {
+		t.Fatalf("expected 2 shard collectors, got %d", len(shards))
+	}
+	rs0 := collectors.shards["rs0"]
+
+	shards = collectors.update([]collector_mongos.ShardingTopoShardInfo{
+		{Shard: "rs0", Host: "rs0/h1:27017"},
+	}, opts)
+	if len(shards) != 1 || shards[0] != rs0 {
+		t.Error("expected the collector of the remaining shard to be kept")
+	}
+	if rs0.sessions.opts.ReplicaSet != "rs0" || rs0.sessions.opts.URI != "mongodb://h1:27017" {
+		t.Errorf("unexpected session options %+v", rs0.sessions.opts)
+	}
+}
+
+func Test_ShardCapabilities(t *testing.T) {
+	shard := newShardCollector(collector_mongos.ShardingTopoShardInfo{Shard: "rs0", Host: "rs0/h1:27017"}, MongodbCollectorOpts{URI: "mongodb://mongos:27017"})
+	session := &sessiontest.Session{Commands: map[string]interface{}{
+		"admin.buildInfo":    bson.M{"version": "3.0.15"},
+		"admin.serverStatus": bson.M{"uptime": 60, "storageEngine": bson.M{"name": "mmapv1"}},
+	}}
+	shard.sessions.session, shard.sessions.generation = session, 1
+	defer shard.sessions.Close()
+
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		for range metrics {
+		}
+	}()
+	defer close(metrics)
+	ch := shared.NewDescs(nil).Channel(metrics)
+
+	shard.collect(ch)
+	shard.collect(ch)
+	buildInfos := 0
+	for _, run := range session.Runs {
+		if run == "admin.buildInfo" {
+			buildInfos++
+		}
+	}
+	if buildInfos != 1 {
+		t.Errorf("expected the version to be asked once per session, got %v", session.Runs)
+	}
+	caps := shard.capabilities(session)
+	if caps.Supports("serverStatus.wiredTiger") || !caps.Supports("serverStatus.backgroundFlushing") {
+		t.Errorf("expected the capabilities of MongoDB 3.0 with MMAPv1, got %+v", caps.Decisions())
+	}
+
+	// a new session asks the version again
+	shard.sessions.generation++
+	session.Commands["admin.buildInfo"] = bson.M{"version": "4.4.6"}
+	if caps := shard.capabilities(session); caps.Supports("serverStatus.backgroundFlushing") {
+		t.Errorf("expected the capabilities of MongoDB 4.4, got %+v", caps.Decisions())
+	}
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+
+	"github.com/golang/glog"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/yaml.v2"
+)
+
+// exporterConfig is the content of the -config.file YAML file. Every setting is optional:
+// command-line flags take precedence over environment variables, which take precedence over the file.
+type exporterConfig struct {
+	MongoDB     mongodbConfig         `yaml:"mongodb,omitempty"`
+	Web         webConfig             `yaml:"web,omitempty"`
+	Groups      []string              `yaml:"groups,omitempty"`
+	Collectors  collectorsConfig      `yaml:"collectors,omitempty"`
+	AuthModules map[string]authModule `yaml:"auth_modules,omitempty"`
+}
+
+// mongodbConfig is the MongoDB server of the /metrics endpoint.
+type mongodbConfig struct {
+	URI            string     `yaml:"uri,omitempty"`
+	Auth           authModule `yaml:",inline"`
+	ConnectTimeout string     `yaml:"connect_timeout,omitempty"`
+	SyncTimeout    string     `yaml:"sync_timeout,omitempty"`
+	Driver         string     `yaml:"driver,omitempty"`
+	// SRVMonitorHosts monitors every host of a mongodb+srv:// URI separately.
+	SRVMonitorHosts *bool `yaml:"srv_monitor_hosts,omitempty"`
+}
+
+// webConfig is the HTTP server of the exporter.
+type webConfig struct {
+	ListenAddress string  `yaml:"listen_address,omitempty"`
+	MetricsPath   string  `yaml:"metrics_path,omitempty"`
+	ProbePath     string  `yaml:"probe_path,omitempty"`
+	SSLCertFile   string  `yaml:"ssl_cert_file,omitempty"`
+	SSLKeyFile    string  `yaml:"ssl_key_file,omitempty"`
+	BasicAuth     webAuth `yaml:"basic_auth,omitempty"`
+}
+
+// collectorsConfig holds the per-collector options.
+type collectorsConfig struct {
+	Namespaces       namespacesConfig   `yaml:"namespaces,omitempty"`
+	CollectionsLimit *int               `yaml:"collections_limit,omitempty"`
+	CurrentOp        currentOpConfig    `yaml:"current_op,omitempty"`
+	Profile          profileConfig      `yaml:"profile,omitempty"`
+	Oplog            oplogConfig        `yaml:"oplog,omitempty"`
+	Shards           shardsConfig       `yaml:"shards,omitempty"`
+	ServerStatus     serverStatusConfig `yaml:"server_status,omitempty"`
+}
+
+// namespacesConfig is a namespace filter.
+type namespacesConfig struct {
+	Allow string `yaml:"allow,omitempty"`
+	Deny  string `yaml:"deny,omitempty"`
+}
+
+// currentOpConfig holds the options of the currentOp collector.
+type currentOpConfig struct {
+	Namespaces     namespacesConfig `yaml:"namespaces,omitempty"`
+	MinSecsRunning *int             `yaml:"min_secs_running,omitempty"`
+}
+
+// profileConfig holds the options of the profiler collector.
+type profileConfig struct {
+	Databases []string `yaml:"databases,omitempty"`
+}
+
+// oplogConfig holds the options of the oplog collector.
+type oplogConfig struct {
+	ScanLimit *int `yaml:"scan_limit,omitempty"`
+}
+
+// shardsConfig holds the options of the collection of the shards through a mongos.
+type shardsConfig struct {
+	FanOut *bool `yaml:"fan_out,omitempty"`
+}
+
+// serverStatusConfig holds the options of the dynamic serverStatus metrics.
+type serverStatusConfig struct {
+	Dynamic *bool  `yaml:"dynamic,omitempty"`
+	Prefix  string `yaml:"prefix,omitempty"`
+	Deny    string `yaml:"deny,omitempty"`
+	// Prefixes replace the name of top-level serverStatus sections in the metric names, e.g. wiredTiger: wt.
+	Prefixes map[string]string `yaml:"prefixes,omitempty"`
+}
+
+// loadConfig reads and validates the configuration file. An empty path gives an empty configuration.
+func loadConfig(path string) (*exporterConfig, error) {
+	cfg := &exporterConfig{}
+	if path == "" {
+		return cfg, nil
+	}
+
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file: %s", err)
+	}
+	if err := yaml.Unmarshal(bytes, cfg); err != nil {
+		return nil, fmt.Errorf("cannot parse config file: %s", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %s", path, err)
+	}
+	return cfg, nil
+}
+
+// validate checks the settings of the configuration and loads its TLS certificates.
+func (cfg *exporterConfig) validate() error {
+	if cfg.MongoDB.URI != "" && !shared.IsSRVURI(cfg.MongoDB.URI) {
+		if _, err := mgo.ParseURL(cfg.MongoDB.URI); err != nil {
+			return fmt.Errorf("mongodb.uri: %s", err)
+		}
+	}
+	for name, value := range map[string]string{
+		"mongodb.connect_timeout": cfg.MongoDB.ConnectTimeout,
+		"mongodb.sync_timeout":    cfg.MongoDB.SyncTimeout,
+	} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+	}
+	switch cfg.MongoDB.Driver {
+	case "", shared.DriverMgo, shared.DriverOfficial:
+	default:
+		return fmt.Errorf("mongodb.driver: unknown driver %q", cfg.MongoDB.Driver)
+	}
+	if cfg.MongoDB.Auth.TLS.Enabled {
+		if _, err := cfg.MongoDB.Auth.TLS.config(); err != nil {
+			return fmt.Errorf("mongodb.tls: %s", err)
+		}
+	}
+
+	if (cfg.Web.SSLCertFile == "") != (cfg.Web.SSLKeyFile == "") {
+		return errors.New("web: ssl_cert_file and ssl_key_file must be set together")
+	}
+
+	for _, group := range cfg.Groups {
+		if !shared.KnownGroups[group] {
+			return fmt.Errorf("groups: unknown group %q", group)
+		}
+	}
+
+	if _, err := shared.NewNamespaceFilter(cfg.Collectors.Namespaces.Allow, cfg.Collectors.Namespaces.Deny); err != nil {
+		return fmt.Errorf("collectors.namespaces: %s", err)
+	}
+	if _, err := shared.NewNamespaceFilter(cfg.Collectors.CurrentOp.Namespaces.Allow, cfg.Collectors.CurrentOp.Namespaces.Deny); err != nil {
+		return fmt.Errorf("collectors.current_op.namespaces: %s", err)
+	}
+
+	if _, err := regexp.Compile(cfg.Collectors.ServerStatus.Deny); err != nil {
+		return fmt.Errorf("collectors.server_status.deny: %s", err)
+	}
+
+	if err := prepareAuthModules(cfg.AuthModules); err != nil {
+		return fmt.Errorf("auth_modules: %s", err)
+	}
+	return nil
+}
+
+// flagValues returns the settings of the configuration which are also command-line flags, by flag name.
+func (cfg *exporterConfig) flagValues() map[string]string {
+	values := map[string]string{
+		"mongodb.uri":                               cfg.MongoDB.URI,
+		"mongodb.connect-timeout":                   cfg.MongoDB.ConnectTimeout,
+		"mongodb.sync-timeout":                      cfg.MongoDB.SyncTimeout,
+		"mongodb.driver":                            cfg.MongoDB.Driver,
+		"mongodb.auth-mechanism":                    cfg.MongoDB.Auth.AuthMechanism,
+		"mongodb.tls-ca-file":                       cfg.MongoDB.Auth.TLS.CAFile,
+		"mongodb.tls-certificate-key-file":          cfg.MongoDB.Auth.TLS.CertificateKeyFile,
+		"mongodb.tls-certificate-key-file-password": cfg.MongoDB.Auth.TLS.KeyPassword,
+		"web.listen-address":                        cfg.Web.ListenAddress,
+		"web.metrics-path":                          cfg.Web.MetricsPath,
+		"web.probe-path":                            cfg.Web.ProbePath,
+		"web.ssl-cert-file":                         cfg.Web.SSLCertFile,
+		"web.ssl-key-file":                          cfg.Web.SSLKeyFile,
+		"groups.enabled":                            strings.Join(cfg.Groups, ","),
+		"namespaces.allow":                          cfg.Collectors.Namespaces.Allow,
+		"namespaces.deny":                           cfg.Collectors.Namespaces.Deny,
+		"currentop.namespaces.allow":                cfg.Collectors.CurrentOp.Namespaces.Allow,
+		"currentop.namespaces.deny":                 cfg.Collectors.CurrentOp.Namespaces.Deny,
+		"profile.databases":                         strings.Join(cfg.Collectors.Profile.Databases, ","),
+		"serverstatus.dynamic-prefix":               cfg.Collectors.ServerStatus.Prefix,
+		"serverstatus.dynamic-deny":                 cfg.Collectors.ServerStatus.Deny,
+	}
+	if cfg.MongoDB.Auth.TLS.Enabled {
+		values["mongodb.tls"] = "true"
+	}
+	if cfg.MongoDB.Auth.TLS.AllowInvalidHostnames {
+		values["mongodb.tls-allow-invalid-hostnames"] = "true"
+	}
+	if cfg.MongoDB.SRVMonitorHosts != nil {
+		values["mongodb.srv-monitor-hosts"] = strconv.FormatBool(*cfg.MongoDB.SRVMonitorHosts)
+	}
+	if cfg.Collectors.CollectionsLimit != nil {
+		values["collections.limit"] = strconv.Itoa(*cfg.Collectors.CollectionsLimit)
+	}
+	if cfg.Collectors.Oplog.ScanLimit != nil {
+		values["oplog.scan-limit"] = strconv.Itoa(*cfg.Collectors.Oplog.ScanLimit)
+	}
+	if cfg.Collectors.CurrentOp.MinSecsRunning != nil {
+		values["currentop.min-secs-running"] = strconv.Itoa(*cfg.Collectors.CurrentOp.MinSecsRunning)
+	}
+	if cfg.Collectors.Shards.FanOut != nil {
+		values["mongos.shard-fan-out"] = strconv.FormatBool(*cfg.Collectors.Shards.FanOut)
+	}
+	if cfg.Collectors.ServerStatus.Dynamic != nil {
+		values["serverstatus.dynamic"] = strconv.FormatBool(*cfg.Collectors.ServerStatus.Dynamic)
+	}
+	for name, value := range values {
+		if value == "" {
+			delete(values, name)
+		}
+	}
+	return values
+}
+
+// commandLineFlags are the names of the flags set on the command line, read before applyConfig sets any.
+var commandLineFlags map[string]bool
+
+// explicitFlags returns the names of the flags set on the command line.
+func explicitFlags() map[string]bool {
+	if commandLineFlags == nil {
+		commandLineFlags = map[string]bool{}
+		flag.Visit(func(f *flag.Flag) {
+			commandLineFlags[f.Name] = true
+		})
+	}
+	return commandLineFlags
+}
+
+// appliedFlags are the flags set by the last applyConfig.
+var appliedFlags = map[string]bool{}
+
+// flagSnapshot holds the values of the flags and the applied flags, to undo applyConfig.
+type flagSnapshot struct {
+	values  map[string]string
+	applied map[string]bool
+}
+
+// snapshotFlags returns the current values of the flags.
+func snapshotFlags() *flagSnapshot {
+	snapshot := &flagSnapshot{values: map[string]string{}, applied: map[string]bool{}}
+	flag.VisitAll(func(f *flag.Flag) {
+		snapshot.values[f.Name] = f.Value.String()
+	})
+	for name := range appliedFlags {
+		snapshot.applied[name] = true
+	}
+	return snapshot
+}
+
+// restore sets the flags back to the values of the snapshot.
+func (snapshot *flagSnapshot) restore() {
+	flag.VisitAll(func(f *flag.Flag) {
+		if value := snapshot.values[f.Name]; f.Value.String() != value {
+			f.Value.Set(value)
+		}
+	})
+	appliedFlags = snapshot.applied
+}
+
+// applyConfig sets the flags which are not set on the command line, first from the environment variables,
+// then from the configuration file. When the configuration file is reloaded, the flags it no longer
+// sets are reset to their default value.
+func applyConfig(cfg *exporterConfig) error {
+	explicit := explicitFlags()
+
+	values := cfg.flagValues()
+	if uri := mongodbEnvUri(); uri != "" {
+		values["mongodb.uri"] = uri
+	}
+
+	for name := range appliedFlags {
+		if _, ok := values[name]; !ok {
+			if err := flag.Set(name, flag.Lookup(name).DefValue); err != nil {
+				return fmt.Errorf("cannot reset %s: %s", name, err)
+			}
+			delete(appliedFlags, name)
+		}
+	}
+	for name, value := range values {
+		if explicit[name] {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return fmt.Errorf("cannot set %s: %s", name, err)
+		}
+		appliedFlags[name] = true
+	}
+	return nil
+}
+
+// mongodbEnvUri returns the MongoDB URI given by the MONGODB_URI environment variable,
+// or by the deprecated MONGODB_URL environment variable.
+func mongodbEnvUri() string {
+	if uri := os.Getenv("MONGODB_URI"); uri != "" {
+		return uri
+	}
+	if uri := os.Getenv("MONGODB_URL"); uri != "" {
+		glog.Warning("The MONGODB_URL environment variable is deprecated, use MONGODB_URI")
+		return uri
+	}
+	return ""
+}
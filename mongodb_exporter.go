@@ -2,53 +2,104 @@ package main
 
 import (
 	"crypto/tls"
+	_ "embed"
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"regexp"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/elarasu/mongodb_exporter/collector"
 	"github.com/elarasu/mongodb_exporter/shared"
 
+	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"gopkg.in/yaml.v2"
 )
 
-func mongodbDefaultUri() string {
-	if u := os.Getenv("MONGODB_URL"); u != "" {
-		return u
-	}
-	return "mongodb://localhost:27017"
-}
-
 var (
 	version          string = "unknown"
 	versionGitCommit string = "unknown"
 
 	doPrintVersion    = flag.Bool("version", false, "Print version info and exit.")
+	configFileFlag    = flag.String("config.file", "", "Path to YAML configuration file. Command-line flags and environment variables take precedence over it.")
+	configCheckFlag   = flag.Bool("config.check", false, "Validate the configuration file and exit.")
 	listenAddressFlag = flag.String("web.listen-address", ":9104", "Address on which to expose metrics and web interface.")
 	metricsPathFlag   = flag.String("web.metrics-path", "/metrics", "Path under which to expose metrics.")
+	probePathFlag     = flag.String("web.probe-path", "/probe", "Path under which to expose the metrics of the MongoDB server given by the target parameter.")
 	webAuthFile       = flag.String("web.auth-file", "", "Path to YAML file with server_user, server_password options for http basic auth (overrides HTTP_AUTH env var).")
 	sslCertFile       = flag.String("web.ssl-cert-file", "", "Path to SSL certificate file.")
 	sslKeyFile        = flag.String("web.ssl-key-file", "", "Path to SSL key file.")
 
-	mongodbURIFlag    = flag.String("mongodb.uri", mongodbDefaultUri(), "Mongodb URI, format: [mongodb://][user:pass@]host1[:port1][,host2[:port2],...][/database][?options]")
-	enabledGroupsFlag = flag.String("groups.enabled", "asserts,durability,background_flushing,connections,extra_info,global_lock,index_counters,network,op_counters,op_counters_repl,memory,locks,metrics", "Comma-separated list of groups to use, for more info see: docs.mongodb.org/manual/reference/command/serverStatus/")
+	mongodbURIFlag       = flag.String("mongodb.uri", "mongodb://localhost:27017", "Mongodb URI, format: [mongodb://][user:pass@]host1[:port1][,host2[:port2],...][/database][?options] or mongodb+srv://[user:pass@]host[/database][?options] (overrides MONGODB_URI env var).")
+	srvMonitorHostsFlag  = flag.Bool("mongodb.srv-monitor-hosts", false, "With a mongodb+srv:// URI, monitor every host of the seedlist separately, labeled by address, instead of the seedlist as a whole.")
+	connectTimeoutFlag   = flag.Duration("mongodb.connect-timeout", 5*time.Second, "Timeout of connecting to MongoDB.")
+	syncTimeoutFlag      = flag.Duration("mongodb.sync-timeout", 1*time.Minute, "Time to wait for MongoDB to answer a command.")
+	authMechanismFlag    = flag.String("mongodb.auth-mechanism", "", "Authentication mechanism of the MongoDB connection, e.g. MONGODB-X509 to authenticate with the subject of the TLS client certificate (default: the one of the URI).")
+	tlsFlag              = flag.Bool("mongodb.tls", false, "Connect to MongoDB over TLS. Implied by the other -mongodb.tls-* flags.")
+	tlsCAFileFlag        = flag.String("mongodb.tls-ca-file", "", "Path to the PEM bundle of the certificate authorities of the MongoDB servers (default: the system ones).")
+	tlsCertKeyFileFlag   = flag.String("mongodb.tls-certificate-key-file", "", "Path to the PEM file with the client certificate and private key of the MongoDB connection.")
+	tlsCertKeyPassFlag   = flag.String("mongodb.tls-certificate-key-file-password", "", "Passphrase of the encrypted private key of -mongodb.tls-certificate-key-file, a PKCS#8 or legacy PEM encrypted key.")
+	tlsAllowInvalidHosts = flag.Bool("mongodb.tls-allow-invalid-hostnames", false, "Verify the certificate of the MongoDB servers but not their host name.")
+	driverFlag           = flag.String("mongodb.driver", shared.DriverMgo, "MongoDB driver: mgo, or official for the official MongoDB Go driver, required by MongoDB 5.1 and later.")
+	probeConfigFile      = flag.String("probe.config-file", "", "Path to YAML file with the auth modules used by the probe endpoint.")
+	probeIdleTimeoutFlag = flag.Duration("probe.idle-timeout", 10*time.Minute, "Time after which the connection to a probed target which is no longer scraped is closed.")
+	namespacesAllowFlag  = flag.String("namespaces.allow", "", "Regular expression of the namespaces (database.collection) the per-collection collectors are limited to.")
+	namespacesDenyFlag   = flag.String("namespaces.deny", "", "Regular expression of the namespaces (database.collection) the per-collection collectors skip.")
+	collectionsLimitFlag = flag.Int("collections.limit", 200, "Maximum number of collections to collect per-collection metrics for (0 = no limit).")
+	oplogScanLimitFlag   = flag.Int("oplog.scan-limit", 10000, "Maximum number of oplog changes read per scrape to compute the oplog rates, the rates of the last changes are exported beyond it.")
+	currentOpAllowFlag   = flag.String("currentop.namespaces.allow", "", "Regular expression of the namespaces (database.collection) the currentOp collector is limited to.")
+	currentOpDenyFlag    = flag.String("currentop.namespaces.deny", "", "Regular expression of the namespaces (database.collection) the currentOp collector skips.")
+	currentOpMinSecsFlag = flag.Int("currentop.min-secs-running", 1, "Minimum number of seconds an operation must have been running for to be collected by the currentOp collector.")
+	profileDatabasesFlag = flag.String("profile.databases", "", "Comma-separated list of the databases whose system.profile entries are read by the profile group (default: all databases).")
+	shardFanOutFlag      = flag.Bool("mongos.shard-fan-out", false, "When connected to a mongos, also collect the server and replica set status of every shard, labeled by shard.")
+	dynamicStatusFlag    = flag.Bool("serverstatus.dynamic", false, "Also export the numeric serverStatus fields the other metrics do not cover, named after their path.")
+	dynamicPrefixFlag    = flag.String("serverstatus.dynamic-prefix", "mongodb_ss", "Prefix of the names of the dynamic serverStatus metrics.")
+	dynamicDenyFlag      = flag.String("serverstatus.dynamic-deny", "", "Regular expression of the dotted paths of the serverStatus fields (e.g. wiredTiger.cache.bytes read into cache) not to export as dynamic metrics.")
+	enabledGroupsFlag    = flag.String("groups.enabled", "asserts,durability,background_flushing,connections,extra_info,global_lock,index_counters,network,op_counters,op_counters_repl,memory,locks,metrics,cursors,storage_engine,in_memory,rocksdb,wiredtiger,queues,replset,oplog,sharding", "Comma-separated list of groups to use, for more info see: docs.mongodb.org/manual/reference/command/serverStatus/")
 )
 
-var landingPage = []byte(`<html>
+// groupsCatalog is the metadata of the metrics: their help text, type, labels and group.
+//
+//go:embed groups.yml
+var groupsCatalog []byte
+
+// loadCatalog parses the metric catalog, and fails when metrics disagree with it, like Test_GroupsCatalog.
+func loadCatalog() (*shared.Catalog, error) {
+	catalog, err := shared.ParseCatalog(groupsCatalog, collector.Namespaces())
+	if err != nil {
+		return nil, err
+	}
+	if errs := catalog.Check(); len(errs) > 0 {
+		messages := []string{}
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+		return nil, fmt.Errorf("the metric catalog disagrees with the collectors: %s", strings.Join(messages, "; "))
+	}
+	return catalog, nil
+}
+
+func landingPage() []byte {
+	return []byte(`<html>
 <head><title>MongoDB exporter</title></head>
 <body>
 <h1>MongoDB exporter</h1>
 <p><a href='` + *metricsPathFlag + `'>Metrics</a></p>
+<p><a href='` + *probePathFlag + `?target=localhost:27017'>Probe localhost:27017</a></p>
 </body>
 </html>
 `)
+}
 
 func printVersion() {
-	fmt.Printf("mongodb_exporter version: %s, git commit hash: %s\n", version, versionGitCommit)
+	fmt.Printf("mongodb_exporter version: %s, git commit hash: %s, go version: %s\n", version, versionGitCommit, runtime.Version())
 }
 
 type webAuth struct {
@@ -73,104 +124,189 @@ func (h *basicAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-func prometheusHandler() http.Handler {
+// loadWebAuth returns the HTTP basic authentication credentials of the -web.auth-file file,
+// else of the HTTP_AUTH env var, else of the configuration file.
+func loadWebAuth(config *exporterConfig) (*webAuth, error) {
 	cfg := &webAuth{}
 	httpAuth := os.Getenv("HTTP_AUTH")
 	if *webAuthFile != "" {
 		bytes, err := ioutil.ReadFile(*webAuthFile)
 		if err != nil {
-			panic(fmt.Sprintf("Cannot read auth file: %s", err))
+			return nil, fmt.Errorf("cannot read auth file: %s", err)
 		}
 		if err := yaml.Unmarshal(bytes, cfg); err != nil {
-			panic(fmt.Sprintf("Cannot parse auth file: %s", err))
+			return nil, fmt.Errorf("cannot parse auth file: %s", err)
 		}
 	} else if httpAuth != "" {
 		data := strings.SplitN(httpAuth, ":", 2)
 		if len(data) != 2 || data[0] == "" || data[1] == "" {
-			panic("HTTP_AUTH should be formatted as user:password")
+			return nil, errors.New("HTTP_AUTH should be formatted as user:password")
 		}
 		cfg.User = data[0]
 		cfg.Password = data[1]
+	} else {
+		*cfg = config.Web.BasicAuth
 	}
-
-	handler := prometheus.Handler()
 	if cfg.User != "" && cfg.Password != "" {
-		handler = &basicAuthHandler{handler: handler.ServeHTTP, user: cfg.User, password: cfg.Password}
 		fmt.Println("HTTP basic authentication is enabled")
 	}
 
+	return cfg, nil
+}
+
+// wrap protects the handler with HTTP basic authentication, if configured.
+func (cfg *webAuth) wrap(handler http.Handler) http.Handler {
+	if cfg.User != "" && cfg.Password != "" {
+		return &basicAuthHandler{handler: handler.ServeHTTP, user: cfg.User, password: cfg.Password}
+	}
 	return handler
 }
 
-func startWebServer() {
-	printVersion()
+// newRegistry returns the registry of the metrics endpoint, with the build info, process and Go runtime metrics of the exporter.
+func newRegistry() *prometheus.Registry {
+	buildInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "mongodb_exporter",
+		Name:      "build_info",
+		Help:      "The version, git revision and Go version the exporter was built with, always 1",
+	}, []string{"version", "revision", "goversion"})
+	buildInfo.WithLabelValues(version, versionGitCommit, runtime.Version()).Set(1)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		buildInfo,
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		prometheus.NewGoCollector(),
+	)
+	return registry
+}
+
+func prometheusHandler(auth *webAuth, registry *prometheus.Registry) http.Handler {
+	return auth.wrap(promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}))
+}
 
-	uri := os.Getenv("MONGODB_URI")
-	if uri != "" {
-		mongodbURIFlag = &uri
+// newProbeHandler returns the handler of the probe endpoint with the auth modules of the
+// -probe.config-file file, else of the configuration file.
+func newProbeHandler(config *exporterConfig) (*probeHandler, error) {
+	cfg := &probeConfig{AuthModules: config.AuthModules}
+	if *probeConfigFile != "" {
+		var err error
+		cfg, err = loadProbeConfig(*probeConfigFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	opts, err := collectorOpts(config)
+	if err != nil {
+		return nil, err
 	}
+	return &probeHandler{config: cfg, opts: opts, idleTimeout: *probeIdleTimeoutFlag}, nil
+}
 
-	handler := prometheusHandler()
+// mongodbAuth returns the auth module of the MongoDB connection, with the mechanism given by the flags.
+func mongodbAuth(config *exporterConfig) authModule {
+	auth := config.MongoDB.Auth
+	auth.AuthMechanism = *authMechanismFlag
+	return auth
+}
 
-	registerCollector()
+// mongodbTLSConfig returns the TLS config of the MongoDB connection given by the flags and the
+// configuration file, nil when TLS is not enabled.
+func mongodbTLSConfig(config *exporterConfig) (*tls.Config, error) {
+	t := config.MongoDB.Auth.TLS
+	t.CAFile, t.CertificateKeyFile, t.KeyPassword = *tlsCAFileFlag, *tlsCertKeyFileFlag, *tlsCertKeyPassFlag
+	t.AllowInvalidHostnames = *tlsAllowInvalidHosts
+	t.Enabled = *tlsFlag || t.CAFile != "" || t.CertificateKeyFile != "" || t.CertFile != "" || t.AllowInvalidHostnames
+	if !t.Enabled {
+		return nil, nil
+	}
+	return t.config()
+}
 
-	if *sslCertFile != "" && *sslKeyFile == "" || *sslCertFile == "" && *sslKeyFile != "" {
-		panic("One of the flags -web.ssl-cert or -web.ssl-key is missed to enable HTTPS/TLS")
+// collectorOpts returns the options of the collectors given by the flags and the configuration file.
+func collectorOpts(config *exporterConfig) (collector.MongodbCollectorOpts, error) {
+	namespaceFilter, err := shared.NewNamespaceFilter(*namespacesAllowFlag, *namespacesDenyFlag)
+	if err != nil {
+		return collector.MongodbCollectorOpts{}, fmt.Errorf("cannot parse namespace filter: %s", err)
 	}
-	ssl := false
-	if *sslCertFile != "" && *sslKeyFile != "" {
-		if _, err := os.Stat(*sslCertFile); os.IsNotExist(err) {
-			panic(fmt.Sprintf("SSL certificate file does not exist: %s", *sslCertFile))
-		}
-		if _, err := os.Stat(*sslKeyFile); os.IsNotExist(err) {
-			panic(fmt.Sprintf("SSL key file does not exist: %s", *sslKeyFile))
-		}
-		ssl = true
-		fmt.Println("HTTPS/TLS is enabled")
-	}
-
-	fmt.Printf("Listening on %s\n", *listenAddressFlag)
-	if ssl {
-		// https
-		mux := http.NewServeMux()
-		mux.Handle(*metricsPathFlag, handler)
-		mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
-			w.Header().Add("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
-			w.Write(landingPage)
-		})
-		tlsCfg := &tls.Config{
-			MinVersion:               tls.VersionTLS12,
-			CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
-			PreferServerCipherSuites: true,
-			CipherSuites: []uint16{
-				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
-				tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_RSA_WITH_AES_256_CBC_SHA,
-			},
+	currentOpFilter, err := shared.NewNamespaceFilter(*currentOpAllowFlag, *currentOpDenyFlag)
+	if err != nil {
+		return collector.MongodbCollectorOpts{}, fmt.Errorf("cannot parse currentOp namespace filter: %s", err)
+	}
+	switch *driverFlag {
+	case shared.DriverMgo, shared.DriverOfficial:
+	default:
+		return collector.MongodbCollectorOpts{}, fmt.Errorf("unknown MongoDB driver %q", *driverFlag)
+	}
+	tlsConfig, err := mongodbTLSConfig(config)
+	if err != nil {
+		return collector.MongodbCollectorOpts{}, fmt.Errorf("cannot load the TLS certificates of the MongoDB connection: %s", err)
+	}
+	var dynamicStatus *shared.StatusWalkerOpts
+	if *dynamicStatusFlag {
+		dynamicStatus = &shared.StatusWalkerOpts{
+			Prefix:   *dynamicPrefixFlag,
+			Prefixes: config.Collectors.ServerStatus.Prefixes,
 		}
-		srv := &http.Server{
-			Addr:         *listenAddressFlag,
-			Handler:      mux,
-			TLSConfig:    tlsCfg,
-			TLSNextProto: make(map[string]func(*http.Server, *tls.Conn, http.Handler), 0),
+		if *dynamicDenyFlag != "" {
+			if dynamicStatus.Deny, err = regexp.Compile(*dynamicDenyFlag); err != nil {
+				return collector.MongodbCollectorOpts{}, fmt.Errorf("cannot parse serverStatus deny list: %s", err)
+			}
 		}
-		panic(srv.ListenAndServeTLS(*sslCertFile, *sslKeyFile))
-	} else {
-		// http
-		http.Handle(*metricsPathFlag, handler)
-		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-			w.Write(landingPage)
-		})
-		panic(http.ListenAndServe(*listenAddressFlag, nil))
 	}
+	var profileDatabases []string
+	if *profileDatabasesFlag != "" {
+		profileDatabases = strings.Split(*profileDatabasesFlag, ",")
+	}
+	return collector.MongodbCollectorOpts{
+		URI:                     mongodbAuth(config).applyTo(*mongodbURIFlag),
+		TLSConfig:               tlsConfig,
+		ConnectTimeout:          *connectTimeoutFlag,
+		SyncTimeout:             *syncTimeoutFlag,
+		Driver:                  *driverFlag,
+		NamespaceFilter:         namespaceFilter,
+		CollectionLimit:         *collectionsLimitFlag,
+		CurrentOpFilter:         currentOpFilter,
+		CurrentOpMinSecsRunning: *currentOpMinSecsFlag,
+		ProfileDatabases:        profileDatabases,
+		OplogScanLimit:          *oplogScanLimitFlag,
+		ShardFanOut:             *shardFanOutFlag,
+		DynamicServerStatus:     dynamicStatus,
+	}, nil
 }
 
-func registerCollector() {
-	mongodbCollector := collector.NewMongodbCollector(collector.MongodbCollectorOpts{
-		URI: *mongodbURIFlag,
-	})
-	prometheus.MustRegister(mongodbCollector)
+// registerCollector registers the collectors of -mongodb.uri, one per host of the seedlist with
+// -mongodb.srv-monitor-hosts, and returns them.
+func registerCollector(registry prometheus.Registerer, config *exporterConfig) ([]*collector.MongodbCollector, error) {
+	opts, err := collectorOpts(config)
+	if err != nil {
+		return nil, err
+	}
+	if !*srvMonitorHostsFlag || !shared.IsSRVURI(opts.URI) {
+		mongodbCollector := collector.NewMongodbCollector(opts)
+		if err := registry.Register(mongodbCollector); err != nil {
+			return nil, err
+		}
+		return []*collector.MongodbCollector{mongodbCollector}, nil
+	}
+
+	// one collector per host of the seedlist, dialed directly with the options of the seedlist
+	resolution, err := shared.ResolveSRVURI(opts.URI, shared.DefaultResolver)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve %s: %s", shared.RedactMongoUri(opts.URI), err)
+	}
+	collectors := []*collector.MongodbCollector{}
+	for _, host := range resolution.Hosts {
+		dialOpts := resolution.HostDialOpts(host, shared.MongoSessionOpts{TLSConfig: opts.TLSConfig})
+		hostOpts := opts
+		hostOpts.URI, hostOpts.TLSConfig = dialOpts.URI, dialOpts.TLSConfig
+		hostOpts.ConstLabels = prometheus.Labels{"address": host}
+		mongodbCollector := collector.NewMongodbCollector(hostOpts)
+		if err := registry.Register(mongodbCollector); err != nil {
+			return collectors, err
+		}
+		collectors = append(collectors, mongodbCollector)
+	}
+	return collectors, nil
 }
 
 func main() {
@@ -181,9 +317,40 @@ func main() {
 		os.Exit(0)
 	}
 
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		glog.Flush()
+		os.Exit(1)
+	}
+	glog.Flush()
+}
+
+// run loads the configuration and serves the endpoints until the exporter is stopped.
+func run() error {
+	catalog, err := loadCatalog()
+	if err != nil {
+		return err
+	}
+	catalog.Apply()
+
+	config, err := loadConfig(*configFileFlag)
+	if err != nil {
+		return err
+	}
+	if *configCheckFlag {
+		if *configFileFlag == "" {
+			return errors.New("-config.check requires -config.file")
+		}
+		fmt.Printf("Configuration file %s is valid\n", *configFileFlag)
+		return nil
+	}
+	if err := applyConfig(config); err != nil {
+		return err
+	}
+
 	shared.ParseEnabledGroups(*enabledGroupsFlag)
 
 	fmt.Println("### Warning: the exporter is in beta/experimental state and field names are very\n### likely to change in the future and features may change or get removed!\n### See: https://github.com/elarasu/mongodb_exporter for updates")
 
-	startWebServer()
+	return startWebServer(config)
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/elarasu/mongodb_exporter/collector"
+	"github.com/elarasu/mongodb_exporter/shared"
+)
+
+func Test_NewRegistry(t *testing.T) {
+	mfs, err := newRegistry().Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, mf := range mfs {
+		names[mf.GetName()] = true
+		if mf.GetName() != "mongodb_exporter_build_info" {
+			continue
+		}
+		labels := map[string]string{}
+		for _, label := range mf.Metric[0].Label {
+			labels[label.GetName()] = label.GetValue()
+		}
+		if labels["version"] != version || labels["revision"] != versionGitCommit || labels["goversion"] != runtime.Version() {
+			t.Errorf("unexpected build info labels %v", labels)
+		}
+	}
+	for _, name := range []string{"mongodb_exporter_build_info", "go_goroutines", "process_start_time_seconds"} {
+		if !names[name] {
+			t.Errorf("expected the %s metric", name)
+		}
+	}
+}
+
+func Test_GroupsCatalog(t *testing.T) {
+	catalog, err := shared.ParseCatalog(groupsCatalog, collector.Namespaces())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every metric of the collectors must be in groups.yml, with the same type and labels
+	for _, err := range catalog.Check() {
+		t.Error(err)
+	}
+
+	catalog.Apply()
+	for _, def := range shared.MetricDescs() {
+		if def.Help == "" {
+			t.Errorf("%s has no help", def.FQName)
+		}
+	}
+}
+
+func Test_DefaultGroups(t *testing.T) {
+	defaults := map[string]bool{}
+	for _, group := range strings.Split(flag.Lookup("groups.enabled").DefValue, ",") {
+		defaults[group] = true
+	}
+	// the serverStatus sub-documents are collected by default, the costly collectors are opt-in
+	for _, group := range []string{"locks", "wiredtiger", "queues", "replset", "oplog"} {
+		if !defaults[group] {
+			t.Errorf("expected the %s group to be enabled by default", group)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/collector"
+	"github.com/elarasu/mongodb_exporter/shared"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"gopkg.in/yaml.v2"
+)
+
+// probeConfig is the content of the -probe.config-file YAML file.
+type probeConfig struct {
+	AuthModules map[string]authModule `yaml:"auth_modules"`
+}
+
+// authModule holds the credentials and TLS options used to connect to a probed target.
+type authModule struct {
+	Username      string        `yaml:"username,omitempty"`
+	Password      string        `yaml:"password,omitempty"`
+	AuthSource    string        `yaml:"auth_source,omitempty"`
+	AuthMechanism string        `yaml:"auth_mechanism,omitempty"`
+	TLS           authModuleTLS `yaml:"tls,omitempty"`
+
+	tlsConfig *tls.Config
+}
+
+// authModuleTLS holds the TLS options of an auth module.
+type authModuleTLS struct {
+	Enabled               bool   `yaml:"enabled,omitempty"`
+	CAFile                string `yaml:"ca_file,omitempty"`
+	CertificateKeyFile    string `yaml:"certificate_key_file,omitempty"`
+	CertFile              string `yaml:"cert_file,omitempty"`
+	KeyFile               string `yaml:"key_file,omitempty"`
+	KeyPassword           string `yaml:"key_password,omitempty"`
+	AllowInvalidHostnames bool   `yaml:"allow_invalid_hostnames,omitempty"`
+	InsecureSkipVerify    bool   `yaml:"insecure_skip_verify,omitempty"`
+}
+
+// loadProbeConfig reads and validates the auth modules from a YAML file.
+func loadProbeConfig(path string) (*probeConfig, error) {
+	cfg := &probeConfig{AuthModules: map[string]authModule{}}
+	if path == "" {
+		return cfg, nil
+	}
+
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read probe config file: %s", err)
+	}
+	if err := yaml.Unmarshal(bytes, cfg); err != nil {
+		return nil, fmt.Errorf("cannot parse probe config file: %s", err)
+	}
+
+	if err := prepareAuthModules(cfg.AuthModules); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// prepareAuthModules loads the TLS certificates of the auth modules.
+func prepareAuthModules(modules map[string]authModule) error {
+	for name, module := range modules {
+		if module.TLS.Enabled {
+			tlsConfig, err := module.TLS.config()
+			if err != nil {
+				return fmt.Errorf("auth module %s: %s", name, err)
+			}
+			module.tlsConfig = tlsConfig
+			modules[name] = module
+		}
+	}
+	return nil
+}
+
+// config builds the client TLS config of the auth module, which reloads its certificates when they change.
+func (t authModuleTLS) config() (*tls.Config, error) {
+	return shared.NewTLSConfig(shared.TLSOptions{
+		CAFile:                t.CAFile,
+		CertificateKeyFile:    t.CertificateKeyFile,
+		CertFile:              t.CertFile,
+		KeyFile:               t.KeyFile,
+		KeyPassword:           t.KeyPassword,
+		AllowInvalidHostnames: t.AllowInvalidHostnames,
+		InsecureSkipVerify:    t.InsecureSkipVerify,
+	})
+}
+
+// applyTo adds the credentials of the auth module to uri, unless uri already has credentials.
+func (module authModule) applyTo(uri string) string {
+	scheme := "mongodb://"
+	if shared.IsSRVURI(uri) {
+		scheme = "mongodb+srv://"
+	} else if !strings.HasPrefix(uri, scheme) {
+		uri = scheme + uri
+	}
+	hosts := strings.TrimPrefix(uri, scheme)
+	if module.Username != "" && !strings.Contains(strings.SplitN(hosts, "/", 2)[0], "@") {
+		credentials := url.QueryEscape(module.Username)
+		if module.Password != "" {
+			credentials += ":" + url.QueryEscape(module.Password)
+		}
+		uri = scheme + credentials + "@" + hosts
+	}
+
+	options := url.Values{}
+	if module.AuthSource != "" && !strings.Contains(uri, "authSource=") {
+		options.Set("authSource", module.AuthSource)
+	}
+	if module.AuthMechanism != "" && !strings.Contains(uri, "authMechanism=") {
+		options.Set("authMechanism", module.AuthMechanism)
+	}
+	if len(options) == 0 {
+		return uri
+	}
+	switch {
+	case strings.Contains(uri, "?"):
+		return uri + "&" + options.Encode()
+	case strings.Contains(strings.TrimPrefix(uri, scheme), "/"):
+		return uri + "?" + options.Encode()
+	default:
+		return uri + "/?" + options.Encode()
+	}
+}
+
+// probeHostPattern matches a host[:port] of a probe target: a host name, an IPv4 address or a
+// bracketed IPv6 address.
+var probeHostPattern = regexp.MustCompile(`^([A-Za-z0-9._-]+|\[[0-9A-Fa-f:.]+\])(:[0-9]{1,5})?$`)
+
+// isValidProbeTarget returns whether target is a host[:port] or a comma-separated list of them, so
+// that the credentials, database and options of the connection only come from the auth module.
+func isValidProbeTarget(target string) bool {
+	for _, host := range strings.Split(target, ",") {
+		if !probeHostPattern.MatchString(host) {
+			return false
+		}
+	}
+	return true
+}
+
+// uri returns the URI of the hosts of a probe target with the credentials and options of the auth module.
+func (module authModule) uri(hosts string) string {
+	uri := "mongodb://"
+	if module.Username != "" {
+		uri += url.QueryEscape(module.Username)
+		if module.Password != "" {
+			uri += ":" + url.QueryEscape(module.Password)
+		}
+		uri += "@"
+	}
+	uri += hosts + "/"
+
+	options := url.Values{}
+	if module.AuthSource != "" {
+		options.Set("authSource", module.AuthSource)
+	}
+	if module.AuthMechanism != "" {
+		options.Set("authMechanism", module.AuthMechanism)
+	}
+	if len(options) > 0 {
+		uri += "?" + options.Encode()
+	}
+	return uri
+}
+
+// probeKey identifies the collector of a probed target.
+type probeKey struct {
+	target, module string
+}
+
+// probeCollector is the collector of a probed target, kept between the scrapes so that the groups
+// which compare two scrapes (oplog rates, sharding activity, profile) are exported.
+type probeCollector struct {
+	collector *collector.MongodbCollector
+	lastUsed  time.Time
+}
+
+// probeHandler serves the metrics of the MongoDB server given by the target parameter,
+// connecting with the auth module given by the auth_module parameter.
+type probeHandler struct {
+	config *probeConfig
+	opts   collector.MongodbCollectorOpts
+	// idleTimeout is the time after which the collector of a target which is no longer scraped is closed
+	idleTimeout time.Duration
+
+	mu         sync.Mutex
+	collectors map[probeKey]*probeCollector
+}
+
+// collector returns the collector of a target, creating it on the first scrape, and closes the
+// collectors which have not been used for idleTimeout.
+func (h *probeHandler) collector(key probeKey, module authModule) *collector.MongodbCollector {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for k, c := range h.collectors {
+		if now.Sub(c.lastUsed) > h.idleTimeout {
+			c.collector.Close()
+			delete(h.collectors, k)
+		}
+	}
+	if h.collectors == nil {
+		h.collectors = map[probeKey]*probeCollector{}
+	}
+	c, ok := h.collectors[key]
+	if !ok {
+		opts := h.opts
+		// the target is dialed with the TLS settings of the auth module only, without TLS when it has none
+		opts.URI, opts.TLSConfig = module.uri(key.target), module.tlsConfig
+		c = &probeCollector{collector: collector.NewMongodbCollector(opts)}
+		h.collectors[key] = c
+	}
+	c.lastUsed = now
+	return c.collector
+}
+
+// Close closes the collectors of every target.
+func (h *probeHandler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, c := range h.collectors {
+		c.collector.Close()
+	}
+	h.collectors = nil
+}
+
+func (h *probeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	target := params.Get("target")
+	if target == "" {
+		http.Error(w, "Target parameter is missing", http.StatusBadRequest)
+		return
+	}
+	if !isValidProbeTarget(target) {
+		http.Error(w, fmt.Sprintf("Invalid target %q, expected host[:port][,host[:port]...]", target), http.StatusBadRequest)
+		return
+	}
+
+	moduleName := params.Get("auth_module")
+	if moduleName == "" {
+		moduleName = "default"
+	}
+	module, ok := h.config.AuthModules[moduleName]
+	if !ok && params.Get("auth_module") != "" {
+		http.Error(w, fmt.Sprintf("Unknown auth module %q", moduleName), http.StatusBadRequest)
+		return
+	}
+	mongodbCollector := h.collector(probeKey{target: target, module: moduleName}, module)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(mongodbCollector)
+
+	mfs, err := registry.Gather()
+	if err != nil {
+		glog.Errorf("Error gathering metrics of %s: %s", target, err)
+		if len(mfs) == 0 {
+			http.Error(w, "An error has occurred while gathering metrics", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	contentType := expfmt.Negotiate(r.Header)
+	w.Header().Set("Content-Type", string(contentType))
+	enc := expfmt.NewEncoder(w, contentType)
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			glog.Errorf("Error encoding metrics of %s: %s", target, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/collector"
+	"github.com/elarasu/mongodb_exporter/shared"
+
+	"github.com/golang/glog"
+)
+
+// shutdownTimeout is the time given to the in-flight requests to finish when the exporter is stopped.
+const shutdownTimeout = 30 * time.Second
+
+// exporterState holds the handlers and collectors built from the configuration, which are
+// built again when the configuration is reloaded.
+type exporterState struct {
+	metrics    http.Handler
+	probe      http.Handler
+	collectors []*collector.MongodbCollector
+	// prober keeps the collectors of the probed targets
+	prober *probeHandler
+
+	// requests counts the in-flight requests, the collectors are closed once they are served
+	requests sync.WaitGroup
+}
+
+// newExporterState builds the handlers and collectors of the configuration.
+func newExporterState(config *exporterConfig) (*exporterState, error) {
+	auth, err := loadWebAuth(config)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := newRegistry()
+	state := &exporterState{metrics: prometheusHandler(auth, registry)}
+	state.collectors, err = registerCollector(registry, config)
+	if err == nil {
+		state.prober, err = newProbeHandler(config)
+	}
+	if err != nil {
+		state.closeCollectors()
+		return nil, err
+	}
+	state.probe = auth.wrap(state.prober)
+	return state, nil
+}
+
+// Close waits for the in-flight requests, then closes the MongoDB sessions of the collectors.
+func (state *exporterState) Close() {
+	state.requests.Wait()
+	state.closeCollectors()
+}
+
+func (state *exporterState) closeCollectors() {
+	for _, mongodbCollector := range state.collectors {
+		mongodbCollector.Close()
+	}
+	if state.prober != nil {
+		state.prober.Close()
+	}
+}
+
+// exporterServer serves the endpoints of the current state, and reloads it on SIGHUP.
+type exporterServer struct {
+	mu    sync.Mutex
+	state *exporterState
+	// webCert is the certificate of the HTTPS server, nil when it serves HTTP
+	webCert *tls.Certificate
+}
+
+// acquire returns the current state, counting a request in flight until requests.Done is called.
+func (server *exporterServer) acquire() *exporterState {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.state.requests.Add(1)
+	return server.state
+}
+
+// handler serves an endpoint of the current state.
+func (server *exporterServer) handler(endpoint func(*exporterState) http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := server.acquire()
+		defer state.requests.Done()
+		endpoint(state).ServeHTTP(w, r)
+	})
+}
+
+// getCertificate returns the current certificate of the HTTPS server.
+func (server *exporterServer) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.webCert, nil
+}
+
+// loadWebCert loads the certificate of -web.ssl-cert-file and -web.ssl-key-file, nil when HTTPS is not enabled.
+func loadWebCert() (*tls.Certificate, error) {
+	if (*sslCertFile == "") != (*sslKeyFile == "") {
+		return nil, errors.New("-web.ssl-cert-file and -web.ssl-key-file must be set together to enable HTTPS/TLS")
+	}
+	if *sslCertFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(*sslCertFile, *sslKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load the SSL certificate: %s", err)
+	}
+	return &cert, nil
+}
+
+// reload reads the configuration file, the auth files and the certificates again, and replaces the
+// current state once they are all valid. The in-flight requests are served by the previous state,
+// whose MongoDB sessions are closed afterwards. The listen address and the metrics and probe paths
+// cannot be changed without a restart.
+func (server *exporterServer) reload() error {
+	config, err := loadConfig(*configFileFlag)
+	if err != nil {
+		return err
+	}
+	listenAddress, metricsPath, probePath := *listenAddressFlag, *metricsPathFlag, *probePathFlag
+	// the state is built from the flags, which are set back to their previous values unless it can be built
+	previousFlags := snapshotFlags()
+	state, webCert, err := server.load(config)
+	if err != nil {
+		previousFlags.restore()
+		return err
+	}
+	if *listenAddressFlag != listenAddress || *metricsPathFlag != metricsPath || *probePathFlag != probePath {
+		glog.Warning("The listen address and the metrics and probe paths are not reloaded, restart the exporter to change them")
+	}
+	shared.ParseEnabledGroups(*enabledGroupsFlag)
+
+	server.mu.Lock()
+	previous := server.state
+	server.state, server.webCert = state, webCert
+	server.mu.Unlock()
+
+	go previous.Close()
+	return nil
+}
+
+// load applies the configuration to the flags, and builds the state and loads the certificate they give.
+func (server *exporterServer) load(config *exporterConfig) (*exporterState, *tls.Certificate, error) {
+	if err := applyConfig(config); err != nil {
+		return nil, nil, err
+	}
+	webCert, err := loadWebCert()
+	if err != nil {
+		return nil, nil, err
+	}
+	if (webCert == nil) != (server.webCert == nil) {
+		return nil, nil, errors.New("HTTPS/TLS cannot be enabled or disabled without a restart")
+	}
+	state, err := newExporterState(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return state, webCert, nil
+}
+
+// shutdown stops accepting requests, waits up to shutdownTimeout for the in-flight requests, and
+// closes the MongoDB sessions.
+func (server *exporterServer) shutdown(srv *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(ctx)
+
+	server.mu.Lock()
+	state := server.state
+	server.mu.Unlock()
+	if err != nil {
+		// the remaining requests are cut short
+		state.closeCollectors()
+		return fmt.Errorf("cannot finish the in-flight requests: %s", err)
+	}
+	state.Close()
+	return nil
+}
+
+// startWebServer serves the endpoints until SIGTERM or SIGINT, and reloads the configuration on SIGHUP.
+func startWebServer(config *exporterConfig) error {
+	printVersion()
+
+	webCert, err := loadWebCert()
+	if err != nil {
+		return err
+	}
+	state, err := newExporterState(config)
+	if err != nil {
+		return err
+	}
+	server := &exporterServer{state: state, webCert: webCert}
+
+	mux := http.NewServeMux()
+	mux.Handle(*metricsPathFlag, server.handler(func(state *exporterState) http.Handler { return state.metrics }))
+	mux.Handle(*probePathFlag, server.handler(func(state *exporterState) http.Handler { return state.probe }))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if webCert != nil {
+			w.Header().Add("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		}
+		w.Write(landingPage())
+	})
+	srv := &http.Server{
+		Addr:    *listenAddressFlag,
+		Handler: mux,
+	}
+
+	serve := srv.ListenAndServe
+	if webCert != nil {
+		fmt.Println("HTTPS/TLS is enabled")
+		srv.TLSConfig = &tls.Config{
+			MinVersion:               tls.VersionTLS12,
+			CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
+			PreferServerCipherSuites: true,
+			CipherSuites: []uint16{
+				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+				tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+				tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+				tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+			},
+			GetCertificate: server.getCertificate,
+		}
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler), 0)
+		serve = func() error { return srv.ListenAndServeTLS("", "") }
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(signals)
+
+	fmt.Printf("Listening on %s\n", *listenAddressFlag)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- serve()
+	}()
+
+	for {
+		select {
+		case err := <-errs:
+			server.state.closeCollectors()
+			return fmt.Errorf("cannot serve on %s: %s", *listenAddressFlag, err)
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				if err := server.reload(); err != nil {
+					glog.Errorf("Cannot reload the configuration, keeping the previous one: %s", err)
+				} else {
+					glog.Info("Reloaded the configuration")
+				}
+				continue
+			}
+			glog.Infof("Received %s, shutting down", sig)
+			return server.shutdown(srv)
+		}
+	}
+}
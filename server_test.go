@@ -0,0 +1,161 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+)
+
+func writeConfigFile(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ReloadConfig(t *testing.T) {
+	file, err := ioutil.TempFile("", "mongodb_exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+	previousConfigFile := *configFileFlag
+	*configFileFlag = file.Name()
+	defer func() { *configFileFlag = previousConfigFile }()
+
+	// nothing listens on port 1, so that the scrapes fail right away
+	writeConfigFile(t, file.Name(), `
+mongodb:
+  uri: mongodb://127.0.0.1:1
+  connect_timeout: 100ms
+web:
+  basic_auth:
+    server_user: prometheus
+    server_password: s3cr3t
+`)
+	config, err := loadConfig(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	state, err := newExporterState(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &exporterServer{state: state}
+	metrics := server.handler(func(state *exporterState) http.Handler { return state.metrics })
+
+	status := func() int {
+		recorder := httptest.NewRecorder()
+		metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		return recorder.Code
+	}
+	if code := status(); code != http.StatusUnauthorized {
+		t.Errorf("expected the metrics to require authentication, got %d", code)
+	}
+
+	writeConfigFile(t, file.Name(), `
+mongodb:
+  uri: mongodb://127.0.0.1:1
+  connect_timeout: 100ms
+`)
+	if err := server.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if code := status(); code != http.StatusOK {
+		t.Errorf("expected the reloaded configuration to disable authentication, got %d", code)
+	}
+
+	writeConfigFile(t, file.Name(), `
+mongodb:
+  driver: unknown
+`)
+	if err := server.reload(); err == nil {
+		t.Error("expected an invalid configuration not to be reloaded")
+	}
+	if server.state == nil || status() != http.StatusOK {
+		t.Error("expected the previous configuration to be kept")
+	}
+	server.state.Close()
+}
+
+func Test_ReloadConfigFailureKeepsFlags(t *testing.T) {
+	file, err := ioutil.TempFile("", "mongodb_exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+	previousConfigFile := *configFileFlag
+	*configFileFlag = file.Name()
+	previousFlags := snapshotFlags()
+	defer func() {
+		previousFlags.restore()
+		shared.ParseEnabledGroups(*enabledGroupsFlag)
+		*configFileFlag = previousConfigFile
+	}()
+
+	writeConfigFile(t, file.Name(), `
+mongodb:
+  uri: mongodb://127.0.0.1:1
+  connect_timeout: 100ms
+groups: [connections]
+collectors:
+  namespaces:
+    allow: ^shop\.
+`)
+	state, err := newExporterState(&exporterConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &exporterServer{state: state}
+	if err := server.reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the certificate files do not exist, so that the reload fails once the flags are set
+	writeConfigFile(t, file.Name(), `
+mongodb:
+  uri: mongodb://127.0.0.1:1
+groups: [oplog]
+collectors:
+  namespaces:
+    allow: ^logs\.
+web:
+  ssl_cert_file: /nonexistent/cert.pem
+  ssl_key_file: /nonexistent/key.pem
+`)
+	if err := server.reload(); err == nil {
+		t.Fatal("expected the reload of missing certificates to fail")
+	}
+	if *namespacesAllowFlag != `^shop\.` || *enabledGroupsFlag != "connections" || *sslCertFile != "" || *connectTimeoutFlag != 100*time.Millisecond {
+		t.Errorf("expected the flags to be unchanged, got namespaces.allow %q, groups.enabled %q, web.ssl-cert-file %q and mongodb.connect-timeout %s",
+			*namespacesAllowFlag, *enabledGroupsFlag, *sslCertFile, *connectTimeoutFlag)
+	}
+	if !shared.IsGroupEnabled("connections") || shared.IsGroupEnabled("oplog") {
+		t.Error("expected the enabled groups to be unchanged")
+	}
+
+	// the flags set by the configuration file are reloaded too
+	writeConfigFile(t, file.Name(), `
+mongodb:
+  uri: mongodb://127.0.0.1:1
+collectors:
+  namespaces:
+    allow: ^logs\.
+`)
+	if err := server.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if *namespacesAllowFlag != `^logs\.` || *connectTimeoutFlag != 5*time.Second {
+		t.Errorf("expected the flags of the reloaded configuration, got namespaces.allow %q and mongodb.connect-timeout %s", *namespacesAllowFlag, *connectTimeoutFlag)
+	}
+	server.state.Close()
+}
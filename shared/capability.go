@@ -0,0 +1,97 @@
+package shared
+
+import (
+	"sort"
+	"strings"
+)
+
+// Capability describes the servers which support a sub-collector or a serverStatus field, so that
+// the collectors skip it on the other servers instead of failing on every scrape.
+type Capability struct {
+	// Name is the name of the sub-collector, e.g. indexStats, or of the serverStatus field, e.g. serverStatus.wiredTiger.
+	Name string
+	// MinVersion is the first version which supports it, MaxVersion the first version which does not (zero = no bound).
+	MinVersion Version
+	MaxVersion Version
+	// StorageEngines are the storage engines which support it (empty = all).
+	StorageEngines []string
+}
+
+// capabilities are the registered capabilities by name.
+var capabilities = map[string]Capability{}
+
+// RegisterCapability registers the servers which support a sub-collector or a serverStatus field.
+func RegisterCapability(capability Capability) {
+	capabilities[capability.Name] = capability
+}
+
+// CapabilityDecision tells whether a server supports a capability, and why.
+type CapabilityDecision struct {
+	Name      string
+	Supported bool
+	Reason    string
+}
+
+// ServerCapabilities decides the capabilities of a server from its version and storage engine.
+// A nil ServerCapabilities supports everything.
+type ServerCapabilities struct {
+	version       Version
+	versionKnown  bool
+	storageEngine string
+}
+
+// NewServerCapabilities returns the capabilities of a server. When the version cannot be parsed,
+// e.g. "unknown", every capability is supported; when the storage engine is empty, the capabilities
+// are decided from the version only.
+func NewServerCapabilities(version, storageEngine string) *ServerCapabilities {
+	v, err := ParseVersion(version)
+	return &ServerCapabilities{version: v, versionKnown: err == nil, storageEngine: storageEngine}
+}
+
+// Decide decides whether the server supports a capability. Unregistered capabilities are supported.
+func (caps *ServerCapabilities) Decide(name string) CapabilityDecision {
+	capability, ok := capabilities[name]
+	if !ok {
+		return CapabilityDecision{Name: name, Supported: true, Reason: "supported"}
+	}
+	if !caps.versionKnown {
+		return CapabilityDecision{Name: name, Supported: true, Reason: "unknown server version"}
+	}
+	if !capability.MinVersion.IsZero() && !caps.version.AtLeast(capability.MinVersion) {
+		return CapabilityDecision{Name: name, Reason: "requires MongoDB " + capability.MinVersion.String() + " or later"}
+	}
+	if !capability.MaxVersion.IsZero() && caps.version.AtLeast(capability.MaxVersion) {
+		return CapabilityDecision{Name: name, Reason: "removed in MongoDB " + capability.MaxVersion.String()}
+	}
+	if len(capability.StorageEngines) > 0 {
+		if caps.storageEngine == "" {
+			return CapabilityDecision{Name: name, Supported: true, Reason: "unknown storage engine"}
+		}
+		supported := false
+		for _, engine := range capability.StorageEngines {
+			supported = supported || engine == caps.storageEngine
+		}
+		if !supported {
+			return CapabilityDecision{Name: name, Reason: "requires the " + strings.Join(capability.StorageEngines, " or ") + " storage engine"}
+		}
+	}
+	return CapabilityDecision{Name: name, Supported: true, Reason: "supported"}
+}
+
+// Supports returns whether the server supports a capability.
+func (caps *ServerCapabilities) Supports(name string) bool {
+	if caps == nil {
+		return true
+	}
+	return caps.Decide(name).Supported
+}
+
+// Decisions returns the decisions of every registered capability, sorted by name.
+func (caps *ServerCapabilities) Decisions() []CapabilityDecision {
+	decisions := make([]CapabilityDecision, 0, len(capabilities))
+	for name := range capabilities {
+		decisions = append(decisions, caps.Decide(name))
+	}
+	sort.Slice(decisions, func(i, j int) bool { return decisions[i].Name < decisions[j].Name })
+	return decisions
+}
@@ -0,0 +1,39 @@
+package shared
+
+import (
+	"testing"
+)
+
+func Test_ServerCapabilities(t *testing.T) {
+	RegisterCapability(Capability{Name: "test.min", MinVersion: Version{Major: 3, Minor: 2}})
+	RegisterCapability(Capability{Name: "test.max", MaxVersion: Version{Major: 4, Minor: 2}})
+	RegisterCapability(Capability{Name: "test.engine", StorageEngines: []string{"mmapv1"}})
+	defer func() {
+		delete(capabilities, "test.min")
+		delete(capabilities, "test.max")
+		delete(capabilities, "test.engine")
+	}()
+
+	tests := []struct {
+		version, storageEngine string
+		expected               map[string]bool
+	}{
+		{"3.0.15", "mmapv1", map[string]bool{"test.min": false, "test.max": true, "test.engine": true}},
+		{"4.2.0-rc1", "wiredTiger", map[string]bool{"test.min": true, "test.max": false, "test.engine": false}},
+		{"4.0", "", map[string]bool{"test.min": true, "test.max": true, "test.engine": true}},
+		{"unknown", "wiredTiger", map[string]bool{"test.min": true, "test.max": true, "test.engine": true}},
+	}
+	for _, test := range tests {
+		caps := NewServerCapabilities(test.version, test.storageEngine)
+		for name, expected := range test.expected {
+			if decision := caps.Decide(name); decision.Supported != expected {
+				t.Errorf("%s on %s %s: expected %v, got %v (%s)", name, test.version, test.storageEngine, expected, decision.Supported, decision.Reason)
+			}
+		}
+	}
+
+	var nilCaps *ServerCapabilities
+	if !nilCaps.Supports("test.min") || !NewServerCapabilities("3.0", "").Supports("unregistered") {
+		t.Error("nil capabilities and unregistered capabilities should be supported")
+	}
+}
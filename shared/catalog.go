@@ -0,0 +1,199 @@
+package shared
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v2"
+)
+
+// catalogMetadataKey is the key of the metadata of an entry of the catalog.
+const catalogMetadataKey = "metadata"
+
+// AlwaysGroup is the catalog group of the metrics which are exported whatever the enabled groups.
+const AlwaysGroup = "always"
+
+// catalogTypes are the metric types of the catalog.
+var catalogTypes = map[string]bool{"counter": true, "gauge": true, "histogram": true, "untyped": true}
+
+// catalogEntry is the metadata of an entry of the catalog, or of one of its metrics or label values.
+type catalogEntry struct {
+	Help   string   `yaml:"help"`
+	Type   string   `yaml:"type"`
+	Labels []string `yaml:"labels"`
+	Group  string   `yaml:"group"`
+}
+
+// CatalogMetric is a metric of the catalog.
+type CatalogMetric struct {
+	// Name is the name of the metric without its namespace, e.g. asserts_total.
+	Name string
+	// Type is counter, gauge, histogram or untyped.
+	Type   string
+	Help   string
+	Labels []string
+	// Group is the group of -groups.enabled which gates the export of the metric, AlwaysGroup when
+	// no group does.
+	Group string
+}
+
+// Catalog is the metadata of the metrics, read from groups.yml. Every entry of the catalog is either:
+//
+//   - a metric, whose metadata type is counter, gauge, histogram or untyped,
+//   - a metric with labels, whose metadata type is counter_vec, gauge_vec, histogram_vec or
+//     untyped_vec, and whose other keys document the values of its first label,
+//   - a set of metrics, whose metadata type is metrics, and whose other keys are the metrics,
+//     named <entry>_<key>.
+//
+// Every metric belongs to a group, given by the metadata of its entry or, for a set of metrics, by
+// the metric itself.
+//
+// The names of the metrics are relative to the namespace of their collector, so that the metrics
+// of mongod and mongos share their entry.
+type Catalog struct {
+	namespaces []string
+	metrics    map[string]*CatalogMetric
+}
+
+// ParseCatalog parses a catalog. The namespaces are removed from the names of the metrics to look
+// them up, the first one which matches is removed.
+func ParseCatalog(data []byte, namespaces []string) (*Catalog, error) {
+	entries := map[string]map[string]catalogEntry{}
+	if err := yaml.UnmarshalStrict(data, &entries); err != nil {
+		return nil, fmt.Errorf("cannot parse the metric catalog: %s", err)
+	}
+
+	catalog := &Catalog{namespaces: namespaces, metrics: map[string]*CatalogMetric{}}
+	add := func(metric *CatalogMetric) error {
+		if !catalogTypes[metric.Type] {
+			return fmt.Errorf("%s: unknown type %q", metric.Name, metric.Type)
+		}
+		if metric.Help == "" {
+			return fmt.Errorf("%s: no help", metric.Name)
+		}
+		if metric.Group == "" {
+			return fmt.Errorf("%s: no group", metric.Name)
+		}
+		if _, ok := catalog.metrics[metric.Name]; ok {
+			return fmt.Errorf("%s: defined twice", metric.Name)
+		}
+		catalog.metrics[metric.Name] = metric
+		return nil
+	}
+	for name, entry := range entries {
+		metadata, ok := entry[catalogMetadataKey]
+		if !ok {
+			return nil, fmt.Errorf("%s: no metadata", name)
+		}
+		delete(entry, catalogMetadataKey)
+
+		var err error
+		switch {
+		case metadata.Type == "metrics":
+			for key, child := range entry {
+				group := child.Group
+				if group == "" {
+					group = metadata.Group
+				}
+				err = add(&CatalogMetric{Name: name + "_" + key, Type: child.Type, Help: child.Help, Labels: child.Labels, Group: group})
+				if err != nil {
+					break
+				}
+			}
+		case strings.HasSuffix(metadata.Type, "_vec"):
+			if len(metadata.Labels) == 0 {
+				return nil, fmt.Errorf("%s: no labels", name)
+			}
+			err = add(&CatalogMetric{Name: name, Type: strings.TrimSuffix(metadata.Type, "_vec"), Help: metadata.Help, Labels: metadata.Labels, Group: metadata.Group})
+		default:
+			if len(entry) > 0 {
+				return nil, fmt.Errorf("%s: a %s has no label values", name, metadata.Type)
+			}
+			err = add(&CatalogMetric{Name: name, Type: metadata.Type, Help: metadata.Help, Labels: metadata.Labels, Group: metadata.Group})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return catalog, nil
+}
+
+// relativeName returns the name of the metric without its namespace.
+func (catalog *Catalog) relativeName(fqName string) string {
+	for _, namespace := range catalog.namespaces {
+		if strings.HasPrefix(fqName, namespace+"_") {
+			return strings.TrimPrefix(fqName, namespace+"_")
+		}
+	}
+	return fqName
+}
+
+// Lookup returns the catalog metric of the metric, nil when it is not in the catalog.
+func (catalog *Catalog) Lookup(fqName string) *CatalogMetric {
+	return catalog.metrics[catalog.relativeName(fqName)]
+}
+
+// metricType returns the catalog type of the metric definition.
+func metricType(def *MetricDesc) string {
+	switch {
+	case def.Buckets != nil:
+		return "histogram"
+	case def.ValueType == prometheus.CounterValue:
+		return "counter"
+	case def.ValueType == prometheus.GaugeValue:
+		return "gauge"
+	default:
+		return "untyped"
+	}
+}
+
+// Check returns the metric definitions which are missing from the catalog or disagree with it, the
+// catalog metrics which are not defined, and those whose group no collector registered.
+func (catalog *Catalog) Check() []error {
+	errs := []error{}
+	defined := map[string]bool{}
+	for _, def := range MetricDescs() {
+		metric := catalog.Lookup(def.FQName)
+		if metric == nil {
+			errs = append(errs, fmt.Errorf("%s is missing from the catalog", def.FQName))
+			continue
+		}
+		defined[metric.Name] = true
+		if typ := metricType(def); typ != metric.Type {
+			errs = append(errs, fmt.Errorf("%s is a %s, the catalog says %s", def.FQName, typ, metric.Type))
+		}
+		if !reflect.DeepEqual(def.Labels, metric.Labels) && len(def.Labels)+len(metric.Labels) > 0 {
+			errs = append(errs, fmt.Errorf("%s has the labels %v, the catalog says %v", def.FQName, def.Labels, metric.Labels))
+		}
+	}
+	for name, metric := range catalog.metrics {
+		if !defined[name] {
+			errs = append(errs, fmt.Errorf("%s is in the catalog but no collector defines it", name))
+		}
+		if metric.Group != AlwaysGroup && !KnownGroups[metric.Group] {
+			errs = append(errs, fmt.Errorf("%s belongs to the unknown group %s", name, metric.Group))
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errs
+}
+
+// Apply sets the help text and the group of the metric definitions from the catalog, the collectors
+// built afterwards describe the metrics with it and only export the metrics of the enabled groups.
+func (catalog *Catalog) Apply() {
+	metricDescsMutex.Lock()
+	defer metricDescsMutex.Unlock()
+
+	for _, def := range metricDescs {
+		if metric := catalog.Lookup(def.FQName); metric != nil {
+			def.Help = metric.Help
+			def.Group = metric.Group
+			if def.Group == AlwaysGroup {
+				def.Group = ""
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package shared
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const testCatalog = `
+instance:
+  metadata:
+    help: "Information about the server instance."
+    type: metrics
+    group: always
+  uptime_seconds:
+    help: "The uptime of the server."
+    type: counter
+
+asserts_total:
+  metadata:
+    help: "The number of asserts by type."
+    labels:
+    - type
+    type: counter_vec
+    group: asserts
+  regular:
+    help: "The number of regular assertions."
+
+up:
+  metadata:
+    help: "Whether the server could be reached."
+    type: gauge
+    group: always
+`
+
+func Test_ParseCatalog(t *testing.T) {
+	catalog, err := ParseCatalog([]byte(testCatalog), []string{"mongodb_mongod", "mongodb_mongos", "mongodb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for fqName, expected := range map[string]*CatalogMetric{
+		"mongodb_mongod_instance_uptime_seconds": {Name: "instance_uptime_seconds", Type: "counter", Help: "The uptime of the server.", Group: "always"},
+		"mongodb_mongos_asserts_total":           {Name: "asserts_total", Type: "counter", Help: "The number of asserts by type.", Labels: []string{"type"}, Group: "asserts"},
+		"mongodb_up":                             {Name: "up", Type: "gauge", Help: "Whether the server could be reached.", Group: "always"},
+		"mongodb_mongod_asserts_regular":         nil,
+		"mongodb_mongod_connections":             nil,
+	} {
+		if metric := catalog.Lookup(fqName); !reflect.DeepEqual(metric, expected) {
+			t.Errorf("expected %s to be %+v, got %+v", fqName, expected, metric)
+		}
+	}
+}
+
+func Test_ParseCatalogErrors(t *testing.T) {
+	for catalog, expected := range map[string]string{
+		"up:\n  metadata:\n    type: gauge\n    group: always\n":                                  "up: no help",
+		"up:\n  metadata:\n    help: Up.\n    type: summary\n    group: always\n":                 `up: unknown type "summary"`,
+		"up:\n  metadata:\n    help: Up.\n    type: gauge_vec\n    group: always\n":               "up: no labels",
+		"up:\n  metadata:\n    help: Up.\n    type: gauge\n    unit: seconds\n":                   "field unit not found",
+		"up:\n  metadata:\n    help: Up.\n    type: gauge\n":                                      "up: no group",
+		"a:\n  metadata:\n    help: A.\n    type: metrics\n  b:\n    help: B.\n    type: gauge\n": "a_b: no group",
+		"up:\n  help: Up.\n": "cannot parse",
+		"up:\n  metadata:\n    help: Up.\n    type: gauge\n    group: always\n  yes:\n    help: Yes.\n": "up: a gauge has no label values",
+		"a:\n  metadata:\n    help: A.\n    type: metrics\n    group: always\n  b:\n    help: B.\n    type: gauge\n" +
+			"a_b:\n  metadata:\n    help: A B.\n    type: gauge\n    group: always\n": "a_b: defined twice",
+	} {
+		_, err := ParseCatalog([]byte(catalog), nil)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected an error containing %q, got %v", expected, err)
+		}
+	}
+}
+
+var catalogTestMetric = NewGaugeDesc(prometheus.GaugeOpts{
+	Namespace: "mongodb_mongod",
+	Name:      "catalog_test_locks",
+}, nil)
+
+func Test_CatalogApply(t *testing.T) {
+	catalog, err := ParseCatalog([]byte("catalog_test:\n  metadata:\n    help: Test.\n    type: metrics\n    group: always\n"+
+		"  locks:\n    help: Test locks.\n    type: gauge\n    group: locks\n"), []string{"mongodb_mongod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog.Apply()
+	if catalogTestMetric.Help != "Test locks." || catalogTestMetric.Group != "locks" {
+		t.Fatalf("expected the help and group of the catalog, got %+v", catalogTestMetric)
+	}
+
+	// the metrics of the disabled groups are dropped
+	defer ParseEnabledGroups("")
+	metrics := make(chan prometheus.Metric, 2)
+	ch := NewDescs(nil).Channel(metrics)
+	ParseEnabledGroups("asserts")
+	ch.Send(catalogTestMetric, 1)
+	ParseEnabledGroups("asserts,locks")
+	ch.Send(catalogTestMetric, 2)
+	close(metrics)
+	if len(metrics) != 1 {
+		t.Errorf("expected the metric to be exported once its group is enabled only, got %d metrics", len(metrics))
+	}
+}
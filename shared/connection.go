@@ -1,6 +1,8 @@
 package shared
 
 import (
+	"crypto/tls"
+	"fmt"
 	"strings"
 	"time"
 
@@ -8,12 +10,24 @@ import (
 	"gopkg.in/mgo.v2"
 )
 
+func init() {
+	// hello replaces isMaster since 4.4.2 (and the latest patch releases of 3.6 to 4.2)
+	RegisterCapability(Capability{Name: "hello", MinVersion: Version{Major: 4, Minor: 4, Patch: 2}})
+}
+
 const (
 	dialMongodbTimeout = 5 * time.Second
 	syncMongodbTimeout = 1 * time.Minute
 )
 
 func RedactMongoUri(uri string) string {
+	if IsSRVURI(uri) {
+		credentials, host, _ := splitURI(strings.TrimPrefix(uri, srvScheme))
+		if credentials != "" {
+			return srvScheme + "****:****@" + host
+		}
+		return uri
+	}
 	if strings.HasPrefix(uri, "mongodb://") && strings.Contains(uri, "@") {
 		dialInfo, err := mgo.ParseURL(uri)
 		if err != nil {
@@ -27,43 +41,128 @@ func RedactMongoUri(uri string) string {
 	return uri
 }
 
-func MongoSession(uri string) *mgo.Session {
-	dialInfo, err := mgo.ParseURL(uri)
-	if err != nil {
-		glog.Errorf("Cannot parse mongodb server url: %s", err)
-		return nil
+// splitURI splits a URI without its scheme into its credentials (with the trailing "@"),
+// its hosts and the rest of the URI (the database and the options).
+func splitURI(uri string) (credentials, hosts, suffix string) {
+	end := strings.IndexAny(uri, "/?")
+	if end < 0 {
+		end = len(uri)
+	}
+	if i := strings.LastIndex(uri[:end], "@"); i >= 0 {
+		credentials, uri, end = uri[:i+1], uri[i+1:], end-i-1
+	}
+	return credentials, uri[:end], uri[end:]
+}
+
+// URIWithHosts replaces the hosts of a mongodb:// URI, keeping its credentials, database and options.
+func URIWithHosts(uri string, hosts []string) string {
+	credentials, _, suffix := splitURI(strings.TrimPrefix(uri, "mongodb://"))
+	return "mongodb://" + credentials + strings.Join(hosts, ",") + suffix
+}
+
+// MongoSessionOpts are the options used to dial a MongoDB server.
+type MongoSessionOpts struct {
+	URI       string
+	TLSConfig *tls.Config
+	// ConnectTimeout is the timeout of dialing the server (0 = 5s).
+	ConnectTimeout time.Duration
+	// SyncTimeout is the time to wait for a server to answer a command (0 = 1m).
+	SyncTimeout time.Duration
+	// ReplicaSet is the name of the replica set to connect to, instead of connecting directly to the server.
+	ReplicaSet string
+	// Driver is the driver dialing the server, DriverMgo or DriverOfficial (empty = DriverMgo).
+	Driver string
+}
+
+func MongoSession(uri string) Session {
+	return MongoSessionWithOpts(MongoSessionOpts{URI: uri})
+}
+
+// MongoSessionWithTLS dials uri like MongoSession, over TLS when tlsConfig is not nil.
+func MongoSessionWithTLS(uri string, tlsConfig *tls.Config) Session {
+	return MongoSessionWithOpts(MongoSessionOpts{URI: uri, TLSConfig: tlsConfig})
+}
+
+// MongoSessionWithOpts dials the server given by opts.URI like MongoSession, with the given TLS config, timeouts and driver.
+// A mongodb+srv:// URI is resolved with DefaultResolver first.
+func MongoSessionWithOpts(opts MongoSessionOpts) Session {
+	if IsSRVURI(opts.URI) {
+		resolution, err := ResolveSRVURI(opts.URI, DefaultResolver)
+		if err != nil {
+			glog.Errorf("Cannot resolve server url %s: %s", RedactMongoUri(opts.URI), err)
+			return nil
+		}
+		opts = resolution.DialOpts(opts)
 	}
 
-	dialInfo.Direct = true // Force direct connection
-	dialInfo.Timeout = dialMongodbTimeout
+	connectTimeout := opts.ConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = dialMongodbTimeout
+	}
+	syncTimeout := opts.SyncTimeout
+	if syncTimeout <= 0 {
+		syncTimeout = syncMongodbTimeout
+	}
 
-	session, err := mgo.DialWithInfo(dialInfo)
+	var session Session
+	var err error
+	switch opts.Driver {
+	case "", DriverMgo:
+		session, err = dialMgo(opts, connectTimeout, syncTimeout)
+	case DriverOfficial:
+		session, err = dialOfficial(opts, connectTimeout, syncTimeout)
+	default:
+		err = fmt.Errorf("unknown driver %q", opts.Driver)
+	}
 	if err != nil {
-		glog.Errorf("Cannot connect to server using url %s: %s", RedactMongoUri(uri), err)
+		glog.Errorf("Cannot connect to server using url %s: %s", RedactMongoUri(opts.URI), err)
 		return nil
 	}
-	session.SetMode(mgo.Eventual, true)
-	session.SetSyncTimeout(syncMongodbTimeout)
-	session.SetSocketTimeout(0)
 	return session
 }
 
-func MongoSessionServerVersion(session *mgo.Session) (string, error) {
-	buildInfo, err := session.BuildInfo()
-	if err != nil {
+// BuildInfo is the version of a MongoDB server, given by the buildInfo command.
+type BuildInfo struct {
+	Version    string `bson:"version"`
+	GitVersion string `bson:"gitVersion"`
+}
+
+// MongoSessionBuildInfo returns the version and git revision of the server.
+func MongoSessionBuildInfo(session Session) (*BuildInfo, error) {
+	buildInfo := &BuildInfo{}
+	if err := session.Run("admin", "buildInfo", buildInfo); err != nil {
 		glog.Errorf("Could not get MongoDB BuildInfo: %s!", err)
+		return nil, err
+	}
+	return buildInfo, nil
+}
+
+func MongoSessionServerVersion(session Session) (string, error) {
+	buildInfo, err := MongoSessionBuildInfo(session)
+	if err != nil {
 		return "unknown", err
 	}
 	return buildInfo.Version, nil
 }
 
-func MongoSessionNodeType(session *mgo.Session) (string, error) {
+// MongoSessionNodeType returns the type of the server: mongod, replset or mongos. It runs hello on
+// the servers which support it, and the deprecated isMaster on the others or when hello fails, e.g.
+// on a server whose version is unknown.
+func MongoSessionNodeType(session Session, caps *ServerCapabilities) (string, error) {
 	masterDoc := struct {
 		SetName interface{} `bson:"setName"`
 		Hosts   interface{} `bson:"hosts"`
 		Msg     string      `bson:"msg"`
 	}{}
-	err := session.Run("isMaster", &masterDoc)
+	command := "isMaster"
+	if caps.Supports("hello") {
+		command = "hello"
+	}
+	err := session.Run("admin", command, &masterDoc)
+	if err != nil && command == "hello" {
+		glog.Warningf("Cannot run hello, running isMaster: %s", err)
+		err = session.Run("admin", "isMaster", &masterDoc)
+	}
 	if err != nil {
 		glog.Errorf("Got unknown node type: %s", err)
 		return "unknown", err
@@ -72,7 +171,7 @@ func MongoSessionNodeType(session *mgo.Session) (string, error) {
 	if masterDoc.SetName != nil || masterDoc.Hosts != nil {
 		return "replset", nil
 	} else if masterDoc.Msg == "isdbgrid" {
-		// isdbgrid is always the msg value when calling isMaster on a mongos
+		// isdbgrid is always the msg value when calling hello or isMaster on a mongos
 		// see http://docs.mongodb.org/manual/core/sharded-cluster-query-router/
 		return "mongos", nil
 	}
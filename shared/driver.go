@@ -0,0 +1,131 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Drivers are the names of the MongoDB drivers a session can be dialed with.
+const (
+	// DriverMgo is gopkg.in/mgo.v2, which only speaks the legacy wire protocol.
+	DriverMgo = "mgo"
+	// DriverOfficial is the official MongoDB Go driver, go.mongodb.org/mongo-driver.
+	DriverOfficial = "official"
+)
+
+// ErrNotFound is returned by Query.One when no document matches the query.
+var ErrNotFound = errors.New("not found")
+
+// collectionsFilter is the listCollections filter of Session.CollectionNames: the collections, as opposed
+// to the views. The servers before 3.4 have no views and no type field.
+var collectionsFilter = map[string]interface{}{
+	"$or": []interface{}{
+		map[string]interface{}{"type": "collection"},
+		map[string]interface{}{"type": map[string]interface{}{"$exists": false}},
+	},
+}
+
+// ReadMode selects the members of a replica set that serve the reads of a session.
+type ReadMode int
+
+const (
+	// Nearest reads from any member, the default of a dialed session.
+	Nearest ReadMode = iota
+	// Primary reads from the primary only.
+	Primary
+	// PrimaryPreferred reads from the primary, or from a secondary when there is no primary.
+	PrimaryPreferred
+)
+
+// Session is a connection to a MongoDB server, implemented on top of every supported driver.
+// The replies are decoded with gopkg.in/mgo.v2/bson whatever the driver, so that the same
+// stats documents decode the replies of the server and the fixtures.
+type Session interface {
+	// Run runs the command on the database and decodes the reply into result.
+	// A string command is run as {command: 1}.
+	Run(database string, command interface{}, result interface{}) error
+	// Find returns a query of the documents of the collection matching the filter (nil = all documents).
+	Find(database, collection string, filter interface{}) Query
+	// Pipe returns the aggregation of the collection by the pipeline stages.
+	Pipe(database, collection string, pipeline interface{}) Pipe
+	// DatabaseNames returns the names of the databases of the server.
+	DatabaseNames() ([]string, error)
+	// CollectionNames returns the names of the collections of the database, views excepted.
+	CollectionNames(database string) ([]string, error)
+
+	// SetMode sets the members of a replica set the next operations read from.
+	SetMode(mode ReadMode)
+	// Ping checks that the server is reachable.
+	Ping() error
+	// Copy returns a new session sharing the connections of this one, to be closed by the caller.
+	Copy() Session
+	// Close releases the session.
+	Close()
+}
+
+// Query is a find query, built like a mgo query.
+type Query interface {
+	Select(projection interface{}) Query
+	Sort(fields ...string) Query
+	Limit(n int) Query
+	Batch(n int) Query
+	Count() (int, error)
+	One(result interface{}) error
+	All(result interface{}) error
+	Iter() Iter
+}
+
+// Pipe is an aggregation.
+type Pipe interface {
+	All(result interface{}) error
+	Iter() Iter
+}
+
+// Iter iterates over the documents of a query or aggregation.
+type Iter interface {
+	// Next decodes the next document into result, and returns false at the end of the results or on error.
+	Next(result interface{}) bool
+	// Close closes the iterator and returns the error of the iteration, if any.
+	Close() error
+}
+
+// allFromIter decodes every document of iter into the slice pointed to by result.
+func allFromIter(iter Iter, result interface{}) error {
+	resultValue := reflect.ValueOf(result)
+	if resultValue.Kind() != reflect.Ptr || resultValue.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("result argument must be a slice address, got %T", result)
+	}
+	slice := resultValue.Elem()
+	slice.Set(slice.Slice(0, 0))
+	for {
+		elem := reflect.New(slice.Type().Elem())
+		if !iter.Next(elem.Interface()) {
+			break
+		}
+		slice.Set(reflect.Append(slice, elem.Elem()))
+	}
+	return iter.Close()
+}
+
+// sortFields converts mgo sort fields ("field", "-field") to field names and directions.
+func sortFields(fields []string) ([]string, []int) {
+	names := make([]string, 0, len(fields))
+	directions := make([]int, 0, len(fields))
+	for _, field := range fields {
+		direction := 1
+		switch {
+		case strings.HasPrefix(field, "-"):
+			direction, field = -1, field[1:]
+		case strings.HasPrefix(field, "+"):
+			field = field[1:]
+		}
+		if field == "" {
+			continue
+		}
+		names = append(names, field)
+		directions = append(directions, direction)
+	}
+	return names, directions
+}
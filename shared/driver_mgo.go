@@ -0,0 +1,185 @@
+package shared
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// mgoSession is a Session on top of mgo.
+type mgoSession struct {
+	session *mgo.Session
+}
+
+// dialMgo dials the server with mgo.
+func dialMgo(opts MongoSessionOpts, connectTimeout, syncTimeout time.Duration) (Session, error) {
+	dialInfo, err := mgo.ParseURL(opts.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ReplicaSet != "" {
+		dialInfo.ReplicaSetName = opts.ReplicaSet
+	} else {
+		dialInfo.Direct = true // Force direct connection
+	}
+	dialInfo.Timeout = connectTimeout
+	if opts.TLSConfig != nil {
+		dialInfo.DialServer = func(addr *mgo.ServerAddr) (net.Conn, error) {
+			dialer := &net.Dialer{Timeout: connectTimeout}
+			ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+			defer cancel()
+			return DialTLS(ctx, dialer, "tcp", addr.String(), opts.TLSConfig)
+		}
+	}
+	if dialInfo.Mechanism == AuthMechanismX509 && dialInfo.Username == "" {
+		// mgo only authenticates with a user, which is the subject of the client certificate
+		if dialInfo.Username, err = X509Username(opts.TLSConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	session, err := mgo.DialWithInfo(dialInfo)
+	if err != nil {
+		return nil, err
+	}
+	session.SetMode(mgo.Eventual, true)
+	session.SetSyncTimeout(syncTimeout)
+	session.SetSocketTimeout(0)
+	return &mgoSession{session: session}, nil
+}
+
+func (s *mgoSession) Run(database string, command interface{}, result interface{}) error {
+	return s.session.DB(database).Run(command, result)
+}
+
+func (s *mgoSession) Find(database, collection string, filter interface{}) Query {
+	return &mgoQuery{query: s.session.DB(database).C(collection).Find(filter)}
+}
+
+func (s *mgoSession) Pipe(database, collection string, pipeline interface{}) Pipe {
+	return &mgoPipe{pipe: s.session.DB(database).C(collection).Pipe(pipeline)}
+}
+
+func (s *mgoSession) DatabaseNames() ([]string, error) {
+	return s.session.DatabaseNames()
+}
+
+// CollectionNames lists the collections with listCollections, falling back to mgo's listing on the
+// servers without the command, which have no views.
+func (s *mgoSession) CollectionNames(database string) ([]string, error) {
+	// the cursor of the command is read from the server which ran it
+	session := s.session.Clone()
+	defer session.Close()
+	session.SetMode(mgo.Monotonic, true)
+	db := session.DB(database)
+
+	var result struct {
+		Cursor struct {
+			FirstBatch []bson.Raw `bson:"firstBatch"`
+			NS         string     `bson:"ns"`
+			Id         int64      `bson:"id"`
+		} `bson:"cursor"`
+	}
+	err := db.Run(bson.D{{"listCollections", 1}, {"filter", collectionsFilter}, {"nameOnly", true}, {"cursor", bson.M{}}}, &result)
+	if err != nil {
+		return db.CollectionNames()
+	}
+	cursorCollection := strings.TrimPrefix(result.Cursor.NS, database+".")
+	iter := db.C(cursorCollection).NewIter(nil, result.Cursor.FirstBatch, result.Cursor.Id, nil)
+	names := []string{}
+	var collection struct {
+		Name string `bson:"name"`
+	}
+	for iter.Next(&collection) {
+		names = append(names, collection.Name)
+	}
+	return names, iter.Close()
+}
+
+func (s *mgoSession) SetMode(mode ReadMode) {
+	switch mode {
+	case Primary:
+		s.session.SetMode(mgo.Strong, true)
+	case PrimaryPreferred:
+		s.session.SetMode(mgo.PrimaryPreferred, true)
+	default:
+		s.session.SetMode(mgo.Eventual, true)
+	}
+}
+
+// Ping pings the server, refreshing the sockets of the session once if the first ping fails.
+func (s *mgoSession) Ping() error {
+	if err := s.session.Ping(); err == nil {
+		return nil
+	}
+	s.session.Refresh()
+	return s.session.Ping()
+}
+
+func (s *mgoSession) Copy() Session {
+	return &mgoSession{session: s.session.Copy()}
+}
+
+func (s *mgoSession) Close() {
+	s.session.Close()
+}
+
+type mgoQuery struct {
+	query *mgo.Query
+}
+
+func (q *mgoQuery) Select(projection interface{}) Query {
+	q.query.Select(projection)
+	return q
+}
+
+func (q *mgoQuery) Sort(fields ...string) Query {
+	q.query.Sort(fields...)
+	return q
+}
+
+func (q *mgoQuery) Limit(n int) Query {
+	q.query.Limit(n)
+	return q
+}
+
+func (q *mgoQuery) Batch(n int) Query {
+	q.query.Batch(n)
+	return q
+}
+
+func (q *mgoQuery) Count() (int, error) {
+	return q.query.Count()
+}
+
+func (q *mgoQuery) One(result interface{}) error {
+	if err := q.query.One(result); err != mgo.ErrNotFound {
+		return err
+	}
+	return ErrNotFound
+}
+
+func (q *mgoQuery) All(result interface{}) error {
+	return q.query.All(result)
+}
+
+func (q *mgoQuery) Iter() Iter {
+	return q.query.Iter()
+}
+
+type mgoPipe struct {
+	pipe *mgo.Pipe
+}
+
+func (p *mgoPipe) All(result interface{}) error {
+	return p.pipe.All(result)
+}
+
+func (p *mgoPipe) Iter() Iter {
+	return p.pipe.Iter()
+}
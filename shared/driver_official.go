@@ -0,0 +1,291 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	mgobson "gopkg.in/mgo.v2/bson"
+)
+
+// officialSession is a Session on top of the official MongoDB Go driver. The documents sent to the
+// server are encoded, and the replies decoded, with mgo's bson package.
+type officialSession struct {
+	client   *mongo.Client
+	readPref *readpref.ReadPref
+	// owner is set on the dialed session, which disconnects the client when closed
+	owner bool
+}
+
+// OfficialDriverURI converts a mgo URI to a URI of the official driver, which requires the mongodb:// scheme.
+func OfficialDriverURI(uri string) string {
+	if !strings.HasPrefix(uri, "mongodb://") && !strings.HasPrefix(uri, "mongodb+srv://") {
+		return "mongodb://" + uri
+	}
+	return uri
+}
+
+// officialClientOptions returns the client options of the official driver. A single server is
+// connected to directly, even when it is a replica set member, while the members of a host list or
+// of a replica set are discovered.
+func officialClientOptions(opts MongoSessionOpts, connectTimeout, syncTimeout time.Duration) *options.ClientOptions {
+	clientOpts := options.Client().
+		ApplyURI(OfficialDriverURI(opts.URI)).
+		SetConnectTimeout(connectTimeout).
+		SetServerSelectionTimeout(connectTimeout).
+		SetTimeout(syncTimeout)
+	if opts.ReplicaSet != "" {
+		clientOpts.SetReplicaSet(opts.ReplicaSet).SetDirect(false)
+	} else if len(clientOpts.Hosts) == 1 && clientOpts.ReplicaSet == nil && clientOpts.Direct == nil {
+		clientOpts.SetDirect(true)
+	}
+	if opts.TLSConfig != nil {
+		// the connections are secured by the dialer, which verifies the host name of the servers
+		// like with mgo, instead of the driver
+		clientOpts.TLSConfig = nil
+		clientOpts.SetDialer(&tlsDialer{dialer: &net.Dialer{Timeout: connectTimeout}, config: opts.TLSConfig})
+	}
+	return clientOpts
+}
+
+// dialOfficial dials the server with the official driver, and pings it so that an unreachable
+// server fails like with mgo.
+func dialOfficial(opts MongoSessionOpts, connectTimeout, syncTimeout time.Duration) (Session, error) {
+	clientOpts := officialClientOptions(opts, connectTimeout, syncTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	client, err := mongo.Connect(ctx, clientOpts)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Ping(ctx, readpref.Nearest()); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+	return &officialSession{client: client, readPref: readpref.Nearest(), owner: true}, nil
+}
+
+// officialDocument encodes a mgo document (struct, map or bson.D) for the official driver.
+func officialDocument(document interface{}) (bson.Raw, error) {
+	if document == nil {
+		document = mgobson.M{}
+	}
+	data, err := mgobson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	return bson.Raw(data), nil
+}
+
+func (s *officialSession) database(name string) *mongo.Database {
+	return s.client.Database(name, options.Database().SetReadPreference(s.readPref))
+}
+
+func (s *officialSession) Run(database string, command interface{}, result interface{}) error {
+	if name, ok := command.(string); ok {
+		command = mgobson.D{{Name: name, Value: 1}}
+	}
+	document, err := officialDocument(command)
+	if err != nil {
+		return err
+	}
+	reply, err := s.database(database).RunCommand(context.Background(), document).Raw()
+	if err != nil {
+		return err
+	}
+	return mgobson.Unmarshal(reply, result)
+}
+
+func (s *officialSession) Find(database, collection string, filter interface{}) Query {
+	return &officialQuery{collection: s.database(database).Collection(collection), filter: filter}
+}
+
+func (s *officialSession) Pipe(database, collection string, pipeline interface{}) Pipe {
+	return &officialPipe{collection: s.database(database).Collection(collection), pipeline: pipeline}
+}
+
+func (s *officialSession) DatabaseNames() ([]string, error) {
+	return s.client.ListDatabaseNames(context.Background(), bson.D{})
+}
+
+func (s *officialSession) CollectionNames(database string) ([]string, error) {
+	return s.database(database).ListCollectionNames(context.Background(), collectionsFilter)
+}
+
+func (s *officialSession) SetMode(mode ReadMode) {
+	switch mode {
+	case Primary:
+		s.readPref = readpref.Primary()
+	case PrimaryPreferred:
+		s.readPref = readpref.PrimaryPreferred()
+	default:
+		s.readPref = readpref.Nearest()
+	}
+}
+
+func (s *officialSession) Ping() error {
+	return s.client.Ping(context.Background(), s.readPref)
+}
+
+func (s *officialSession) Copy() Session {
+	return &officialSession{client: s.client, readPref: s.readPref}
+}
+
+func (s *officialSession) Close() {
+	if s.owner {
+		s.client.Disconnect(context.Background())
+	}
+}
+
+type officialQuery struct {
+	collection *mongo.Collection
+	filter     interface{}
+	projection interface{}
+	sort       []string
+	limit      int
+	batch      int
+}
+
+func (q *officialQuery) Select(projection interface{}) Query {
+	q.projection = projection
+	return q
+}
+
+func (q *officialQuery) Sort(fields ...string) Query {
+	q.sort = fields
+	return q
+}
+
+func (q *officialQuery) Limit(n int) Query {
+	q.limit = n
+	return q
+}
+
+func (q *officialQuery) Batch(n int) Query {
+	q.batch = n
+	return q
+}
+
+func (q *officialQuery) Count() (int, error) {
+	if q.filter == nil {
+		count, err := q.collection.EstimatedDocumentCount(context.Background())
+		return int(count), err
+	}
+	filter, err := officialDocument(q.filter)
+	if err != nil {
+		return 0, err
+	}
+	count, err := q.collection.CountDocuments(context.Background(), filter)
+	return int(count), err
+}
+
+func (q *officialQuery) One(result interface{}) error {
+	iter := q.Limit(1).Iter()
+	if !iter.Next(result) {
+		if err := iter.Close(); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+	return iter.Close()
+}
+
+func (q *officialQuery) All(result interface{}) error {
+	return allFromIter(q.Iter(), result)
+}
+
+func (q *officialQuery) Iter() Iter {
+	filter, err := officialDocument(q.filter)
+	if err != nil {
+		return &officialIter{err: err}
+	}
+	findOpts := options.Find()
+	if q.projection != nil {
+		projection, err := officialDocument(q.projection)
+		if err != nil {
+			return &officialIter{err: err}
+		}
+		findOpts.SetProjection(projection)
+	}
+	if len(q.sort) > 0 {
+		names, directions := sortFields(q.sort)
+		sort := bson.D{}
+		for i, name := range names {
+			sort = append(sort, bson.E{Key: name, Value: directions[i]})
+		}
+		findOpts.SetSort(sort)
+	}
+	if q.limit > 0 {
+		findOpts.SetLimit(int64(q.limit))
+	}
+	if q.batch > 0 {
+		findOpts.SetBatchSize(int32(q.batch))
+	}
+
+	cursor, err := q.collection.Find(context.Background(), filter, findOpts)
+	return &officialIter{cursor: cursor, err: err}
+}
+
+type officialPipe struct {
+	collection *mongo.Collection
+	pipeline   interface{}
+}
+
+func (p *officialPipe) All(result interface{}) error {
+	return allFromIter(p.Iter(), result)
+}
+
+func (p *officialPipe) Iter() Iter {
+	stages := reflect.ValueOf(p.pipeline)
+	if stages.Kind() != reflect.Slice {
+		return &officialIter{err: fmt.Errorf("pipeline must be a slice of stages, got %T", p.pipeline)}
+	}
+	pipeline := make([]bson.Raw, stages.Len())
+	for i := range pipeline {
+		stage, err := officialDocument(stages.Index(i).Interface())
+		if err != nil {
+			return &officialIter{err: err}
+		}
+		pipeline[i] = stage
+	}
+
+	cursor, err := p.collection.Aggregate(context.Background(), pipeline)
+	return &officialIter{cursor: cursor, err: err}
+}
+
+type officialIter struct {
+	cursor *mongo.Cursor
+	err    error
+}
+
+func (it *officialIter) Next(result interface{}) bool {
+	if it.err != nil || it.cursor == nil {
+		return false
+	}
+	if !it.cursor.Next(context.Background()) {
+		return false
+	}
+	if err := mgobson.Unmarshal(it.cursor.Current, result); err != nil {
+		it.err = err
+		return false
+	}
+	return true
+}
+
+func (it *officialIter) Close() error {
+	if it.cursor != nil {
+		if err := it.cursor.Err(); err != nil && it.err == nil {
+			it.err = err
+		}
+		it.cursor.Close(context.Background())
+	}
+	return it.err
+}
@@ -1,18 +1,65 @@
 package shared
 
 import (
+	"sort"
 	"strings"
+	"sync"
+
+	"github.com/golang/glog"
 )
 
 var (
 	// EnabledGroups is map with the group name as field and a boolean indicating wether that group is enabled or not.
 	EnabledGroups = make(map[string]bool)
+
+	// KnownGroups is the set of group names registered by the collectors.
+	KnownGroups = make(map[string]bool)
+
+	// enabledGroupsMu protects EnabledGroups, which is parsed again when the configuration is reloaded.
+	enabledGroupsMu sync.RWMutex
 )
 
+// RegisterGroup makes a group name known so it can be switched on or off with ParseEnabledGroups.
+func RegisterGroup(name string) {
+	KnownGroups[name] = true
+}
+
 // ParseEnabledGroups parses the groups passed by the command line input.
 func ParseEnabledGroups(enabledGroupsFlag string) {
+	enabled := make(map[string]bool)
 	for _, name := range strings.Split(enabledGroupsFlag, ",") {
 		name = strings.TrimSpace(name)
-		EnabledGroups[name] = true
+		if name == "" {
+			continue
+		}
+		if len(KnownGroups) > 0 && !KnownGroups[name] {
+			glog.Warningf("Unknown group %s, known groups are: %s", name, strings.Join(GroupNames(), ","))
+		}
+		enabled[name] = true
+	}
+
+	enabledGroupsMu.Lock()
+	EnabledGroups = enabled
+	enabledGroupsMu.Unlock()
+}
+
+// IsGroupEnabled returns true if the group was enabled. When no groups were parsed every group is enabled.
+func IsGroupEnabled(name string) bool {
+	enabledGroupsMu.RLock()
+	defer enabledGroupsMu.RUnlock()
+
+	if len(EnabledGroups) == 0 {
+		return true
+	}
+	return EnabledGroups[name]
+}
+
+// GroupNames returns the sorted names of all registered groups.
+func GroupNames() []string {
+	names := make([]string, 0, len(KnownGroups))
+	for name := range KnownGroups {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
 }
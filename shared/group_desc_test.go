@@ -16,3 +16,18 @@ func Test_ParseEnabledGroups(t *testing.T) {
 		t.Error("c was not loaded.")
 	}
 }
+
+func Test_IsGroupEnabled(t *testing.T) {
+	ParseEnabledGroups("")
+	if !IsGroupEnabled("locks") {
+		t.Error("all groups should be enabled when none were parsed.")
+	}
+
+	ParseEnabledGroups("asserts,metrics")
+	if !IsGroupEnabled("metrics") {
+		t.Error("metrics should be enabled.")
+	}
+	if IsGroupEnabled("locks") {
+		t.Error("locks should be disabled.")
+	}
+}
@@ -0,0 +1,78 @@
+package shared
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// LogCursor is the position of a reader of a collection of log entries, such as config.changelog
+// or system.profile: the time of the last read entry, and the entries read with that time, which
+// are read again by the next $gte query. The entries are told apart by their content, so that the
+// entries without _id of the capped collections are counted once too.
+type LogCursor struct {
+	// TimeField is the field of the time of the entries, e.g. time or ts.
+	TimeField string
+	// Order is the field the entries are read in the order of, TimeField or $natural for a capped
+	// collection without index on its time.
+	Order string
+
+	initialized bool
+	time        time.Time
+	seen        map[string]bool
+}
+
+// Read passes the entries added to the collection since the last read to handle, up to limit entries.
+// The first read only positions the cursor on the newest entry.
+func (cursor *LogCursor) Read(session Session, db, collection string, limit int, handle func(entry bson.Raw) error) error {
+	if !cursor.initialized {
+		newest := bson.Raw{}
+		err := session.Find(db, collection, nil).Sort("-" + cursor.Order).One(&newest)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		cursor.initialized = true
+		if err == ErrNotFound {
+			return nil
+		}
+		cursor.time = cursor.entryTime(newest)
+		handle = func(bson.Raw) error { return nil }
+	}
+
+	query := bson.M{}
+	if !cursor.time.IsZero() {
+		query[cursor.TimeField] = bson.M{"$gte": cursor.time}
+	}
+	iter := session.Find(db, collection, query).Sort(cursor.Order).Limit(limit + len(cursor.seen)).Iter()
+	entry := bson.Raw{}
+	read := 0
+	for read < limit && iter.Next(&entry) {
+		if key := string(entry.Data); !cursor.seen[key] {
+			if entryTime := cursor.entryTime(entry); cursor.seen == nil || !entryTime.Equal(cursor.time) {
+				cursor.time = entryTime
+				cursor.seen = map[string]bool{}
+			}
+			cursor.seen[key] = true
+			if err := handle(entry); err != nil {
+				glog.Errorf("Cannot decode an entry of %s.%s: %s", db, collection, err)
+			}
+			read++
+		}
+		entry = bson.Raw{}
+	}
+	if read == limit {
+		glog.Warningf("More than %d new entries in %s.%s, reading the others on the next scrapes", limit, db, collection)
+	}
+	return iter.Close()
+}
+
+// entryTime returns the time of a log entry, the zero time when it has none.
+func (cursor *LogCursor) entryTime(entry bson.Raw) time.Time {
+	fields := bson.M{}
+	if err := entry.Unmarshal(&fields); err != nil {
+		return time.Time{}
+	}
+	entryTime, _ := fields[cursor.TimeField].(time.Time)
+	return entryTime
+}
@@ -0,0 +1,107 @@
+package shared_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/elarasu/mongodb_exporter/shared/sessiontest"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func logEntry(id int, seconds int) bson.M {
+	return bson.M{"_id": id, "what": "split", "time": time.Unix(int64(1600000000+seconds), 0).UTC()}
+}
+
+// profileEntry is an entry of system.profile, which has no _id and is read in $natural order.
+func profileEntry(natural int, seconds int) bson.M {
+	return bson.M{"$natural": natural, "op": "query", "ts": time.Unix(int64(1600000000+seconds), 0).UTC()}
+}
+
+func Test_LogCursor(t *testing.T) {
+	tests := []struct {
+		name     string
+		cursor   shared.LogCursor
+		key      string
+		initial  []bson.M
+		reads    [][]bson.M // the entries added before each read after the first one
+		limit    int
+		expected [][]int // the keys handled by each read after the first one
+	}{
+		{
+			name:     "the first read only positions the cursor",
+			initial:  []bson.M{logEntry(1, 0), logEntry(2, 10)},
+			reads:    [][]bson.M{nil},
+			limit:    10,
+			expected: [][]int{nil},
+		},
+		{
+			name:     "the next reads count the newer entries only",
+			initial:  []bson.M{logEntry(1, 0)},
+			reads:    [][]bson.M{{logEntry(2, 10), logEntry(3, 20)}, {logEntry(4, 30)}, nil},
+			limit:    10,
+			expected: [][]int{{2, 3}, {4}, nil},
+		},
+		{
+			name:     "the entries with the time of the last read one are not counted twice",
+			initial:  []bson.M{logEntry(1, 10)},
+			reads:    [][]bson.M{{logEntry(2, 10)}, {logEntry(3, 10), logEntry(4, 20)}, {logEntry(5, 20)}},
+			limit:    10,
+			expected: [][]int{{2}, {3, 4}, {5}},
+		},
+		{
+			name:     "the entries over the limit are read by the next reads",
+			initial:  nil,
+			reads:    [][]bson.M{{logEntry(1, 10), logEntry(2, 10), logEntry(3, 20)}, nil, nil},
+			limit:    2,
+			expected: [][]int{{1, 2}, {3}, nil},
+		},
+		{
+			name:     "the entries without _id with the time of the last read one are counted once",
+			cursor:   shared.LogCursor{TimeField: "ts", Order: "$natural"},
+			key:      "$natural",
+			initial:  []bson.M{profileEntry(1, 10)},
+			reads:    [][]bson.M{{profileEntry(2, 10)}, {profileEntry(3, 10), profileEntry(4, 20)}, {profileEntry(5, 20)}, nil},
+			limit:    10,
+			expected: [][]int{{2}, {3, 4}, {5}, nil},
+		},
+	}
+	for _, test := range tests {
+		cursor := test.cursor
+		if cursor.TimeField == "" {
+			cursor = shared.LogCursor{TimeField: "time", Order: "time"}
+		}
+		key := test.key
+		if key == "" {
+			key = "_id"
+		}
+		session := &sessiontest.Session{Collections: map[string][]bson.M{"config.log": test.initial}}
+		handled := []int{}
+		handle := func(raw bson.Raw) error {
+			entry := bson.M{}
+			if err := raw.Unmarshal(&entry); err != nil {
+				return err
+			}
+			handled = append(handled, entry[key].(int))
+			return nil
+		}
+		if err := cursor.Read(session, "config", "log", test.limit, handle); err != nil {
+			t.Fatal(err)
+		}
+		if len(handled) != 0 && test.initial != nil {
+			t.Errorf("%s: the first read handled %v", test.name, handled)
+		}
+		handled = handled[:0]
+		for i, added := range test.reads {
+			session.Collections["config.log"] = append(session.Collections["config.log"], added...)
+			if err := cursor.Read(session, "config", "log", test.limit, handle); err != nil {
+				t.Fatal(err)
+			}
+			if len(handled)+len(test.expected[i]) > 0 && !reflect.DeepEqual(handled, test.expected[i]) {
+				t.Errorf("%s: read %d handled %v, expected %v", test.name, i+1, handled, test.expected[i])
+			}
+			handled = handled[:0]
+		}
+	}
+}
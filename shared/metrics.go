@@ -0,0 +1,215 @@
+package shared
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricDesc is the definition of a metric, exported as const metrics on every scrape.
+// The prometheus descriptors are built from the definition by every collector instance, see Descs.
+type MetricDesc struct {
+	FQName      string
+	Help        string
+	ValueType   prometheus.ValueType
+	Labels      []string
+	ConstLabels prometheus.Labels
+	// Buckets are the upper bounds of the buckets of a histogram, nil for other metrics.
+	Buckets []float64
+	// Group is the group which gates the export of the metric, set from the catalog. The metric is
+	// always exported when it is empty.
+	Group string
+}
+
+var (
+	metricDescsMutex sync.Mutex
+	metricDescs      []*MetricDesc
+	metricNames      = map[string]bool{}
+)
+
+func newMetricDesc(opts prometheus.Opts, valueType prometheus.ValueType, labels []string, buckets []float64) *MetricDesc {
+	def := &MetricDesc{
+		FQName:      prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		Help:        opts.Help,
+		ValueType:   valueType,
+		Labels:      labels,
+		ConstLabels: opts.ConstLabels,
+		Buckets:     buckets,
+	}
+
+	metricDescsMutex.Lock()
+	metricDescs = append(metricDescs, def)
+	metricNames[def.FQName] = true
+	metricDescsMutex.Unlock()
+	return def
+}
+
+// MetricDescs returns every metric definition.
+func MetricDescs() []*MetricDesc {
+	metricDescsMutex.Lock()
+	defer metricDescsMutex.Unlock()
+
+	defs := make([]*MetricDesc, len(metricDescs))
+	copy(defs, metricDescs)
+	return defs
+}
+
+// NewCounterDesc defines a counter with the given variable labels.
+func NewCounterDesc(opts prometheus.CounterOpts, labels []string) *MetricDesc {
+	return newMetricDesc(prometheus.Opts(opts), prometheus.CounterValue, labels, nil)
+}
+
+// NewGaugeDesc defines a gauge with the given variable labels.
+func NewGaugeDesc(opts prometheus.GaugeOpts, labels []string) *MetricDesc {
+	return newMetricDesc(prometheus.Opts(opts), prometheus.GaugeValue, labels, nil)
+}
+
+// NewHistogramDesc defines a histogram with the given variable labels.
+func NewHistogramDesc(opts prometheus.HistogramOpts, labels []string) *MetricDesc {
+	buckets := opts.Buckets
+	if buckets == nil {
+		buckets = prometheus.DefBuckets
+	}
+	return newMetricDesc(prometheus.Opts{
+		Namespace:   opts.Namespace,
+		Subsystem:   opts.Subsystem,
+		Name:        opts.Name,
+		Help:        opts.Help,
+		ConstLabels: opts.ConstLabels,
+	}, prometheus.UntypedValue, labels, buckets)
+}
+
+// NewDynamicDesc defines a metric discovered at scrape time, e.g. a field of serverStatus. Unlike the
+// other definitions, it is not described by Descs.Describe, so that the collectors stay unchecked for it.
+func NewDynamicDesc(fqName, help string, valueType prometheus.ValueType, labels []string) *MetricDesc {
+	return &MetricDesc{FQName: fqName, Help: help, ValueType: valueType, Labels: labels}
+}
+
+// IsMetricDefined returns whether a metric of the name is defined, to avoid exporting it twice.
+func IsMetricDefined(fqName string) bool {
+	metricDescsMutex.Lock()
+	defer metricDescsMutex.Unlock()
+
+	return metricNames[fqName]
+}
+
+// Descs holds the prometheus descriptors of a collector instance, built from the metric
+// definitions with the constant labels of the instance.
+type Descs struct {
+	constLabels prometheus.Labels
+
+	mu    sync.Mutex
+	descs map[*MetricDesc]*prometheus.Desc
+}
+
+// NewDescs returns the descriptors of a collector instance, with the given constant labels.
+func NewDescs(constLabels prometheus.Labels) *Descs {
+	return &Descs{constLabels: constLabels, descs: map[*MetricDesc]*prometheus.Desc{}}
+}
+
+// Desc returns the descriptor of the metric definition.
+func (descs *Descs) Desc(def *MetricDesc) *prometheus.Desc {
+	descs.mu.Lock()
+	defer descs.mu.Unlock()
+
+	desc, ok := descs.descs[def]
+	if !ok {
+		constLabels := prometheus.Labels{}
+		for name, value := range def.ConstLabels {
+			constLabels[name] = value
+		}
+		for name, value := range descs.constLabels {
+			constLabels[name] = value
+		}
+		desc = prometheus.NewDesc(def.FQName, def.Help, def.Labels, constLabels)
+		descs.descs[def] = desc
+	}
+	return desc
+}
+
+// Describe sends the descriptors of every metric definition.
+func (descs *Descs) Describe(ch chan<- *prometheus.Desc) {
+	for _, def := range MetricDescs() {
+		ch <- descs.Desc(def)
+	}
+}
+
+// Channel returns a channel sending const metrics to ch with these descriptors.
+func (descs *Descs) Channel(ch chan<- prometheus.Metric) *MetricChannel {
+	return &MetricChannel{descs: descs, ch: ch}
+}
+
+// MetricChannel sends the const metrics of a scrape.
+type MetricChannel struct {
+	descs *Descs
+	ch    chan<- prometheus.Metric
+}
+
+// WithDescs returns a channel sending to the same channel with other descriptors.
+func (ch *MetricChannel) WithDescs(descs *Descs) *MetricChannel {
+	return &MetricChannel{descs: descs, ch: ch.ch}
+}
+
+// Send sends the value of a counter or gauge with the given label values.
+func (ch *MetricChannel) Send(def *MetricDesc, value float64, labelValues ...string) {
+	if def.Group != "" && !IsGroupEnabled(def.Group) {
+		return
+	}
+	metric, err := prometheus.NewConstMetric(ch.descs.Desc(def), def.ValueType, value, labelValues...)
+	if err != nil {
+		glog.Errorf("Cannot export %s: %s", def.FQName, err)
+		return
+	}
+	ch.ch <- metric
+}
+
+// SendHistogram sends the observations of a histogram with the given label values.
+func (ch *MetricChannel) SendHistogram(def *MetricDesc, histogram *Histogram, labelValues ...string) {
+	if def.Group != "" && !IsGroupEnabled(def.Group) {
+		return
+	}
+	metric, err := prometheus.NewConstHistogram(ch.descs.Desc(def), histogram.Count, histogram.Sum, histogram.buckets(), labelValues...)
+	if err != nil {
+		glog.Errorf("Cannot export %s: %s", def.FQName, err)
+		return
+	}
+	ch.ch <- metric
+}
+
+// Histogram accumulates the observations of a histogram, to be sent with SendHistogram.
+type Histogram struct {
+	Count uint64
+	Sum   float64
+
+	upperBounds []float64
+	counts      []uint64
+}
+
+// NewHistogram returns an empty histogram with the buckets of the histogram definition.
+func NewHistogram(def *MetricDesc) *Histogram {
+	upperBounds := make([]float64, len(def.Buckets))
+	copy(upperBounds, def.Buckets)
+	sort.Float64s(upperBounds)
+	return &Histogram{upperBounds: upperBounds, counts: make([]uint64, len(upperBounds))}
+}
+
+// Observe adds an observation to the histogram.
+func (histogram *Histogram) Observe(value float64) {
+	histogram.Count++
+	histogram.Sum += value
+	for i, upperBound := range histogram.upperBounds {
+		if value <= upperBound {
+			histogram.counts[i]++
+		}
+	}
+}
+
+func (histogram *Histogram) buckets() map[float64]uint64 {
+	buckets := make(map[float64]uint64, len(histogram.upperBounds))
+	for i, upperBound := range histogram.upperBounds {
+		buckets[upperBound] = histogram.counts[i]
+	}
+	return buckets
+}
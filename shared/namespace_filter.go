@@ -0,0 +1,42 @@
+package shared
+
+import (
+	"regexp"
+)
+
+// NamespaceFilter selects namespaces ("database.collection") with allow and deny regular expressions.
+type NamespaceFilter struct {
+	Allow *regexp.Regexp
+	Deny  *regexp.Regexp
+}
+
+// NewNamespaceFilter compiles the allow and deny expressions, an empty expression is ignored.
+func NewNamespaceFilter(allow string, deny string) (*NamespaceFilter, error) {
+	filter := &NamespaceFilter{}
+	var err error
+	if allow != "" {
+		if filter.Allow, err = regexp.Compile(allow); err != nil {
+			return nil, err
+		}
+	}
+	if deny != "" {
+		if filter.Deny, err = regexp.Compile(deny); err != nil {
+			return nil, err
+		}
+	}
+	return filter, nil
+}
+
+// Match returns true if the namespace matches the allow expression, if any, and does not match the deny expression.
+func (filter *NamespaceFilter) Match(namespace string) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.Allow != nil && !filter.Allow.MatchString(namespace) {
+		return false
+	}
+	if filter.Deny != nil && filter.Deny.MatchString(namespace) {
+		return false
+	}
+	return true
+}
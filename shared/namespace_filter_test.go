@@ -0,0 +1,31 @@
+package shared
+
+import (
+	"testing"
+)
+
+func Test_NamespaceFilter(t *testing.T) {
+	filter, err := NewNamespaceFilter("^app\\.", "\\.system\\.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !filter.Match("app.users") {
+		t.Error("app.users should match.")
+	}
+	if filter.Match("other.users") {
+		t.Error("other.users should not match the allow expression.")
+	}
+	if filter.Match("app.system.profile") {
+		t.Error("app.system.profile should match the deny expression.")
+	}
+
+	var none *NamespaceFilter
+	if !none.Match("any.thing") {
+		t.Error("a nil filter should match everything.")
+	}
+
+	if _, err := NewNamespaceFilter("(", ""); err == nil {
+		t.Error("an invalid expression should fail.")
+	}
+}
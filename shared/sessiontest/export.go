@@ -0,0 +1,76 @@
+package sessiontest
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// exportCollector is a collector exporting the metrics of a stats document.
+type exportCollector struct {
+	descs  *shared.Descs
+	export func(ch *shared.MetricChannel)
+}
+
+func (c *exportCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.descs.Describe(ch)
+}
+
+func (c *exportCollector) Collect(ch chan<- prometheus.Metric) {
+	c.export(c.descs.Channel(ch))
+}
+
+// GatherExport gathers the metrics exported by export through a registry, and returns their values
+// by metric name and label pairs, e.g. `mongodb_mongod_asserts_total{type="regular"}`. The value of a
+// histogram is its number of observations.
+func GatherExport(export func(ch *shared.MetricChannel)) (map[string]float64, error) {
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(&exportCollector{descs: shared.NewDescs(nil), export: export}); err != nil {
+		return nil, err
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			pairs := []string{}
+			for _, label := range metric.Label {
+				pairs = append(pairs, label.GetName()+`="`+label.GetValue()+`"`)
+			}
+			sort.Strings(pairs)
+			name := family.GetName()
+			if len(pairs) > 0 {
+				name += "{" + strings.Join(pairs, ",") + "}"
+			}
+			switch {
+			case metric.Counter != nil:
+				values[name] = metric.Counter.GetValue()
+			case metric.Gauge != nil:
+				values[name] = metric.Gauge.GetValue()
+			case metric.Untyped != nil:
+				values[name] = metric.Untyped.GetValue()
+			case metric.Histogram != nil:
+				values[name] = float64(metric.Histogram.GetSampleCount())
+			}
+		}
+	}
+	return values, nil
+}
+
+// ExpectValues checks the values of the metrics gathered by GatherExport.
+func ExpectValues(t *testing.T, values map[string]float64, expected map[string]float64) {
+	t.Helper()
+	for name, value := range expected {
+		if got, ok := values[name]; !ok {
+			t.Errorf("%s was not exported", name)
+		} else if got != value {
+			t.Errorf("%s: expected %v, got %v", name, value, got)
+		}
+	}
+}
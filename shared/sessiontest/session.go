@@ -0,0 +1,394 @@
+// Package sessiontest provides an in-memory shared.Session, so that the collectors which query a
+// server can be tested without one, and gathers the metrics the collectors export.
+package sessiontest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elarasu/mongodb_exporter/shared"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Session is an in-memory session. Its replies are encoded and decoded with bson like the ones of a server.
+type Session struct {
+	// Collections are the documents of the collections by "database.collection".
+	Collections map[string][]bson.M
+	// Commands are the replies of the commands by "database.command", e.g. "admin.serverStatus".
+	// A reply which is an error is returned by Run.
+	Commands map[string]interface{}
+	// Aggregate returns the results of an aggregation, nil when unset.
+	Aggregate func(database, collection string, pipeline interface{}) []interface{}
+	// FindErrors are the errors of the queries by "database.collection".
+	FindErrors map[string]error
+
+	// Runs are the commands run on the session, by "database.command".
+	Runs []string
+	// Finds are the queries of the session, by "database.collection".
+	Finds []string
+}
+
+// Run replies to the command with Commands.
+func (s *Session) Run(database string, command interface{}, result interface{}) error {
+	name := commandName(command)
+	s.Runs = append(s.Runs, database+"."+name)
+	reply, ok := s.Commands[database+"."+name]
+	if !ok {
+		return fmt.Errorf("no such command: '%s'", name)
+	}
+	if err, ok := reply.(error); ok {
+		return err
+	}
+	return convert(reply, result)
+}
+
+// commandName returns the name of a command: the command itself or its first key.
+func commandName(command interface{}) string {
+	switch command := command.(type) {
+	case string:
+		return command
+	case bson.D:
+		if len(command) > 0 {
+			return command[0].Name
+		}
+	case bson.M:
+		for name := range command {
+			return name
+		}
+	}
+	return ""
+}
+
+// Find returns the documents of the collection matching the filter, which supports the equality of
+// (dotted) fields and the $gt, $gte, $lt, $lte, $ne, $in and $exists operators.
+func (s *Session) Find(database, collection string, filter interface{}) shared.Query {
+	s.Finds = append(s.Finds, database+"."+collection)
+	query := &Query{err: s.FindErrors[database+"."+collection]}
+	var conditions bson.M
+	if err := convert(filter, &conditions); filter != nil && err != nil {
+		query.err = err
+		return query
+	}
+	for _, doc := range s.Collections[database+"."+collection] {
+		if matches(doc, conditions) {
+			query.docs = append(query.docs, doc)
+		}
+	}
+	return query
+}
+
+// Pipe returns the results of Aggregate.
+func (s *Session) Pipe(database, collection string, pipeline interface{}) shared.Pipe {
+	if s.Aggregate == nil {
+		return &Query{}
+	}
+	query := &Query{}
+	for _, doc := range s.Aggregate(database, collection, pipeline) {
+		var m bson.M
+		if err := convert(doc, &m); err != nil {
+			query.err = err
+			break
+		}
+		query.docs = append(query.docs, m)
+	}
+	return query
+}
+
+// DatabaseNames returns the databases of Collections.
+func (s *Session) DatabaseNames() ([]string, error) {
+	names := []string{}
+	seen := map[string]bool{}
+	for ns := range s.Collections {
+		database := strings.SplitN(ns, ".", 2)[0]
+		if !seen[database] {
+			seen[database] = true
+			names = append(names, database)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CollectionNames returns the collections of a database of Collections.
+func (s *Session) CollectionNames(database string) ([]string, error) {
+	names := []string{}
+	for ns := range s.Collections {
+		if strings.HasPrefix(ns, database+".") {
+			names = append(names, strings.TrimPrefix(ns, database+"."))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *Session) SetMode(mode shared.ReadMode) {}
+
+func (s *Session) Ping() error { return nil }
+
+func (s *Session) Copy() shared.Session { return s }
+
+func (s *Session) Close() {}
+
+// Query is the query or aggregation of a Session.
+type Query struct {
+	docs  []bson.M
+	limit int
+	err   error
+}
+
+func (q *Query) Select(projection interface{}) shared.Query { return q }
+
+// Sort sorts the documents by (dotted) fields, descending when prefixed by "-".
+func (q *Query) Sort(fields ...string) shared.Query {
+	sort.SliceStable(q.docs, func(i, j int) bool {
+		for _, field := range fields {
+			direction := 1
+			if strings.HasPrefix(field, "-") {
+				field, direction = field[1:], -1
+			}
+			a, _ := lookup(q.docs[i], field)
+			b, _ := lookup(q.docs[j], field)
+			if c := compare(a, b); c != 0 {
+				return c*direction < 0
+			}
+		}
+		return false
+	})
+	return q
+}
+
+func (q *Query) Limit(n int) shared.Query {
+	q.limit = n
+	return q
+}
+
+func (q *Query) Batch(n int) shared.Query { return q }
+
+func (q *Query) results() []bson.M {
+	if q.limit > 0 && len(q.docs) > q.limit {
+		return q.docs[:q.limit]
+	}
+	return q.docs
+}
+
+func (q *Query) Count() (int, error) {
+	return len(q.results()), q.err
+}
+
+func (q *Query) One(result interface{}) error {
+	if q.err != nil {
+		return q.err
+	}
+	docs := q.results()
+	if len(docs) == 0 {
+		return shared.ErrNotFound
+	}
+	return convert(docs[0], result)
+}
+
+// All decodes the documents into the slice pointed to by result.
+func (q *Query) All(result interface{}) error {
+	if q.err != nil {
+		return q.err
+	}
+	slice := reflect.ValueOf(result).Elem()
+	slice.Set(slice.Slice(0, 0))
+	for _, doc := range q.results() {
+		elem := reflect.New(slice.Type().Elem())
+		if err := convert(doc, elem.Interface()); err != nil {
+			return err
+		}
+		slice.Set(reflect.Append(slice, elem.Elem()))
+	}
+	return nil
+}
+
+func (q *Query) Iter() shared.Iter {
+	return &iter{docs: q.results(), err: q.err}
+}
+
+type iter struct {
+	docs []bson.M
+	err  error
+}
+
+func (it *iter) Next(result interface{}) bool {
+	if it.err != nil || len(it.docs) == 0 {
+		return false
+	}
+	it.err = convert(it.docs[0], result)
+	it.docs = it.docs[1:]
+	return it.err == nil
+}
+
+func (it *iter) Close() error {
+	return it.err
+}
+
+// convert encodes value and decodes it into result, like a reply of a server.
+func convert(value interface{}, result interface{}) error {
+	if value == nil {
+		value = bson.M{}
+	}
+	data, err := bson.Marshal(ordered(value))
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, result)
+}
+
+// ordered returns the value with the keys of its documents sorted, so that a document is encoded
+// with the same bytes every time, like a server replies.
+func ordered(value interface{}) interface{} {
+	switch value := value.(type) {
+	case bson.M:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		doc := make(bson.D, 0, len(keys))
+		for _, key := range keys {
+			doc = append(doc, bson.DocElem{Name: key, Value: ordered(value[key])})
+		}
+		return doc
+	case []interface{}:
+		values := make([]interface{}, len(value))
+		for i, v := range value {
+			values[i] = ordered(v)
+		}
+		return values
+	}
+	return value
+}
+
+// lookup returns the value of a dotted field of a document.
+func lookup(doc bson.M, field string) (interface{}, bool) {
+	var value interface{} = doc
+	for _, key := range strings.Split(field, ".") {
+		m, ok := value.(bson.M)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// matches returns whether the document matches every condition of the filter.
+func matches(doc bson.M, conditions bson.M) bool {
+	for field, condition := range conditions {
+		value, exists := lookup(doc, field)
+		operators, ok := condition.(bson.M)
+		if !ok || !isOperators(operators) {
+			if !exists || compare(value, condition) != 0 {
+				return false
+			}
+			continue
+		}
+		for operator, operand := range operators {
+			if !matchOperator(value, exists, operator, operand) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isOperators(m bson.M) bool {
+	for key := range m {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+	return len(m) > 0
+}
+
+func matchOperator(value interface{}, exists bool, operator string, operand interface{}) bool {
+	switch operator {
+	case "$exists":
+		return exists == (operand == true)
+	case "$ne":
+		return !exists || compare(value, operand) != 0
+	case "$in":
+		values, _ := operand.([]interface{})
+		for _, v := range values {
+			if exists && compare(value, v) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+	if !exists {
+		return false
+	}
+	c := compare(value, operand)
+	switch operator {
+	case "$gt":
+		return c > 0
+	case "$gte":
+		return c >= 0
+	case "$lt":
+		return c < 0
+	case "$lte":
+		return c <= 0
+	}
+	panic(errors.New("sessiontest: unsupported operator " + operator))
+}
+
+// compare compares two values of the same bson kind: numbers, strings, times and timestamps.
+func compare(a, b interface{}) int {
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y)
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			switch {
+			case x.Before(y):
+				return -1
+			case x.After(y):
+				return 1
+			}
+			return 0
+		}
+	}
+	if reflect.DeepEqual(a, b) {
+		return 0
+	}
+	return 1
+}
+
+func number(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case int:
+		return float64(value), true
+	case int32:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	case bson.MongoTimestamp:
+		return float64(value), true
+	}
+	return 0, false
+}
@@ -0,0 +1,174 @@
+package shared
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const srvScheme = "mongodb+srv://"
+
+// DNSResolver resolves the SRV and TXT records of a DNS seedlist.
+type DNSResolver interface {
+	LookupSRV(service, proto, name string) (string, []*net.SRV, error)
+	LookupTXT(name string) ([]string, error)
+}
+
+type netResolver struct{}
+
+func (netResolver) LookupSRV(service, proto, name string) (string, []*net.SRV, error) {
+	return net.LookupSRV(service, proto, name)
+}
+
+func (netResolver) LookupTXT(name string) ([]string, error) {
+	return net.LookupTXT(name)
+}
+
+// DefaultResolver resolves the mongodb+srv:// URIs with the DNS servers of the system.
+var DefaultResolver DNSResolver = netResolver{}
+
+// srvTXTOptions are the options a TXT record of a DNS seedlist may set.
+var srvTXTOptions = map[string]bool{"authSource": true, "replicaSet": true, "loadBalanced": true}
+
+// SRVResolution is a mongodb+srv:// URI resolved to the hosts of its SRV records.
+type SRVResolution struct {
+	// URI is a mongodb:// URI of the hosts, with the options of the TXT record and of the original URI,
+	// but without the tls and ssl options.
+	URI        string
+	Hosts      []string
+	ReplicaSet string
+	TLS        bool
+
+	credentials, path string
+	options           url.Values
+}
+
+// IsSRVURI returns whether the URI is a mongodb+srv:// URI.
+func IsSRVURI(uri string) bool {
+	return strings.HasPrefix(uri, srvScheme)
+}
+
+// ResolveSRVURI resolves a mongodb+srv://[user:pass@]host[/database][?options] URI: the hosts are
+// given by the _mongodb._tcp.host SRV records, and the options of the TXT record of host are merged
+// with the options of the URI, which take precedence. TLS is enabled unless the URI disables it.
+func ResolveSRVURI(uri string, resolver DNSResolver) (*SRVResolution, error) {
+	if !IsSRVURI(uri) {
+		return nil, fmt.Errorf("not a %s URI", srvScheme)
+	}
+	credentials, host, suffix := splitURI(strings.TrimPrefix(uri, srvScheme))
+	if host == "" || strings.ContainsAny(host, ",:") {
+		return nil, errors.New("a mongodb+srv:// URI must have a single host name, without port")
+	}
+	parts := strings.Split(host, ".")
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("the host name %s of a mongodb+srv:// URI must have at least three parts", host)
+	}
+	domain := strings.Join(parts[1:], ".")
+
+	path, query := suffix, ""
+	if i := strings.Index(suffix, "?"); i >= 0 {
+		path, query = suffix[:i], suffix[i+1:]
+	}
+	uriOptions, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the options of the URI: %s", err)
+	}
+
+	_, records, err := resolver.LookupSRV("mongodb", "tcp", host)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve the SRV records of %s: %s", host, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no SRV records for %s", host)
+	}
+	resolution := &SRVResolution{TLS: true}
+	for _, record := range records {
+		target := strings.TrimSuffix(record.Target, ".")
+		if !strings.HasSuffix(target, "."+domain) {
+			return nil, fmt.Errorf("the SRV record %s is not in the domain %s", target, domain)
+		}
+		resolution.Hosts = append(resolution.Hosts, net.JoinHostPort(target, strconv.Itoa(int(record.Port))))
+	}
+
+	options := url.Values{}
+	txt, err := resolver.LookupTXT(host)
+	if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+		txt, err = nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve the TXT record of %s: %s", host, err)
+	}
+	if len(txt) > 1 {
+		return nil, fmt.Errorf("more than one TXT record for %s", host)
+	}
+	if len(txt) == 1 {
+		if options, err = url.ParseQuery(txt[0]); err != nil {
+			return nil, fmt.Errorf("cannot parse the TXT record of %s: %s", host, err)
+		}
+		for name := range options {
+			if !srvTXTOptions[name] {
+				return nil, fmt.Errorf("the TXT record of %s sets the option %s, only authSource, replicaSet and loadBalanced are allowed", host, name)
+			}
+		}
+	}
+	for name, values := range uriOptions {
+		options[name] = values
+	}
+
+	for _, name := range []string{"tls", "ssl"} {
+		if value := options.Get(name); value != "" {
+			if resolution.TLS, err = strconv.ParseBool(value); err != nil {
+				return nil, fmt.Errorf("invalid %s option: %s", name, value)
+			}
+			options.Del(name)
+		}
+	}
+	resolution.ReplicaSet = options.Get("replicaSet")
+	resolution.credentials, resolution.path, resolution.options = credentials, path, options
+	resolution.URI = resolution.uri(resolution.Hosts, options)
+	return resolution, nil
+}
+
+// uri returns the mongodb:// URI of the hosts with the credentials and database of the resolved URI.
+func (resolution *SRVResolution) uri(hosts []string, options url.Values) string {
+	path := resolution.path
+	if len(options) > 0 {
+		if path == "" {
+			path = "/"
+		}
+		path += "?" + options.Encode()
+	}
+	return "mongodb://" + resolution.credentials + strings.Join(hosts, ",") + path
+}
+
+// DialOpts returns the dial options of the resolved hosts: a replica set given by the seedlist
+// is dialed as a replica set, and TLS is enabled with the default configuration unless opts has one.
+func (resolution *SRVResolution) DialOpts(opts MongoSessionOpts) MongoSessionOpts {
+	opts.URI = resolution.URI
+	if opts.ReplicaSet == "" {
+		opts.ReplicaSet = resolution.ReplicaSet
+	}
+	if resolution.TLS && opts.TLSConfig == nil {
+		opts.TLSConfig = &tls.Config{}
+	}
+	return opts
+}
+
+// HostDialOpts returns the dial options of a single resolved host, dialed directly: the replica set
+// of the seedlist is not part of its URI.
+func (resolution *SRVResolution) HostDialOpts(host string, opts MongoSessionOpts) MongoSessionOpts {
+	options := url.Values{}
+	for name, values := range resolution.options {
+		if name != "replicaSet" {
+			options[name] = values
+		}
+	}
+	opts = resolution.DialOpts(opts)
+	opts.URI = resolution.uri([]string{host}, options)
+	opts.ReplicaSet = ""
+	return opts
+}
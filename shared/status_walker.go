@@ -0,0 +1,190 @@
+package shared
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var underscoresRegexp = regexp.MustCompile("_+")
+
+// StatusWalkerOpts are the options of the dynamic serverStatus metrics.
+type StatusWalkerOpts struct {
+	// Prefix is the prefix of the metric names, e.g. mongodb_ss.
+	Prefix string
+	// Prefixes replace the name of top-level sections in the metric names, e.g. wiredTiger: wt.
+	Prefixes map[string]string
+	// Deny matches the dotted paths of the fields not to export, e.g. ^wiredTiger\.LSM\.
+	Deny *regexp.Regexp
+}
+
+// StatusWalker exports every numeric field of a raw status document which is not covered by the
+// hand-written metrics, with a name derived from its path: wiredTiger.cache."bytes read into cache"
+// is exported as <prefix>_wired_tiger_cache_bytes_read_into_cache. The fields decoded by the
+// hand-written status struct are skipped, as are the names of the hand-written metrics.
+type StatusWalker struct {
+	opts StatusWalkerOpts
+	// covered are the paths of the fields of the status struct, coveredTrees the paths of its maps,
+	// slices and interfaces, which cover every field below them.
+	covered      map[string]bool
+	coveredTrees map[string]bool
+
+	mu    sync.Mutex
+	descs map[string]*MetricDesc
+}
+
+// NewStatusWalker returns a walker skipping the fields decoded by the struct status.
+func NewStatusWalker(opts StatusWalkerOpts, status interface{}) *StatusWalker {
+	walker := &StatusWalker{
+		opts:         opts,
+		covered:      map[string]bool{},
+		coveredTrees: map[string]bool{},
+		descs:        map[string]*MetricDesc{},
+	}
+	walker.cover("", reflect.TypeOf(status))
+	return walker
+}
+
+// cover records the bson paths of the fields of the type.
+func (walker *StatusWalker) cover(path string, typ reflect.Type) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		if typ.PkgPath() == "time" {
+			walker.covered[path] = true
+			return
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			tag := strings.Split(field.Tag.Get("bson"), ",")
+			name := tag[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			inline := false
+			for _, flag := range tag[1:] {
+				inline = inline || flag == "inline"
+			}
+			switch {
+			case inline:
+				walker.cover(path, field.Type)
+			case path == "":
+				walker.cover(name, field.Type)
+			default:
+				walker.cover(path+"."+name, field.Type)
+			}
+		}
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Interface:
+		walker.coveredTrees[path] = true
+	default:
+		walker.covered[path] = true
+	}
+}
+
+// isCovered returns whether the field of the path is decoded by the status struct.
+func (walker *StatusWalker) isCovered(path string) bool {
+	if walker.covered[path] {
+		return true
+	}
+	for tree := path; ; {
+		if walker.coveredTrees[tree] {
+			return true
+		}
+		i := strings.LastIndex(tree, ".")
+		if i < 0 {
+			return false
+		}
+		tree = tree[:i]
+	}
+}
+
+// MetricName returns the name of the metric of the field of the path.
+func (walker *StatusWalker) MetricName(path []string) string {
+	parts := []string{ParameterizeString(walker.opts.Prefix)}
+	for i, name := range path {
+		if prefix, ok := walker.opts.Prefixes[name]; ok && i == 0 {
+			parts = append(parts, ParameterizeString(prefix))
+			continue
+		}
+		parts = append(parts, SnakeCase(name))
+	}
+	name := underscoresRegexp.ReplaceAllString(strings.Join(parts, "_"), "_")
+	return strings.Trim(name, "_")
+}
+
+// desc returns the definition of the dynamic metric of the name.
+func (walker *StatusWalker) desc(name, path string) *MetricDesc {
+	walker.mu.Lock()
+	defer walker.mu.Unlock()
+
+	def, ok := walker.descs[name]
+	if !ok {
+		def = NewDynamicDesc(name, "serverStatus field "+path, prometheus.UntypedValue, nil)
+		walker.descs[name] = def
+	}
+	return def
+}
+
+// Export exports the numeric fields of the raw status document.
+func (walker *StatusWalker) Export(ch *MetricChannel, status bson.Raw) {
+	doc := bson.D{}
+	if err := status.Unmarshal(&doc); err != nil {
+		glog.Errorf("Cannot decode the server status: %s", err)
+		return
+	}
+	walker.walk(ch, nil, doc, map[string]bool{})
+}
+
+func (walker *StatusWalker) walk(ch *MetricChannel, path []string, value interface{}, seen map[string]bool) {
+	switch value := value.(type) {
+	case bson.D:
+		for _, elem := range value {
+			walker.walk(ch, append(path[:len(path):len(path)], elem.Name), elem.Value, seen)
+		}
+	case bson.M:
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			walker.walk(ch, append(path[:len(path):len(path)], name), value[name], seen)
+		}
+	case int:
+		walker.send(ch, path, float64(value), seen)
+	case int32:
+		walker.send(ch, path, float64(value), seen)
+	case int64:
+		walker.send(ch, path, float64(value), seen)
+	case float64:
+		walker.send(ch, path, value, seen)
+	}
+}
+
+// send exports a numeric field, unless it is covered, denied, or its name is taken.
+func (walker *StatusWalker) send(ch *MetricChannel, path []string, value float64, seen map[string]bool) {
+	dotted := strings.Join(path, ".")
+	if walker.isCovered(dotted) || walker.opts.Deny != nil && walker.opts.Deny.MatchString(dotted) {
+		return
+	}
+	name := walker.MetricName(path)
+	if seen[name] || IsMetricDefined(name) {
+		return
+	}
+	seen[name] = true
+	ch.Send(walker.desc(name, dotted), value)
+}
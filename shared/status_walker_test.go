@@ -0,0 +1,91 @@
+package shared
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type walkerTestStatus struct {
+	Covered struct {
+		Count int64 `bson:"count"`
+	} `bson:"covered"`
+	Tree    map[string]int64 `bson:"tree"`
+	Ignored int64            `bson:"-"`
+}
+
+var walkerTestMetric = NewGaugeDesc(prometheus.GaugeOpts{
+	Name: "walker_test_taken",
+	Help: "A hand-written metric",
+}, nil)
+
+func Test_StatusWalkerMetricName(t *testing.T) {
+	walker := NewStatusWalker(StatusWalkerOpts{
+		Prefix:   "mongodb_ss",
+		Prefixes: map[string]string{"wiredTiger": "wt"},
+	}, walkerTestStatus{})
+	for path, expected := range map[string][]string{
+		"mongodb_ss_wt_cache_bytes_read_into_cache":         {"wiredTiger", "cache", "bytes read into cache"},
+		"mongodb_ss_metrics_query_executor_scanned_objects": {"metrics", "queryExecutor", "scannedObjects"},
+		"mongodb_ss_network_compression_snappy":             {"network", "compression", "snappy"},
+		"mongodb_ss_tcmalloc_generic_heap_size":             {"tcmalloc", "generic", "heap_size"},
+		"mongodb_ss_metrics_wired_tiger_cache":              {"metrics", "wiredTiger", "cache"},
+	} {
+		if name := walker.MetricName(expected); name != path {
+			t.Errorf("expected %v to be named %s, got %s", expected, path, name)
+		}
+	}
+}
+
+// walkerCollector exports the dynamic metrics of a status document, as an unchecked collector.
+type walkerCollector struct {
+	walker *StatusWalker
+	status bson.Raw
+}
+
+func (c *walkerCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c *walkerCollector) Collect(ch chan<- prometheus.Metric) {
+	c.walker.Export(NewDescs(nil).Channel(ch), c.status)
+}
+
+func Test_StatusWalkerExport(t *testing.T) {
+	data, err := bson.Marshal(bson.D{
+		{"covered", bson.D{{"count", int64(1)}, {"other", 2}}},
+		{"tree", bson.D{{"a", 3}}},
+		{"ignored", 4.5},
+		{"walker", bson.D{{"test", bson.D{{"taken", 5}}}}},
+		{"flag", true},
+		{"list", []int{6}},
+		{"denied", bson.D{{"count", 7}}},
+		{"a_bc", 8},
+		{"aBc", 9},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	walker := NewStatusWalker(StatusWalkerOpts{Deny: regexp.MustCompile(`^denied\.`)}, walkerTestStatus{})
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(&walkerCollector{walker: walker, status: bson.Raw{Kind: 3, Data: data}}); err != nil {
+		t.Fatal(err)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]float64{}
+	for _, family := range families {
+		values[family.GetName()] = family.Metric[0].GetUntyped().GetValue()
+	}
+
+	// covered.count and tree.* are decoded by the status struct, walker_test_taken is a hand-written
+	// metric, aBc is named like a_bc, which comes first
+	expected := map[string]float64{"covered_other": 2, "ignored": 4.5, "a_bc": 8}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v to be exported, got %v", expected, values)
+	}
+}
@@ -0,0 +1,307 @@
+package shared
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/youmark/pkcs8"
+)
+
+// AuthMechanismX509 authenticates with the subject of the TLS client certificate.
+const AuthMechanismX509 = "MONGODB-X509"
+
+// TLSOptions are the TLS options of the connection to MongoDB, named after the tls* URI options of the drivers.
+type TLSOptions struct {
+	// CAFile is a PEM bundle of the certificate authorities of the servers (empty = the system ones).
+	CAFile string
+	// CertificateKeyFile is a PEM file with the client certificate and its private key.
+	CertificateKeyFile string
+	// CertFile and KeyFile are the client certificate and its private key in separate PEM files,
+	// instead of CertificateKeyFile.
+	CertFile string
+	KeyFile  string
+	// KeyPassword decrypts an encrypted private key.
+	KeyPassword string
+	// AllowInvalidHostnames verifies the certificate of the servers but not their host name.
+	AllowInvalidHostnames bool
+	// InsecureSkipVerify does not verify the certificate of the servers at all.
+	InsecureSkipVerify bool
+}
+
+// tlsFiles holds the certificates loaded from the files of TLSOptions, and reloads them when the files change.
+type tlsFiles struct {
+	opts TLSOptions
+
+	mu       sync.Mutex
+	modTimes map[string]time.Time
+	roots    *x509.CertPool
+	cert     *tls.Certificate
+}
+
+// NewTLSConfig returns the client TLS config of opts. The certificate authorities and the client
+// certificate are read again whenever their files change on disk, so that rotated certificates are
+// used by the next connections. The certificate of the server is verified by the config itself,
+// so it must be dialed with DialTLS, which tells it the host name of the server.
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, errors.New("the client certificate and key files must be set together")
+	}
+	if opts.CertificateKeyFile != "" && opts.CertFile != "" {
+		return nil, errors.New("the certificate key file and the client certificate and key files are mutually exclusive")
+	}
+
+	files := &tlsFiles{opts: opts}
+	if err := files.load(); err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		// the server certificate is verified by VerifyConnection, with the current certificate authorities
+		InsecureSkipVerify: true,
+	}
+	if !opts.InsecureSkipVerify {
+		config.VerifyConnection = files.verifyConnection
+	}
+	if files.cert != nil {
+		config.GetClientCertificate = files.clientCertificate
+	}
+	return config, nil
+}
+
+// paths returns the files of the options.
+func (files *tlsFiles) paths() []string {
+	paths := []string{}
+	for _, path := range []string{files.opts.CAFile, files.opts.CertificateKeyFile, files.opts.CertFile, files.opts.KeyFile} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// load reads the certificate authorities and the client certificate.
+func (files *tlsFiles) load() error {
+	modTimes := map[string]time.Time{}
+	for _, path := range files.paths() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		modTimes[path] = info.ModTime()
+	}
+
+	var roots *x509.CertPool
+	if files.opts.CAFile != "" {
+		ca, err := ioutil.ReadFile(files.opts.CAFile)
+		if err != nil {
+			return fmt.Errorf("cannot read CA file: %s", err)
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(ca) {
+			return errors.New("no certificates found in CA file")
+		}
+	}
+
+	var cert *tls.Certificate
+	certFile, keyFile := files.opts.CertFile, files.opts.KeyFile
+	if files.opts.CertificateKeyFile != "" {
+		certFile, keyFile = files.opts.CertificateKeyFile, files.opts.CertificateKeyFile
+	}
+	if certFile != "" {
+		loaded, err := loadKeyPair(certFile, keyFile, files.opts.KeyPassword)
+		if err != nil {
+			return fmt.Errorf("cannot load client certificate: %s", err)
+		}
+		cert = &loaded
+	}
+
+	files.modTimes, files.roots, files.cert = modTimes, roots, cert
+	return nil
+}
+
+// refresh reloads the files when one of them changed, and keeps the previous certificates
+// when they cannot be reloaded, e.g. while the files are being rotated.
+func (files *tlsFiles) refresh() (*x509.CertPool, *tls.Certificate) {
+	files.mu.Lock()
+	defer files.mu.Unlock()
+
+	for _, path := range files.paths() {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Equal(files.modTimes[path]) {
+			continue
+		}
+		if err := files.load(); err != nil {
+			glog.Errorf("Cannot reload the TLS certificates, keeping the previous ones: %s", err)
+		} else {
+			glog.Infof("Reloaded the TLS certificates of the MongoDB connection")
+		}
+		break
+	}
+	return files.roots, files.cert
+}
+
+func (files *tlsFiles) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	_, cert := files.refresh()
+	return cert, nil
+}
+
+// verifyConnection verifies the certificate chain of the server with the current certificate
+// authorities, and its host name unless AllowInvalidHostnames is set.
+func (files *tlsFiles) verifyConnection(state tls.ConnectionState) error {
+	roots, _ := files.refresh()
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("the server sent no certificate")
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	if !files.opts.AllowInvalidHostnames {
+		if state.ServerName == "" {
+			return errors.New("cannot verify the host name of the server, dial it with DialTLS")
+		}
+		opts.DNSName = state.ServerName
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := state.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// loadKeyPair loads a PEM certificate and its private key, decrypting the key with password
+// when it is encrypted.
+func loadKeyPair(certFile, keyFile, password string) (tls.Certificate, error) {
+	certData, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyData := certData
+	if keyFile != certFile {
+		if keyData, err = ioutil.ReadFile(keyFile); err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+
+	var certPEM, keyPEM []byte
+	for block, rest := pem.Decode(certData); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			certPEM = append(certPEM, pem.EncodeToMemory(block)...)
+		}
+	}
+	for block, rest := pem.Decode(keyData); block != nil; block, rest = pem.Decode(rest) {
+		if !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			continue
+		}
+		encrypted := block.Type == "ENCRYPTED PRIVATE KEY" || x509.IsEncryptedPEMBlock(block)
+		if encrypted && password == "" {
+			return tls.Certificate{}, errors.New("the private key is encrypted, a key password is required")
+		}
+		switch {
+		case block.Type == "ENCRYPTED PRIVATE KEY":
+			// a PKCS#8 key encrypted with PBES2, e.g. by "openssl pkcs8 -topk8 -v2 aes256"
+			key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(password))
+			if err != nil {
+				return tls.Certificate{}, fmt.Errorf("cannot decrypt the private key: %s", err)
+			}
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			if err != nil {
+				return tls.Certificate{}, fmt.Errorf("cannot decrypt the private key: %s", err)
+			}
+			block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+		case encrypted:
+			// the legacy PEM encryption of "openssl rsa -aes256", which the MongoDB tools use for key files
+			der, err := x509.DecryptPEMBlock(block, []byte(password))
+			if err != nil {
+				return tls.Certificate{}, fmt.Errorf("cannot decrypt the private key: %s", err)
+			}
+			block = &pem.Block{Type: block.Type, Bytes: der}
+		}
+		keyPEM = pem.EncodeToMemory(block)
+		break
+	}
+	if certPEM == nil {
+		return tls.Certificate{}, fmt.Errorf("no certificate found in %s", certFile)
+	}
+	if keyPEM == nil {
+		return tls.Certificate{}, fmt.Errorf("no private key found in %s", keyFile)
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// DialTLS dials addr over TLS with config, setting the host name of the server to verify its certificate.
+func DialTLS(ctx context.Context, dialer *net.Dialer, network, addr string, config *tls.Config) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	config = config.Clone()
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	if verify := config.VerifyConnection; verify != nil {
+		// the TLS client leaves the server name of the connection empty for an IP address
+		serverName := config.ServerName
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			if state.ServerName == "" {
+				state.ServerName = serverName
+			}
+			return verify(state)
+		}
+	}
+
+	raw, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := tls.Client(raw, config)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		raw.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// tlsDialer dials the servers of the official driver with DialTLS.
+type tlsDialer struct {
+	dialer *net.Dialer
+	config *tls.Config
+}
+
+func (d *tlsDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return DialTLS(ctx, d.dialer, network, addr, d.config)
+}
+
+// X509Username returns the user of MONGODB-X509 authentication, the subject of the client
+// certificate of config in the RFC 2253 format.
+func X509Username(config *tls.Config) (string, error) {
+	var cert *tls.Certificate
+	switch {
+	case config == nil:
+	case config.GetClientCertificate != nil:
+		var err error
+		if cert, err = config.GetClientCertificate(&tls.CertificateRequestInfo{}); err != nil {
+			return "", err
+		}
+	case len(config.Certificates) > 0:
+		cert = &config.Certificates[0]
+	}
+	if cert == nil || len(cert.Certificate) == 0 {
+		return "", errors.New("MONGODB-X509 authentication requires a TLS client certificate")
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return "", err
+	}
+	return leaf.Subject.String(), nil
+}
@@ -0,0 +1,260 @@
+package shared
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/youmark/pkcs8"
+)
+
+// testCert is a certificate with its key, signed by parent (self-signed when parent is nil).
+type testCert struct {
+	cert *x509.Certificate
+	der  []byte
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, subject pkix.Name, hosts []string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, der: der, key: key}
+}
+
+func (c *testCert) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der})
+}
+
+func (c *testCert) keyPEM(t *testing.T) []byte {
+	der, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// serveTLS accepts TLS connections with cert, requiring a client certificate signed by clientCA,
+// and returns its address.
+func serveTLS(t *testing.T, cert *testCert, clientCA *testCert) string {
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCA.cert)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert.tlsCertificate()},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return listener.Addr().String()
+}
+
+func dialTestTLS(addr string, config *tls.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := DialTLS(ctx, &net.Dialer{}, "tcp", addr, config)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func Test_NewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, pkix.Name{CommonName: "ca"}, nil, nil)
+	server := newTestCert(t, pkix.Name{CommonName: "server"}, []string{"127.0.0.1"}, ca)
+	otherServer := newTestCert(t, pkix.Name{CommonName: "other"}, []string{"mongo.example.com"}, ca)
+	client := newTestCert(t, pkix.Name{CommonName: "exporter", OrganizationalUnit: []string{"monitoring"}}, nil, ca)
+
+	caFile := writeTestFile(t, dir, "ca.pem", ca.certPEM())
+	certKeyFile := writeTestFile(t, dir, "client.pem", append(client.certPEM(), client.keyPEM(t)...))
+
+	config, err := NewTLSConfig(TLSOptions{CAFile: caFile, CertificateKeyFile: certKeyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dialTestTLS(serveTLS(t, server, ca), config); err != nil {
+		t.Errorf("expected the server certificate to be trusted, got %s", err)
+	}
+
+	addr := serveTLS(t, otherServer, ca)
+	if err := dialTestTLS(addr, config); err == nil {
+		t.Error("expected a certificate for another host name to be rejected")
+	}
+	config, err = NewTLSConfig(TLSOptions{CAFile: caFile, CertificateKeyFile: certKeyFile, AllowInvalidHostnames: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dialTestTLS(addr, config); err != nil {
+		t.Errorf("expected the host name not to be verified, got %s", err)
+	}
+
+	untrusted := newTestCert(t, pkix.Name{CommonName: "untrusted"}, []string{"127.0.0.1"}, nil)
+	if err := dialTestTLS(serveTLS(t, untrusted, ca), config); err == nil {
+		t.Error("expected a certificate of another authority to be rejected")
+	}
+
+	username, err := X509Username(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if username != "CN=exporter,OU=monitoring" {
+		t.Errorf("unexpected X.509 user %q", username)
+	}
+}
+
+func Test_NewTLSConfigReload(t *testing.T) {
+	dir := t.TempDir()
+	oldCA := newTestCert(t, pkix.Name{CommonName: "old ca"}, nil, nil)
+	newCA := newTestCert(t, pkix.Name{CommonName: "new ca"}, nil, nil)
+	client := newTestCert(t, pkix.Name{CommonName: "old client"}, nil, oldCA)
+	caFile := writeTestFile(t, dir, "ca.pem", oldCA.certPEM())
+	certKeyFile := writeTestFile(t, dir, "client.pem", append(client.certPEM(), client.keyPEM(t)...))
+
+	config, err := NewTLSConfig(TLSOptions{CAFile: caFile, CertificateKeyFile: certKeyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := serveTLS(t, newTestCert(t, pkix.Name{CommonName: "server"}, []string{"127.0.0.1"}, newCA), newCA)
+	if err := dialTestTLS(addr, config); err == nil {
+		t.Fatal("expected the server of the new authority to be rejected before the rotation")
+	}
+
+	// rotate the certificates, with modification times the file system cannot confuse with the previous ones
+	client = newTestCert(t, pkix.Name{CommonName: "new client"}, nil, newCA)
+	writeTestFile(t, dir, "ca.pem", newCA.certPEM())
+	writeTestFile(t, dir, "client.pem", append(client.certPEM(), client.keyPEM(t)...))
+	later := time.Now().Add(time.Minute)
+	for _, path := range []string{caFile, certKeyFile} {
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := dialTestTLS(addr, config); err != nil {
+		t.Errorf("expected the rotated certificates to be used, got %s", err)
+	}
+	if username, _ := X509Username(config); username != "CN=new client" {
+		t.Errorf("unexpected X.509 user %q after the rotation", username)
+	}
+
+	// a broken file keeps the previous certificates
+	writeTestFile(t, dir, "ca.pem", []byte("garbage"))
+	later = later.Add(time.Minute)
+	os.Chtimes(caFile, later, later)
+	if err := dialTestTLS(addr, config); err != nil {
+		t.Errorf("expected the previous certificates to be kept, got %s", err)
+	}
+}
+
+func Test_LoadKeyPairEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "exporter"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key), []byte("s3cr3t"), x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8DER, err := pkcs8.MarshalPrivateKey(key, []byte("s3cr3t"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := writeTestFile(t, dir, "client.crt", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	for name, keyPEM := range map[string][]byte{
+		"client.key":       pem.EncodeToMemory(block),
+		"client-pkcs8.key": pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: pkcs8DER}),
+	} {
+		keyFile := writeTestFile(t, dir, name, keyPEM)
+		if _, err := loadKeyPair(certFile, keyFile, "s3cr3t"); err != nil {
+			t.Errorf("expected the encrypted key of %s to be decrypted, got %s", name, err)
+		}
+		if _, err := loadKeyPair(certFile, keyFile, ""); err == nil {
+			t.Errorf("expected an error without password for %s", name)
+		}
+		if _, err := loadKeyPair(certFile, keyFile, "wrong"); err == nil {
+			t.Errorf("expected an error with a wrong password for %s", name)
+		}
+	}
+	if _, err := NewTLSConfig(TLSOptions{CertFile: certFile}); err == nil {
+		t.Error("expected an error for a certificate without key")
+	}
+}
@@ -3,7 +3,6 @@ package shared
 import (
 	"regexp"
 	"strings"
-	"strconv"
 )
 
 var (
@@ -26,16 +25,11 @@ func ParameterizeString(text string) string {
 	return strings.ToLower(result)
 }
 
+// IsVersionGreater returns whether the version is major.minor.release or later, false when it cannot be parsed.
 func IsVersionGreater(version string, major int, minor int, release int) bool {
-	split := strings.Split(version, ".")
-	cmp_major, _ := strconv.Atoi(split[0])
-	cmp_minor, _ := strconv.Atoi(split[1])
-	cmp_release, _ := strconv.Atoi(split[2])
-
-	if cmp_major >= major && cmp_minor >= minor && cmp_release >= release {
-		return true
+	v, err := ParseVersion(version)
+	if err != nil {
+		return false
 	}
-
-	return false
+	return v.AtLeast(Version{Major: major, Minor: minor, Patch: release})
 }
-
@@ -31,3 +31,23 @@ func Test_ParameterizeString(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_IsVersionGreater(t *testing.T) {
+	tests := []struct {
+		version             string
+		major, minor, patch int
+		expected            bool
+	}{
+		{"4.0.0", 3, 6, 5, true},
+		{"3.6.5", 3, 6, 5, true},
+		{"3.4.10", 3, 6, 0, false},
+		{"4.2", 4, 2, 0, true},
+		{"4.4.0-rc1", 4, 4, 0, true},
+		{"unknown", 3, 0, 0, false},
+	}
+	for _, test := range tests {
+		if IsVersionGreater(test.version, test.major, test.minor, test.patch) != test.expected {
+			t.Errorf("IsVersionGreater(%q, %d, %d, %d) should be %v", test.version, test.major, test.minor, test.patch, test.expected)
+		}
+	}
+}
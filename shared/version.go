@@ -0,0 +1,85 @@
+package shared
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of a MongoDB server, e.g. 4.4.0-rc1.
+type Version struct {
+	Major, Minor, Patch int
+	// PreRelease is the part after the first "-", e.g. rc1, empty for a release.
+	PreRelease string
+}
+
+// ParseVersion parses a version of the form major.minor[.patch][-prerelease], e.g. 4.2 or 4.4.0-rc1.
+func ParseVersion(version string) (Version, error) {
+	v := Version{}
+	release := version
+	if i := strings.Index(version, "-"); i >= 0 {
+		release, v.PreRelease = version[:i], version[i+1:]
+	}
+	parts := strings.Split(release, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid version %q", version)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", version)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// Release returns the version without its pre-release.
+func (v Version) Release() Version {
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+}
+
+// IsZero returns whether the version is 0.0.0, which stands for no version.
+func (v Version) IsZero() bool {
+	return v == Version{}
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or greater than other. A pre-release
+// is lower than its release, e.g. 4.4.0-rc1 < 4.4.0, and pre-releases are compared as strings.
+func (v Version) Compare(other Version) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		switch {
+		case diff < 0:
+			return -1
+		case diff > 0:
+			return 1
+		}
+	}
+	switch {
+	case v.PreRelease == other.PreRelease:
+		return 0
+	case v.PreRelease == "":
+		return 1
+	case other.PreRelease == "":
+		return -1
+	case v.PreRelease < other.PreRelease:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// AtLeast returns whether the release of v is other or later, so that the pre-releases of a version
+// have its features: 4.2.0-rc1 is at least 4.2.0.
+func (v Version) AtLeast(other Version) bool {
+	return v.Release().Compare(other.Release()) >= 0
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
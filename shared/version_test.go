@@ -0,0 +1,49 @@
+package shared
+
+import (
+	"testing"
+)
+
+func Test_ParseVersion(t *testing.T) {
+	tests := map[string]Version{
+		"4.2":         {Major: 4, Minor: 2},
+		"3.6.5":       {Major: 3, Minor: 6, Patch: 5},
+		"4.4.0-rc1":   {Major: 4, Minor: 4, PreRelease: "rc1"},
+		"5.0.0-alpha": {Major: 5, PreRelease: "alpha"},
+	}
+	for s, expected := range tests {
+		v, err := ParseVersion(s)
+		if err != nil || v != expected {
+			t.Errorf("ParseVersion(%q) = %v, %v, expected %v", s, v, err, expected)
+		}
+	}
+	for _, s := range []string{"", "unknown", "4", "4.x", "1.2.3.4", "-1.0"} {
+		if _, err := ParseVersion(s); err == nil {
+			t.Errorf("ParseVersion(%q) should fail", s)
+		}
+	}
+}
+
+func Test_VersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"4.0.0", "3.6.5", 1},
+		{"3.6.5", "3.6.5", 0},
+		{"3.4.10", "3.6.0", -1},
+		{"4.2", "4.2.0", 0},
+		{"4.4.0-rc1", "4.4.0", -1},
+		{"4.4.0-rc2", "4.4.0-rc1", 1},
+	}
+	for _, test := range tests {
+		a, _ := ParseVersion(test.a)
+		b, _ := ParseVersion(test.b)
+		if a.Compare(b) != test.expected {
+			t.Errorf("%s compared to %s should be %d", test.a, test.b, test.expected)
+		}
+	}
+	if v, _ := ParseVersion("4.4.0-rc1"); !v.AtLeast(Version{Major: 4, Minor: 4}) {
+		t.Error("4.4.0-rc1 should be at least 4.4.0")
+	}
+}